@@ -0,0 +1,221 @@
+package formats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// FIT is the binary activity file format of Garmin and other sports watches and bike computers.
+// Only the position records of the activities are read, just enough to get the track.
+// See https://developer.garmin.com/fit/protocol/
+
+// fitEpoch is the start of the FIT timestamps, i.e. 1989-12-31T00:00:00Z.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+const (
+	fitRecord           = 20  // global message number of the record message with the position, altitude, speed, etc
+	fitTimestamp        = 253 // field number of the timestamp, common to all messages
+	fitPositionLat      = 0   // field number of the record latitude in semicircles
+	fitPositionLong     = 1   // field number of the record longitude in semicircles
+	fitAltitude         = 2   // field number of the record altitude in 1/5m with 500m offset
+	fitEnhancedAltitude = 78  // field number of the 32-bit record altitude
+)
+
+var errFITCorrupt = errors.New("corrupt FIT file")
+
+// fitDefinition describes the layout of the data messages of a local message type.
+type fitDefinition struct {
+	global    int // global message number
+	bigEndian bool
+	fields    []fitField
+	devSize   int // total size of the developer fields, which are skipped
+}
+
+type fitField struct {
+	num, size int
+	baseType  byte
+}
+
+// ReadFIT reads the track of a FIT activity file.
+func ReadFIT(fn string) (*Source, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	points, err := fitPoints(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%s: no positions in the FIT file", fn)
+	}
+	name := filepath.Base(fn)
+	g := &gpx.GPX{Version: "1.1", Creator: "FIT"}
+	g.AppendTrack(&gpx.GPXTrack{Name: name, Segments: []gpx.GPXTrackSegment{{Points: points}}})
+	return &Source{GPX: g, Filename: name, extensions: make(map[gpxPointKey][]byte)}, nil
+}
+
+// fitPoints decodes the record messages with a position from the FIT data,
+// which can be several FIT files chained one after another.
+func fitPoints(b []byte) (points []gpx.GPXPoint, err error) {
+	for len(b) > 0 {
+		if len(b) < 12 || string(b[8:12]) != ".FIT" {
+			if points != nil {
+				break // trailing garbage after a complete file
+			}
+			return nil, errors.New("not a FIT file")
+		}
+		hsize, size := int(b[0]), int(binary.LittleEndian.Uint32(b[4:]))
+		if hsize < 12 || hsize+size > len(b) {
+			return nil, errFITCorrupt
+		}
+		ps, err := fitRecords(b[hsize : hsize+size])
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, ps...)
+		// skip the CRC at the end of the file
+		b = b[min(len(b), hsize+size+2):]
+	}
+	return points, nil
+}
+
+// fitRecords decodes the data records of a FIT file.
+func fitRecords(b []byte) (points []gpx.GPXPoint, err error) {
+	var defs [16]*fitDefinition
+	var last uint32 // last full timestamp for the compressed timestamp headers
+	for off := 0; off < len(b); {
+		h := b[off]
+		off++
+		local, compressed := int(h&0x0f), false
+		if h&0x80 != 0 { // compressed timestamp header
+			local, compressed = int(h>>5&0x03), true
+			offset := uint32(h & 0x1f)
+			if offset < last&0x1f {
+				last += 0x20
+			}
+			last = last&^0x1f | offset
+		} else if h&0x40 != 0 { // definition message
+			def, n, err := fitDefine(b[off:], h&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			defs[local] = def
+			off += n
+			continue
+		}
+		def := defs[local]
+		if def == nil {
+			return nil, errFITCorrupt
+		}
+		var lat, lon, ele *int64
+		var ts *uint32
+		for _, f := range def.fields {
+			if off+f.size > len(b) {
+				return nil, errFITCorrupt
+			}
+			v, ok := fitValue(b[off:off+f.size], f.baseType, def.bigEndian)
+			off += f.size
+			if !ok || def.global != fitRecord && f.num != fitTimestamp {
+				continue
+			}
+			switch f.num {
+			case fitTimestamp:
+				t := uint32(v)
+				ts = &t
+			case fitPositionLat:
+				lat = &v
+			case fitPositionLong:
+				lon = &v
+			case fitAltitude, fitEnhancedAltitude:
+				ele = &v
+			}
+		}
+		off += def.devSize
+		if ts != nil {
+			last = *ts
+		} else if !compressed {
+			continue
+		}
+		if def.global != fitRecord || lat == nil || lon == nil {
+			continue
+		}
+		p := gpx.GPXPoint{Timestamp: fitEpoch.Add(time.Duration(last) * time.Second)}
+		p.Latitude = float64(*lat) * 180 / (1 << 31)
+		p.Longitude = float64(*lon) * 180 / (1 << 31)
+		if ele != nil {
+			p.Elevation = *gpx.NewNullableFloat64(float64(*ele)/5 - 500)
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// fitDefine decodes a definition message, returns the definition and the size of the message.
+func fitDefine(b []byte, dev bool) (*fitDefinition, int, error) {
+	if len(b) < 5 {
+		return nil, 0, errFITCorrupt
+	}
+	def := &fitDefinition{bigEndian: b[1] == 1}
+	if def.bigEndian {
+		def.global = int(binary.BigEndian.Uint16(b[2:]))
+	} else {
+		def.global = int(binary.LittleEndian.Uint16(b[2:]))
+	}
+	n := 5 + 3*int(b[4])
+	if n > len(b) {
+		return nil, 0, errFITCorrupt
+	}
+	for i := 5; i < n; i += 3 {
+		def.fields = append(def.fields, fitField{num: int(b[i]), size: int(b[i+1]), baseType: b[i+2]})
+	}
+	if dev {
+		if n >= len(b) {
+			return nil, 0, errFITCorrupt
+		}
+		end := n + 1 + 3*int(b[n])
+		if end > len(b) {
+			return nil, 0, errFITCorrupt
+		}
+		for i := n + 1; i < end; i += 3 {
+			def.devSize += int(b[i+1])
+		}
+		n = end
+	}
+	return def, n, nil
+}
+
+// fitValue decodes an integer field value of 1, 2 or 4 bytes of the base type,
+// returns false for other sizes and for the invalid value of the base type.
+func fitValue(b []byte, baseType byte, bigEndian bool) (int64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	var v uint64
+	switch len(b) {
+	case 1:
+		v = uint64(b[0])
+	case 2:
+		v = uint64(order.Uint16(b))
+	case 4:
+		v = uint64(order.Uint32(b))
+	default:
+		return 0, false
+	}
+	bits := 8 * len(b)
+	switch baseType & 0x1f {
+	case 0x01, 0x03, 0x05: // sint8, sint16, sint32, the invalid value is the maximum
+		return int64(v<<(64-bits)) >> (64 - bits), v != 1<<(bits-1)-1
+	case 0x0a, 0x0b, 0x0c: // uint8z, uint16z, uint32z, the invalid value is zero
+		return int64(v), v != 0
+	default: // the invalid value of the unsigned types and enums has all bits set
+		return int64(v), v != 1<<bits-1
+	}
+}
@@ -0,0 +1,80 @@
+package formats
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+// fitTestFile builds a FIT file with a file_id message and a record message for each position,
+// every other record uses the compressed timestamp header.
+func fitTestFile(start time.Time, positions [][2]float64) []byte {
+	semicircles := func(deg float64) uint32 { return uint32(int32(math.Round(deg * (1 << 31) / 180))) }
+	ts := uint32(start.Sub(fitEpoch).Seconds())
+	var data []byte
+	// file_id (global 0) with a developer field, local type 0
+	data = append(data, 0x60, 0, 0, 0, 0, 1, 4, 4, 0x86, 1, 0, 2, 0)
+	data = append(data, 0x00)
+	data = binary.LittleEndian.AppendUint32(data, ts)
+	data = append(data, 0xab, 0xcd)
+	// record (global 20) with timestamp, position and altitude, local type 1
+	data = append(data, 0x41, 0, 0, 20, 0, 4, 253, 4, 0x86, 0, 4, 0x85, 1, 4, 0x85, 2, 2, 0x84)
+	// record without timestamp for the compressed timestamp headers, local type 2
+	data = append(data, 0x42, 0, 0, 20, 0, 3, 0, 4, 0x85, 1, 4, 0x85, 2, 2, 0x84)
+	for i, p := range positions {
+		t := ts + uint32(i)*2
+		if i%2 == 0 {
+			data = append(data, 0x01)
+			data = binary.LittleEndian.AppendUint32(data, t)
+		} else {
+			data = append(data, 0x80|2<<5|byte(t&0x1f))
+		}
+		data = binary.LittleEndian.AppendUint32(data, semicircles(p[0]))
+		data = binary.LittleEndian.AppendUint32(data, semicircles(p[1]))
+		data = binary.LittleEndian.AppendUint16(data, uint16((80+500)*5))
+	}
+	// record with invalid position is skipped
+	data = append(data, 0x01)
+	data = binary.LittleEndian.AppendUint32(data, ts+uint32(len(positions))*2)
+	data = binary.LittleEndian.AppendUint32(data, 0x7fffffff)
+	data = binary.LittleEndian.AppendUint32(data, 0x7fffffff)
+	data = binary.LittleEndian.AppendUint16(data, 0xffff)
+	b := []byte{14, 0x20}
+	b = binary.LittleEndian.AppendUint16(b, 2132)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, ".FIT"...)
+	b = append(b, 0, 0) // header CRC, not checked
+	b = append(b, data...)
+	return append(b, 0, 0) // file CRC, not checked
+}
+
+func Test_ReadFIT(t *testing.T) {
+	start := time.Date(2024, 8, 24, 19, 9, 56, 0, time.UTC)
+	var positions [][2]float64
+	for i := 0; i < 40; i++ {
+		positions = append(positions, [2]float64{44.089 + float64(i)*0.0001, -76.906 - float64(i)*0.0001})
+	}
+	fn := filepath.Join(t.TempDir(), "activity.fit")
+	if err := os.WriteFile(fn, fitTestFile(start, positions), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := ReadSource(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := src.GPX.Tracks[0].Segments[0].Points
+	testutil.AssertEqual(t, len(points), len(positions))
+	for i, p := range points {
+		testutil.AssertEqual(t, p.Timestamp, start.Add(time.Duration(i)*2*time.Second))
+		testutil.AssertEqual(t, math.Round(p.Latitude*1e6), math.Round(positions[i][0]*1e6))
+		testutil.AssertEqual(t, math.Round(p.Longitude*1e6), math.Round(positions[i][1]*1e6))
+		testutil.AssertEqual(t, p.Elevation.Value(), 80.0)
+	}
+	_, err = fitPoints([]byte("not a fit file"))
+	testutil.AssertEqual(t, err != nil, true)
+}
@@ -0,0 +1,217 @@
+package formats
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// Telemetry is the GPS stream that GoPro cameras record into the video file
+// using the GPMF format in a metadata track with "gpmd" sample entries.
+// See https://github.com/gopro/gpmf-parser
+type Telemetry struct {
+	Filename string
+	Samples  []GPSSample
+}
+
+// GPSSample is a single GPS5 reading of the Telemetry, timed both in the video and in UTC.
+type GPSSample struct {
+	VideoTime time.Duration // time of the sample in the video
+	Time      time.Time     // UTC time of the sample derived from GPSU
+	Lat, Lon  float64       // in degrees
+	Ele       float64       // in meters
+	Speed     float64       // 2D speed in m/s
+}
+
+// ReadTelemetry reads the GPS telemetry from a GoPro video file.
+func ReadTelemetry(fn string) (*Telemetry, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tm, err := DecodeTelemetry(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	tm.Filename = filepath.Base(fn)
+	return tm, nil
+}
+
+// DecodeTelemetry decodes the GPMF telemetry from the metadata track of the MP4 video.
+func DecodeTelemetry(r io.ReadSeeker) (*Telemetry, error) {
+	samples, err := mp4MetadataTrack(r, "gpmd")
+	if err != nil {
+		return nil, err
+	}
+	tm := &Telemetry{}
+	for _, s := range samples {
+		if _, err := r.Seek(s.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		payload := make([]byte, s.Size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		tm.Samples = append(tm.Samples, gpmfGPS(payload, s.Time, s.Duration)...)
+	}
+	if len(tm.Samples) == 0 {
+		return nil, fmt.Errorf("no GPS telemetry with a fix")
+	}
+	return tm, nil
+}
+
+// gpmfGPS extracts GPS5 readings from a GPMF payload that starts at @start in the video and lasts @duration.
+// The readings of a payload are spread evenly over its duration, starting at the GPSU time.
+// Payloads without a GPS fix are skipped.
+func gpmfGPS(payload []byte, start, duration time.Duration) (samples []GPSSample) {
+	var scale []float64
+	var utc time.Time
+	fix := uint32(3)
+	gpmfEach(payload, func(key string, typ byte, size, repeat int, data []byte) {
+		switch key {
+		case "STRM":
+			scale, utc, fix = nil, time.Time{}, 3
+		case "SCAL":
+			scale = gpmfNumbers(typ, size, repeat, data)
+		case "GPSF":
+			if len(data) >= 4 {
+				fix = binary.BigEndian.Uint32(data)
+			}
+		case "GPSU":
+			utc, _ = time.Parse("060102150405.000", strings.TrimRight(string(data), "\x00"))
+		case "GPS5":
+			values := gpmfNumbers(typ, size, repeat, data)
+			if fix < 2 || utc.IsZero() || len(values) < 5*repeat {
+				return
+			}
+			for i := 0; i < repeat; i++ {
+				v := values[5*i : 5*i+5]
+				for j := range v {
+					if j < len(scale) && scale[j] != 0 {
+						v[j] /= scale[j]
+					} else if len(scale) == 1 && scale[0] != 0 {
+						v[j] /= scale[0]
+					}
+				}
+				offset := duration * time.Duration(i) / time.Duration(repeat)
+				samples = append(samples, GPSSample{
+					VideoTime: start + offset,
+					Time:      utc.Add(offset),
+					Lat:       v[0],
+					Lon:       v[1],
+					Ele:       v[2],
+					Speed:     v[3],
+				})
+			}
+		}
+	})
+	return samples
+}
+
+// gpmfEach walks the KLV structure of the GPMF payload calling f for each entry,
+// nested entries (e.g. DEVC, STRM) are called before their content.
+func gpmfEach(b []byte, f func(key string, typ byte, size, repeat int, data []byte)) {
+	for len(b) >= 8 {
+		key, typ, size, repeat := string(b[:4]), b[4], int(b[5]), int(binary.BigEndian.Uint16(b[6:8]))
+		length := size * repeat
+		padded := (length + 3) &^ 3
+		if 8+padded > len(b) {
+			return
+		}
+		data := b[8 : 8+length]
+		f(key, typ, size, repeat, data)
+		if typ == 0 {
+			gpmfEach(data, f)
+		}
+		b = b[8+padded:]
+	}
+}
+
+// gpmfNumbers decodes numeric GPMF data.
+func gpmfNumbers(typ byte, size, repeat int, data []byte) (values []float64) {
+	var width int
+	switch typ {
+	case 'b', 'B':
+		width = 1
+	case 's', 'S':
+		width = 2
+	case 'l', 'L', 'f':
+		width = 4
+	case 'd', 'j', 'J':
+		width = 8
+	default:
+		return nil
+	}
+	for i := 0; i+width <= len(data) && i < size*repeat; i += width {
+		d := data[i : i+width]
+		var v float64
+		switch typ {
+		case 'b':
+			v = float64(int8(d[0]))
+		case 'B':
+			v = float64(d[0])
+		case 's':
+			v = float64(int16(binary.BigEndian.Uint16(d)))
+		case 'S':
+			v = float64(binary.BigEndian.Uint16(d))
+		case 'l':
+			v = float64(int32(binary.BigEndian.Uint32(d)))
+		case 'L':
+			v = float64(binary.BigEndian.Uint32(d))
+		case 'f':
+			v = float64(math.Float32frombits(binary.BigEndian.Uint32(d)))
+		case 'd':
+			v = math.Float64frombits(binary.BigEndian.Uint64(d))
+		case 'j':
+			v = float64(int64(binary.BigEndian.Uint64(d)))
+		case 'J':
+			v = float64(binary.BigEndian.Uint64(d))
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// Segment returns the telemetry as a track segment with a point per second.
+func (tm *Telemetry) Segment() *gpx.GPXTrackSegment {
+	s := &gpx.GPXTrackSegment{}
+	var last time.Time
+	for _, sample := range tm.Samples {
+		ts := sample.Time.Truncate(time.Second)
+		if !ts.After(last) {
+			continue
+		}
+		last = ts
+		p := gpx.GPXPoint{Point: gpx.Point{Latitude: sample.Lat, Longitude: sample.Lon}, Timestamp: ts}
+		p.Elevation.SetValue(sample.Ele)
+		s.AppendPoint(&p)
+	}
+	return s
+}
+
+// source wraps the telemetry as a GPX file so that it can be processed as a track source.
+func (tm *Telemetry) source() *Source {
+	g := &gpx.GPX{Version: "1.1", Creator: "GoPro"}
+	g.AppendTrack(&gpx.GPXTrack{Name: tm.Filename, Segments: []gpx.GPXTrackSegment{*tm.Segment()}})
+	return &Source{GPX: g, Filename: tm.Filename, extensions: make(map[gpxPointKey][]byte)}
+}
+
+// Start returns the UTC time of the start of the video according to the camera GPS,
+// i.e. the median of the differences between the GPS time and the video time of the samples.
+func (tm *Telemetry) Start() time.Time {
+	starts := make([]time.Time, len(tm.Samples))
+	for i, s := range tm.Samples {
+		starts[i] = s.Time.Add(-s.VideoTime)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	return starts[len(starts)/2]
+}
@@ -0,0 +1,43 @@
+package formats
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+func Test_Telemetry(t *testing.T) {
+	start := time.Date(2024, 8, 24, 19, 9, 50, 300e6, time.UTC)
+	lag := 400 * time.Millisecond
+	tm, err := DecodeTelemetry(bytes.NewReader(testutil.GoProVideo(testutil.SamplePoints(t, testutil.Turn1), start, 100, lag)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the track spans 90s starting 5.7s into the video, only whole payloads within it have a fix
+	testutil.AssertEqual(t, len(tm.Samples), 89*5)
+	testutil.AssertEqual(t, tm.Samples[0].VideoTime, 6*time.Second)
+	testutil.AssertEqual(t, tm.Samples[1].VideoTime, 6200*time.Millisecond)
+	testutil.AssertEqual(t, tm.Samples[0].Ele, 80.0)
+	testutil.AssertEqual(t, tm.Start(), start.Add(lag))
+}
+
+func Test_TelemetrySource(t *testing.T) {
+	start := time.Date(2024, 8, 24, 19, 9, 50, 300e6, time.UTC)
+	fn := filepath.Join(t.TempDir(), "GX010042.MP4")
+	if err := os.WriteFile(fn, testutil.GoProVideo(testutil.SamplePoints(t, testutil.Turn1), start, 100, 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := ReadSource(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, src.Filename, "GX010042.MP4")
+	testutil.AssertEqual(t, len(src.GPX.Tracks), 1)
+	// one point per second, 19:09:56 - 19:11:25
+	testutil.AssertEqual(t, len(src.GPX.Tracks[0].Segments[0].Points), 90)
+	testutil.AssertEqual(t, src.GPX.Tracks[0].Segments[0].Points[0].Timestamp, time.Date(2024, 8, 24, 19, 9, 56, 0, time.UTC))
+}
@@ -0,0 +1,346 @@
+// Package formats reads and writes the GPS data formats:
+// GPX and FIT files, GoPro GPMF telemetry in MP4 videos and NMEA logs and streams.
+package formats
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+const gpxNamespace = "http://www.topografix.com/GPX/1/1"
+const gpxTimeFormat = "2006-01-02T15:04:05Z"
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// Source is a parsed GPX file along with the parts of the original document
+// that gpxgo doesn't retain (file metadata, namespaces and point extensions),
+// so that they can be carried over into the generated GPX files.
+type Source struct {
+	GPX            *gpx.GPX
+	Filename       string                 // base name of the file
+	namespaces     []xml.Attr             // prefixed namespace declarations of the root element
+	schemaLocation string                 // xsi:schemaLocation of a GPX 1.1 document
+	metadata       []byte                 // raw content of the metadata element of a GPX 1.1 document
+	extensions     map[gpxPointKey][]byte // raw content of the trkpt extensions
+}
+
+// gpxPointKey identifies a track point across the copies made during processing.
+// Timestamps are compared at the second resolution that gpxgo retains.
+type gpxPointKey struct {
+	time     int64
+	lat, lon float64
+}
+
+func gpxKey(p *gpx.GPXPoint) gpxPointKey {
+	return gpxPointKey{time: p.Timestamp.Unix(), lat: p.Latitude, lon: p.Longitude}
+}
+
+// ReadSource reads a track source file, either GPX, FIT or a GoPro video with GPS telemetry.
+func ReadSource(fn string) (*Source, error) {
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".mp4", ".mov":
+		tm, err := ReadTelemetry(fn)
+		if err != nil {
+			return nil, err
+		}
+		return tm.source(), nil
+	case ".fit":
+		return ReadFIT(fn)
+	}
+	return gpxParseFile(fn)
+}
+
+// gpxParseFile parses the GPX file and collects the parts of it that gpxgo drops.
+func gpxParseFile(fn string) (*Source, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	g, err := gpx.ParseBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	var raw xmlSourceGpx
+	if err := xml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	src := &Source{GPX: g, Filename: filepath.Base(fn), extensions: make(map[gpxPointKey][]byte)}
+	for _, a := range raw.Attrs {
+		if a.Name.Space == "xmlns" {
+			src.namespaces = append(src.namespaces, xml.Attr{Name: xml.Name{Local: "xmlns:" + a.Name.Local}, Value: a.Value})
+		}
+		if a.Name.Space == xsiNamespace && a.Name.Local == "schemaLocation" && g.Version == "1.1" {
+			src.schemaLocation = a.Value
+		}
+	}
+	if raw.Metadata != nil && g.Version == "1.1" {
+		src.metadata = raw.Metadata.Content
+	}
+	for _, t := range raw.Tracks {
+		for _, s := range t.Segments {
+			for _, p := range s.Points {
+				if p.Extensions == nil {
+					continue
+				}
+				tm, ok := gpxParseTime(p.Time)
+				if !ok {
+					continue
+				}
+				key := gpxPointKey{time: tm.Unix(), lat: p.Lat, lon: p.Lon}
+				src.extensions[key] = p.Extensions.Content
+			}
+		}
+	}
+	return src, nil
+}
+
+// gpxParseTime parses point timestamps the same way gpxgo does,
+// i.e. dropping fractional seconds and assuming UTC.
+func gpxParseTime(ts string) (time.Time, bool) {
+	ts, _, _ = strings.Cut(strings.TrimSpace(ts), ".")
+	for _, layout := range []string{gpxTimeFormat, "2006-01-02T15:04:05", "2006-01-02 15:04:05Z", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, ts); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Sources are the parsed source files, e.g. the sources of the points of a track.
+type Sources []*Source
+
+// Add returns the sources with the source added, unless it is nil or already present.
+func (srcs Sources) Add(src *Source) Sources {
+	if src == nil {
+		return srcs
+	}
+	for _, s := range srcs {
+		if s == src {
+			return srcs
+		}
+	}
+	return append(srcs, src)
+}
+
+// pointExtensions returns raw extensions content of the point from any of the sources.
+func (srcs Sources) pointExtensions(p *gpx.GPXPoint) []byte {
+	key := gpxKey(p)
+	for _, src := range srcs {
+		if ext, ok := src.extensions[key]; ok {
+			return ext
+		}
+	}
+	return nil
+}
+
+// WriteGPX writes the track as a GPX 1.1 document.
+// File metadata is taken from the first source, falling back to the metadata
+// that gpxgo parsed from GPX 1.0 documents. Namespaces and point extensions
+// are collected from all the sources.
+func WriteGPX(w io.Writer, t *gpx.GPXTrack, srcs Sources) error {
+	doc := xmlGpx{XMLNs: gpxNamespace, Version: "1.1", Creator: "https://github.com/mkobetic/gpx"}
+	if len(srcs) > 0 {
+		first := srcs[0]
+		if first.GPX.Creator != "" {
+			doc.Creator = first.GPX.Creator
+		}
+		if first.metadata != nil {
+			doc.Metadata = &xmlInner{Content: first.metadata}
+		} else {
+			doc.Metadata = newXmlMetadata(first.GPX)
+		}
+		if first.schemaLocation != "" {
+			doc.SchemaLocation = first.schemaLocation
+		}
+		declared := make(map[string]bool)
+		for _, src := range srcs {
+			for _, ns := range src.namespaces {
+				if !declared[ns.Name.Local] {
+					declared[ns.Name.Local] = true
+					doc.Namespaces = append(doc.Namespaces, ns)
+				}
+			}
+		}
+	}
+	trk := xmlTrack{
+		Name:    t.Name,
+		Comment: t.Comment,
+		Desc:    t.Description,
+		Source:  t.Source,
+		Type:    t.Type,
+	}
+	if t.Number.NotNull() {
+		n := t.Number.Value()
+		trk.Number = &n
+	}
+	for i := range t.Segments {
+		var seg xmlSegment
+		for j := range t.Segments[i].Points {
+			p := &t.Segments[i].Points[j]
+			seg.Points = append(seg.Points, newXmlPoint(p, srcs.pointExtensions(p)))
+		}
+		trk.Segments = append(trk.Segments, seg)
+	}
+	doc.Tracks = []xmlTrack{trk}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// XML structures used to pick up what gpxgo drops on parsing.
+
+type xmlSourceGpx struct {
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Metadata *xmlInner  `xml:"metadata"`
+	Tracks   []struct {
+		Segments []struct {
+			Points []struct {
+				Lat        float64   `xml:"lat,attr"`
+				Lon        float64   `xml:"lon,attr"`
+				Time       string    `xml:"time"`
+				Extensions *xmlInner `xml:"extensions"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type xmlInner struct {
+	Content []byte `xml:",innerxml"`
+}
+
+// XML structures used to write GPX 1.1 files.
+
+type xmlGpx struct {
+	XMLName        xml.Name   `xml:"gpx"`
+	XMLNs          string     `xml:"xmlns,attr"`
+	Namespaces     []xml.Attr `xml:",any,attr"`
+	SchemaLocation string     `xml:"xsi:schemaLocation,attr,omitempty"`
+	Version        string     `xml:"version,attr"`
+	Creator        string     `xml:"creator,attr"`
+	Metadata       any        `xml:"metadata,omitempty"`
+	Tracks         []xmlTrack `xml:"trk"`
+}
+
+type xmlMetadata struct {
+	Name     string   `xml:"name,omitempty"`
+	Desc     string   `xml:"desc,omitempty"`
+	Author   *xmlLink `xml:"author>link,omitempty"`
+	Link     *xmlLink `xml:"link,omitempty"`
+	Time     string   `xml:"time,omitempty"`
+	Keywords string   `xml:"keywords,omitempty"`
+}
+
+type xmlLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:"text,omitempty"`
+	Type string `xml:"type,omitempty"`
+}
+
+func newXmlMetadata(g *gpx.GPX) *xmlMetadata {
+	m := &xmlMetadata{Name: g.Name, Desc: g.Description, Keywords: g.Keywords}
+	if g.AuthorLink != "" {
+		m.Author = &xmlLink{Href: g.AuthorLink, Text: g.AuthorLinkText, Type: g.AuthorLinkType}
+	}
+	if g.Link != "" {
+		m.Link = &xmlLink{Href: g.Link, Text: g.LinkText, Type: g.LinkType}
+	}
+	if g.Time != nil {
+		m.Time = g.Time.UTC().Format(gpxTimeFormat)
+	}
+	return m
+}
+
+type xmlTrack struct {
+	Name     string       `xml:"name,omitempty"`
+	Comment  string       `xml:"cmt,omitempty"`
+	Desc     string       `xml:"desc,omitempty"`
+	Source   string       `xml:"src,omitempty"`
+	Number   *int         `xml:"number,omitempty"`
+	Type     string       `xml:"type,omitempty"`
+	Segments []xmlSegment `xml:"trkseg"`
+}
+
+type xmlSegment struct {
+	Points []xmlPoint `xml:"trkpt"`
+}
+
+type xmlPoint struct {
+	Lat           float64   `xml:"lat,attr"`
+	Lon           float64   `xml:"lon,attr"`
+	Ele           *float64  `xml:"ele,omitempty"`
+	Time          string    `xml:"time,omitempty"`
+	MagVar        string    `xml:"magvar,omitempty"`
+	GeoidHeight   string    `xml:"geoidheight,omitempty"`
+	Name          string    `xml:"name,omitempty"`
+	Comment       string    `xml:"cmt,omitempty"`
+	Desc          string    `xml:"desc,omitempty"`
+	Source        string    `xml:"src,omitempty"`
+	Symbol        string    `xml:"sym,omitempty"`
+	Type          string    `xml:"type,omitempty"`
+	Fix           string    `xml:"fix,omitempty"`
+	Sat           *int      `xml:"sat,omitempty"`
+	Hdop          *float64  `xml:"hdop,omitempty"`
+	Vdop          *float64  `xml:"vdop,omitempty"`
+	Pdop          *float64  `xml:"pdop,omitempty"`
+	AgeOfDGpsData *float64  `xml:"ageofdgpsdata,omitempty"`
+	DGpsId        *int      `xml:"dgpsid,omitempty"`
+	Extensions    *xmlInner `xml:"extensions,omitempty"`
+}
+
+func newXmlPoint(p *gpx.GPXPoint, extensions []byte) xmlPoint {
+	xp := xmlPoint{
+		Lat:         p.Latitude,
+		Lon:         p.Longitude,
+		MagVar:      p.MagneticVariation,
+		GeoidHeight: p.GeoidHeight,
+		Name:        p.Name,
+		Comment:     p.Comment,
+		Desc:        p.Description,
+		Source:      p.Source,
+		Symbol:      p.Symbol,
+		Type:        p.Type,
+		Fix:         p.TypeOfGpsFix,
+	}
+	if p.Timestamp.Year() > 1 {
+		xp.Time = p.Timestamp.UTC().Format(gpxTimeFormat)
+	}
+	xp.Ele = nullableFloat(p.Elevation)
+	xp.Hdop = nullableFloat(p.HorizontalDilution)
+	xp.Vdop = nullableFloat(p.VerticalDilution)
+	xp.Pdop = nullableFloat(p.PositionalDilution)
+	xp.AgeOfDGpsData = nullableFloat(p.AgeOfDGpsData)
+	xp.Sat = nullableInt(p.Satellites)
+	xp.DGpsId = nullableInt(p.DGpsId)
+	if extensions != nil {
+		xp.Extensions = &xmlInner{Content: extensions}
+	}
+	return xp
+}
+
+func nullableFloat(n gpx.NullableFloat64) *float64 {
+	if n.Null() {
+		return nil
+	}
+	v := n.Value()
+	return &v
+}
+
+func nullableInt(n gpx.NullableInt) *int {
+	if n.Null() {
+		return nil
+	}
+	v := n.Value()
+	return &v
+}
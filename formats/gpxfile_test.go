@@ -0,0 +1,109 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+func Test_GpxRoundTripSamples(t *testing.T) {
+	fns, err := filepath.Glob("../samples/in/*.gpx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range fns {
+		t.Run(filepath.Base(fn), func(t *testing.T) {
+			in, out := roundTrip(t, fn)
+			testutil.AssertEqual(t, out.GPX.Creator, in.GPX.Creator)
+			testutil.AssertEqual(t, out.GPX.Tracks[0].Name, in.GPX.Tracks[0].Name)
+			assertPointsPreserved(t, in, out)
+		})
+	}
+}
+
+func Test_GpxRoundTripExtensions(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "garmin.gpx")
+	if err := os.WriteFile(fn, []byte(testutil.Garmin), 0644); err != nil {
+		t.Fatal(err)
+	}
+	in, out := roundTrip(t, fn)
+	testutil.AssertEqual(t, out.GPX.Creator, "Garmin Connect")
+	testutil.AssertEqual(t, string(out.metadata), string(in.metadata))
+	testutil.AssertEqual(t, out.schemaLocation, in.schemaLocation)
+	testutil.AssertEqual(t, len(out.namespaces), 2)
+	testutil.AssertEqual(t, out.GPX.Tracks[0].Name, "Kingston Sailing")
+	testutil.AssertEqual(t, out.GPX.Tracks[0].Type, "sailing")
+	testutil.AssertEqual(t, len(out.extensions), 23)
+	assertPointsPreserved(t, in, out)
+}
+
+// roundTrip writes a copy of the first track of the GPX file with the file as the source and parses the written file back.
+func roundTrip(t *testing.T, fn string) (in, out *Source) {
+	t.Helper()
+	in, err := gpxParseFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the points are copied in the processing of the tracks
+	trk := in.GPX.Tracks[0]
+	trk.Segments = nil
+	for _, s := range in.GPX.Tracks[0].Segments {
+		trk.AppendSegment(&gpx.GPXTrackSegment{Points: append([]gpx.GPXPoint(nil), s.Points...)})
+	}
+	var b bytes.Buffer
+	if err := WriteGPX(&b, &trk, Sources{in}); err != nil {
+		t.Fatal(err)
+	}
+	fn = filepath.Join(t.TempDir(), "out.gpx")
+	if err := os.WriteFile(fn, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = gpxParseFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return in, out
+}
+
+// assertPointsPreserved checks that every output point is identical to its input counterpart.
+func assertPointsPreserved(t *testing.T, in, out *Source) {
+	t.Helper()
+	inPoints := make(map[gpxPointKey]string)
+	for _, trk := range in.GPX.Tracks {
+		for _, s := range trk.Segments {
+			for i := range s.Points {
+				p := &s.Points[i]
+				inPoints[gpxKey(p)] = marshalPoint(t, newXmlPoint(p, in.extensions[gpxKey(p)]))
+			}
+		}
+	}
+	count := 0
+	for _, s := range out.GPX.Tracks[0].Segments {
+		for i := range s.Points {
+			p := &s.Points[i]
+			exp, ok := inPoints[gpxKey(p)]
+			if !ok {
+				t.Errorf("point not in input: %v", gpxKey(p))
+				continue
+			}
+			testutil.AssertEqual(t, marshalPoint(t, newXmlPoint(p, out.extensions[gpxKey(p)])), exp)
+			count++
+		}
+	}
+	if count == 0 {
+		t.Error("no points in output")
+	}
+}
+
+func marshalPoint(t *testing.T, p xmlPoint) string {
+	var b bytes.Buffer
+	if err := xml.NewEncoder(&b).Encode(p); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
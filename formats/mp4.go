@@ -0,0 +1,273 @@
+package formats
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// mp4Epoch is the start of the time scale used for MP4/MOV creation times.
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// mp4Box is a box (atom) of an ISO base media file (MP4, MOV).
+// See https://developer.apple.com/documentation/quicktime-file-format
+type mp4Box struct {
+	Type   string
+	Offset int64 // offset of the box content (after the header) in the file
+	Size   int64 // size of the box content
+}
+
+// mp4Boxes returns the boxes found in the section of the file between start and end.
+func mp4Boxes(r io.ReadSeeker, start, end int64) (boxes []mp4Box, err error) {
+	var header [16]byte
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		switch size {
+		case 0: // box extends to the end of the file
+			size = end - offset
+		case 1: // 64-bit size follows the type
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize || offset+size > end {
+			return nil, fmt.Errorf("invalid mp4 box %q at %d", header[4:8], offset)
+		}
+		boxes = append(boxes, mp4Box{Type: string(header[4:8]), Offset: offset + headerSize, Size: size - headerSize})
+		offset += size
+	}
+	return boxes, nil
+}
+
+// mp4Find returns the first top level box at the specified path, e.g. "moov", "mvhd".
+func mp4Find(r io.ReadSeeker, path ...string) (*mp4Box, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return mp4Child(r, &mp4Box{Offset: 0, Size: end}, path...)
+}
+
+// mp4Read reads the content of the box.
+func mp4Read(r io.ReadSeeker, box *mp4Box) ([]byte, error) {
+	if _, err := r.Seek(box.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b := make([]byte, box.Size)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+// MovieHeader reads the creation time and duration from the movie header (moov/mvhd).
+func MovieHeader(r io.ReadSeeker) (created time.Time, duration time.Duration, err error) {
+	box, err := mp4Find(r, "moov", "mvhd")
+	if err != nil {
+		return created, duration, err
+	}
+	b, err := mp4Read(r, box)
+	if err != nil {
+		return created, duration, err
+	}
+	var creation, timescale, units uint64
+	// version(1) flags(3) creation modification timescale duration
+	switch {
+	case len(b) >= 32 && b[0] == 1:
+		creation = binary.BigEndian.Uint64(b[4:12])
+		timescale = uint64(binary.BigEndian.Uint32(b[20:24]))
+		units = binary.BigEndian.Uint64(b[24:32])
+	case len(b) >= 20 && b[0] == 0:
+		creation = uint64(binary.BigEndian.Uint32(b[4:8]))
+		timescale = uint64(binary.BigEndian.Uint32(b[12:16]))
+		units = uint64(binary.BigEndian.Uint32(b[16:20]))
+	default:
+		return created, duration, fmt.Errorf("unsupported mvhd box")
+	}
+	if timescale == 0 {
+		return created, duration, fmt.Errorf("invalid mvhd timescale")
+	}
+	created = time.Unix(mp4Epoch.Unix()+int64(creation), 0).UTC()
+	duration = time.Duration(units * uint64(time.Second) / timescale)
+	return created, duration, nil
+}
+
+// mp4Sample is a media sample of a track.
+type mp4Sample struct {
+	Offset   int64         // offset of the sample in the file
+	Size     int64         // size of the sample
+	Time     time.Duration // presentation time of the sample
+	Duration time.Duration
+}
+
+// mp4MetadataTrack finds the track with the specified sample entry format, e.g. "gpmd",
+// and returns its samples.
+func mp4MetadataTrack(r io.ReadSeeker, format string) ([]mp4Sample, error) {
+	moov, err := mp4Find(r, "moov")
+	if err != nil {
+		return nil, err
+	}
+	traks, err := mp4Boxes(r, moov.Offset, moov.Offset+moov.Size)
+	if err != nil {
+		return nil, err
+	}
+	for _, trak := range traks {
+		if trak.Type != "trak" {
+			continue
+		}
+		stsd, err := mp4Child(r, &trak, "mdia", "minf", "stbl", "stsd")
+		if err != nil {
+			continue
+		}
+		b, err := mp4Read(r, stsd)
+		// version/flags(4) entry count(4) first entry size(4) and format(4)
+		if err != nil || len(b) < 16 || string(b[12:16]) != format {
+			continue
+		}
+		return mp4Samples(r, &trak)
+	}
+	return nil, fmt.Errorf("mp4 track %s not found", format)
+}
+
+// mp4Child returns the first box at the specified path inside the parent box.
+func mp4Child(r io.ReadSeeker, parent *mp4Box, path ...string) (*mp4Box, error) {
+	box := parent
+	for _, typ := range path {
+		boxes, err := mp4Boxes(r, box.Offset, box.Offset+box.Size)
+		if err != nil {
+			return nil, err
+		}
+		box = nil
+		for i := range boxes {
+			if boxes[i].Type == typ {
+				box = &boxes[i]
+				break
+			}
+		}
+		if box == nil {
+			return nil, fmt.Errorf("mp4 box %s not found", typ)
+		}
+	}
+	return box, nil
+}
+
+// mp4Samples computes the location and timing of the samples of the track from its sample tables.
+func mp4Samples(r io.ReadSeeker, trak *mp4Box) ([]mp4Sample, error) {
+	read := func(path ...string) ([]byte, error) {
+		box, err := mp4Child(r, trak, path...)
+		if err != nil {
+			return nil, err
+		}
+		return mp4Read(r, box)
+	}
+	u32 := func(b []byte, i int) int64 { return int64(binary.BigEndian.Uint32(b[i : i+4])) }
+
+	mdhd, err := read("mdia", "mdhd")
+	if err != nil {
+		return nil, err
+	}
+	timescale := int64(0)
+	if len(mdhd) >= 24 && mdhd[0] == 1 {
+		timescale = u32(mdhd, 20)
+	} else if len(mdhd) >= 16 {
+		timescale = u32(mdhd, 12)
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("invalid mdhd timescale")
+	}
+	stbl := []string{"mdia", "minf", "stbl"}
+
+	// sample sizes
+	stsz, err := read(append(stbl, "stsz")...)
+	if err != nil || len(stsz) < 12 {
+		return nil, fmt.Errorf("invalid stsz box")
+	}
+	// the sample count comes from the file, check it before allocating the samples:
+	// the sizes of the samples must fit in the table, or the samples of the fixed size in the file
+	count, size := u32(stsz, 8), u32(stsz, 4)
+	limit := int64(len(stsz)-12) / 4
+	if size > 0 {
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		limit = end / size
+	}
+	if count > limit {
+		return nil, fmt.Errorf("invalid stsz sample count %d", count)
+	}
+	samples := make([]mp4Sample, count)
+	for i := range samples {
+		if size > 0 {
+			samples[i].Size = size
+		} else {
+			samples[i].Size = u32(stsz, 12+4*i)
+		}
+	}
+
+	// sample timing
+	stts, err := read(append(stbl, "stts")...)
+	if err != nil || len(stts) < 8 {
+		return nil, fmt.Errorf("invalid stts box")
+	}
+	var i int
+	var units int64
+	for e := 0; e < int(u32(stts, 4)) && 8+8*e+8 <= len(stts); e++ {
+		count, delta := u32(stts, 8+8*e), u32(stts, 12+8*e)
+		for ; count > 0 && i < len(samples); count-- {
+			samples[i].Time = time.Duration(units * int64(time.Second) / timescale)
+			samples[i].Duration = time.Duration(delta * int64(time.Second) / timescale)
+			units += delta
+			i++
+		}
+	}
+
+	// chunk offsets
+	var chunks []int64
+	if stco, err := read(append(stbl, "stco")...); err == nil && len(stco) >= 8 {
+		for c := 0; c < int(u32(stco, 4)) && 8+4*c+4 <= len(stco); c++ {
+			chunks = append(chunks, u32(stco, 8+4*c))
+		}
+	} else if co64, err := read(append(stbl, "co64")...); err == nil && len(co64) >= 8 {
+		for c := 0; c < int(u32(co64, 4)) && 8+8*c+8 <= len(co64); c++ {
+			chunks = append(chunks, int64(binary.BigEndian.Uint64(co64[8+8*c:])))
+		}
+	} else {
+		return nil, fmt.Errorf("missing chunk offsets")
+	}
+
+	// samples to chunks
+	stsc, err := read(append(stbl, "stsc")...)
+	if err != nil || len(stsc) < 8 {
+		return nil, fmt.Errorf("invalid stsc box")
+	}
+	entries := int(u32(stsc, 4))
+	i = 0
+	for e := 0; e < entries && 8+12*e+12 <= len(stsc); e++ {
+		first, perChunk := u32(stsc, 8+12*e), u32(stsc, 12+12*e)
+		if first < 1 || first > int64(len(chunks)) {
+			return nil, fmt.Errorf("invalid stsc first chunk %d of %d chunks", first, len(chunks))
+		}
+		last := int64(len(chunks))
+		if e+1 < entries && 8+12*(e+1)+4 <= len(stsc) {
+			last = u32(stsc, 8+12*(e+1)) - 1
+		}
+		for c := first; c <= last && c-1 < int64(len(chunks)); c++ {
+			offset := chunks[c-1]
+			for s := int64(0); s < perChunk && i < len(samples); s++ {
+				samples[i].Offset = offset
+				offset += samples[i].Size
+				i++
+			}
+		}
+	}
+	return samples, nil
+}
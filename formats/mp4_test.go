@@ -0,0 +1,75 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+func Test_Mp4MovieHeader(t *testing.T) {
+	created := time.Date(2024, 8, 24, 15, 8, 29, 0, time.UTC)
+	for _, version := range []byte{0, 1} {
+		r := bytes.NewReader(testutil.MP4Movie(version, created, 90000, 90000*125+45000))
+		c, d, err := MovieHeader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testutil.AssertEqual(t, c, created)
+		testutil.AssertEqual(t, d, 125500*time.Millisecond)
+	}
+	_, _, err := MovieHeader(bytes.NewReader(testutil.MP4Box("ftyp", []byte("isom"))))
+	if err == nil {
+		t.Error("expected missing moov error")
+	}
+}
+
+func u32s(values ...uint32) (b []byte) {
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+// mp4Video builds a movie with a gpmd track with the sample table boxes.
+func mp4Video(stbl ...[]byte) []byte {
+	return testutil.MP4Box("moov", testutil.MP4Box("trak", testutil.MP4Box("mdia",
+		testutil.MP4Box("mdhd", u32s(0, 0, 0, 1000, 1000, 0)),
+		testutil.MP4Box("minf", testutil.MP4Box("stbl",
+			append([][]byte{testutil.MP4Box("stsd", append(u32s(0, 1, 16), []byte("gpmd")...))}, stbl...)...,
+		)),
+	)))
+}
+
+func Test_Mp4SampleCount(t *testing.T) {
+	for name, stsz := range map[string][]byte{
+		"table": u32s(0, 0, 0xffffffff, 10), // sample sizes with the count beyond the table
+		"fixed": u32s(0, 4, 0xffffffff),     // fixed sample size with the count beyond the file
+	} {
+		_, err := mp4MetadataTrack(bytes.NewReader(mp4Video(testutil.MP4Box("stsz", stsz))), "gpmd")
+		if err == nil || !strings.Contains(err.Error(), "sample count") {
+			t.Errorf("%s: expected invalid sample count error, got %v", name, err)
+		}
+	}
+}
+
+func Test_Mp4SampleChunks(t *testing.T) {
+	for first, exp := range map[uint32]string{0: "invalid stsc", 3: "invalid stsc", 1: ""} {
+		video := mp4Video(
+			testutil.MP4Box("stsz", u32s(0, 4, 2)),
+			testutil.MP4Box("stts", u32s(0, 1, 2, 1000)),
+			testutil.MP4Box("stco", u32s(0, 2, 100, 200)),
+			testutil.MP4Box("stsc", u32s(0, 1, first, 1, 1)),
+		)
+		samples, err := mp4MetadataTrack(bytes.NewReader(video), "gpmd")
+		if exp == "" {
+			testutil.AssertEqual(t, err, nil)
+			testutil.AssertEqual(t, samples[1].Offset, int64(200))
+		} else if err == nil || !strings.Contains(err.Error(), exp) {
+			t.Errorf("first chunk %d: expected %q error, got %v", first, exp, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package formats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// NMEA 0183 is the serial protocol of marine GPS receivers and instruments,
+// often broadcast over TCP or UDP by the multiplexers of the onboard network.
+// Only the position sentences RMC (position, date) and GGA (position, altitude) are decoded.
+// See https://gpsd.gitlab.io/gpsd/NMEA.html
+
+var errNMEAChecksum = errors.New("NMEA checksum mismatch")
+
+// nmeaDecoder assembles track points from the sentences of an NMEA stream.
+// The sentences of the same fix (with the same time) are merged into a single point,
+// so a point is complete when a sentence of the next fix arrives (or the stream ends, see flush).
+type nmeaDecoder struct {
+	date time.Time // date of the last RMC sentence, GGA sentences have only the time of day
+	fix  *nmeaFix
+}
+
+// nmeaFix collects the sentences of a single fix.
+type nmeaFix struct {
+	tod      string // time of day as in the sentences, hhmmss.ss
+	date     time.Time
+	lat, lon float64
+	ele      *float64
+}
+
+// decode decodes the sentence, returns the point of the previous fix if the sentence starts a new one.
+// Sentences other than RMC and GGA and sentences without a valid position are ignored.
+func (d *nmeaDecoder) decode(sentence string) (*gpx.GPXPoint, error) {
+	fields, err := nmeaFields(sentence)
+	if err != nil {
+		return nil, err
+	}
+	var fix nmeaFix
+	var ok bool
+	switch fields[0] {
+	case "RMC": // time, status, lat, N/S, lon, E/W, speed, course, date, ...
+		if len(fields) < 10 || fields[2] != "A" {
+			return nil, nil
+		}
+		if fix.date, ok = nmeaDate(fields[9]); !ok {
+			return nil, nil
+		}
+		d.date = fix.date
+		fix.lat, fix.lon, ok = nmeaPosition(fields[3:7])
+	case "GGA": // time, lat, N/S, lon, E/W, quality, satellites, hdop, altitude, M, ...
+		if len(fields) < 10 || fields[6] == "" || fields[6] == "0" {
+			return nil, nil
+		}
+		fix.lat, fix.lon, ok = nmeaPosition(fields[2:6])
+		if ele, err := strconv.ParseFloat(fields[9], 64); err == nil {
+			fix.ele = &ele
+		}
+	default:
+		return nil, nil
+	}
+	if !ok || fields[1] == "" {
+		return nil, nil
+	}
+	fix.tod = fields[1]
+	if d.fix != nil && d.fix.tod == fix.tod {
+		if !fix.date.IsZero() {
+			d.fix.date = fix.date
+		}
+		if fix.ele != nil {
+			d.fix.ele = fix.ele
+		}
+		return nil, nil
+	}
+	p := d.flush()
+	if fix.date.IsZero() {
+		fix.date = d.date
+	}
+	d.fix = &fix
+	return p, nil
+}
+
+// flush returns the point of the current fix, nil if there is none or if its date isn't known (no RMC sentence yet).
+func (d *nmeaDecoder) flush() *gpx.GPXPoint {
+	fix := d.fix
+	d.fix = nil
+	if fix == nil || fix.date.IsZero() {
+		return nil
+	}
+	tod, err := time.Parse("150405.999999999", fix.tod)
+	if err != nil {
+		return nil
+	}
+	p := &gpx.GPXPoint{Timestamp: fix.date.Add(tod.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)))}
+	p.Latitude, p.Longitude = fix.lat, fix.lon
+	if fix.ele != nil {
+		p.Elevation = *gpx.NewNullableFloat64(*fix.ele)
+	}
+	return p
+}
+
+// nmeaFields validates the checksum of the sentence (if present) and splits it into the fields,
+// the first field is the address without the talker id, e.g. GPRMC => RMC.
+func nmeaFields(sentence string) ([]string, error) {
+	sentence = strings.TrimSpace(sentence)
+	if !strings.HasPrefix(sentence, "$") {
+		return []string{""}, nil
+	}
+	body, sum, found := strings.Cut(sentence[1:], "*")
+	if found {
+		var x byte
+		for i := 0; i < len(body); i++ {
+			x ^= body[i]
+		}
+		if s, err := strconv.ParseUint(sum, 16, 8); err != nil || byte(s) != x {
+			return nil, errNMEAChecksum
+		}
+	}
+	fields := strings.Split(body, ",")
+	if len(fields[0]) == 5 && fields[0][0] != 'P' { // skip proprietary sentences
+		fields[0] = fields[0][2:]
+	} else {
+		fields[0] = ""
+	}
+	return fields, nil
+}
+
+// nmeaPosition decodes the latitude and longitude fields, ddmm.mmmm,N/S,dddmm.mmmm,E/W.
+func nmeaPosition(fields []string) (lat, lon float64, ok bool) {
+	degrees := func(v, hemisphere, negative string) (float64, bool) {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		deg := float64(int(f / 100))
+		deg += (f - deg*100) / 60
+		if hemisphere == negative {
+			deg = -deg
+		}
+		return deg, true
+	}
+	lat, ok1 := degrees(fields[0], fields[1], "S")
+	lon, ok2 := degrees(fields[2], fields[3], "W")
+	return lat, lon, ok1 && ok2
+}
+
+// nmeaDate decodes the date field, ddmmyy.
+func nmeaDate(v string) (time.Time, bool) {
+	t, err := time.Parse("020106", v)
+	return t, err == nil
+}
+
+// ReadNMEA decodes the points of the NMEA stream and calls f with each point.
+// Corrupted sentences are skipped, the stream is read until it ends or f returns an error.
+func ReadNMEA(r io.Reader, f func(*gpx.GPXPoint) error) error {
+	var d nmeaDecoder
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		p, err := d.decode(s.Text())
+		if err == nil && p != nil {
+			if err := f(p); err != nil {
+				return err
+			}
+		}
+	}
+	if p := d.flush(); p != nil {
+		if err := f(p); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// OpenNMEA connects to the NMEA stream of the source, tcp://host:port connects to a TCP server,
+// udp://[host]:port listens for UDP broadcasts.
+func OpenNMEA(source string) (io.ReadCloser, error) {
+	scheme, addr, _ := strings.Cut(source, "://")
+	switch scheme {
+	case "tcp":
+		return net.Dial("tcp", addr)
+	case "udp":
+		c, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		// each read of a UDP socket returns a single datagram truncated to the size of the buffer,
+		// the buffer of the reader is large enough for any datagram
+		return struct {
+			io.Reader
+			io.Closer
+		}{bufio.NewReaderSize(c.(net.Conn), 65536), c}, nil
+	default:
+		return nil, fmt.Errorf("invalid NMEA source %s, expected tcp://host:port or udp://[host]:port", source)
+	}
+}
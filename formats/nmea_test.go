@@ -0,0 +1,60 @@
+package formats
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+func Test_NMEADecoder(t *testing.T) {
+	var d nmeaDecoder
+	var points []*gpx.GPXPoint
+	for _, s := range []string{
+		testutil.NMEASentence("GPGGA,235959.00,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,"), // no date yet
+		testutil.NMEASentence("GPRMC,235959.00,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W"),
+		testutil.NMEASentence("GNRMC,000000.50,A,4807.038,S,01131.000,W,022.4,084.4,240394,003.1,W"),
+		testutil.NMEASentence("GPGGA,000001.00,4807.038,N,01131.000,E,0,00,,,M,,M,,"), // no fix
+		testutil.NMEASentence("GPRMC,000002.00,V,,,,,,,240394,,"),                     // no fix
+		testutil.NMEASentence("PGRME,15.0,M,45.0,M,25.0,M"),
+		"$GPRMC,000003.00,A,4807.038,N,01131.000,E,022.4,084.4,240394,003.1,W*00", // bad checksum
+		testutil.NMEASentence("GPGGA,000004.00,4807.038,N,01131.000,E,1,08,0.9,10,M,46.9,M,,"),
+	} {
+		p, err := d.decode(s)
+		testutil.AssertEqual(t, err == errNMEAChecksum, strings.HasSuffix(s, "*00"))
+		if p != nil {
+			points = append(points, p)
+		}
+	}
+	if p := d.flush(); p != nil {
+		points = append(points, p)
+	}
+	testutil.AssertEqual(t, len(points), 3)
+	testutil.AssertEqual(t, points[0].Timestamp, time.Date(1994, 3, 23, 23, 59, 59, 0, time.UTC))
+	testutil.AssertEqual(t, fmt.Sprintf("%.5f %.5f %.1f", points[0].Latitude, points[0].Longitude, points[0].Elevation.Value()), "48.11730 11.51667 545.4")
+	testutil.AssertEqual(t, points[1].Timestamp, time.Date(1994, 3, 24, 0, 0, 0, 500e6, time.UTC))
+	testutil.AssertEqual(t, fmt.Sprintf("%.5f %.5f %v", points[1].Latitude, points[1].Longitude, points[1].Elevation.NotNull()), "-48.11730 -11.51667 false")
+	// the GGA sentence gets the date of the last RMC sentence
+	testutil.AssertEqual(t, points[2].Timestamp, time.Date(1994, 3, 24, 0, 0, 4, 0, time.UTC))
+}
+
+func Test_ReadNMEA(t *testing.T) {
+	expected := testutil.SamplePoints(t, testutil.Turn1)
+	var decoded []*gpx.GPXPoint
+	err := ReadNMEA(strings.NewReader(testutil.NMEALog(expected)), func(p *gpx.GPXPoint) error {
+		decoded = append(decoded, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(decoded), len(expected))
+	for i, p := range decoded {
+		testutil.AssertEqual(t, p.Timestamp, expected[i].Timestamp)
+		testutil.AssertEqual(t, fmt.Sprintf("%.6f %.6f %.1f", p.Latitude, p.Longitude, p.Elevation.Value()),
+			fmt.Sprintf("%.6f %.6f %.1f", expected[i].Latitude, expected[i].Longitude, expected[i].Elevation.Value()))
+	}
+}
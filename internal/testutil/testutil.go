@@ -1,44 +1,45 @@
-package main
+// Package testutil provides the sample data and helpers shared by the tests of the other packages.
+package testutil
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/tkrajina/gpxgo/gpx"
 )
 
-func Test_AnalyzeTurn1(t *testing.T) {
-	trk := readTrackSample(t, turn1)
-	trk.gpxAnalyze(Sailing)
-	assertEqual(t, len(trk.Segments), 2)
-	assertEqual(t, trk.Segments[0].Mode, Turning)
-	assertEqual(t, len(trk.Segments[0].Points), 15)
-	assertEqual(t, trk.Segments[1].Mode, Moving)
-	assertEqual(t, len(trk.Segments[1].Points), 8)
-	assertEqual(t, trk.FileName(), "240824-0h01-00.2nm")
+// AssertEqual reports a test error if the got value is not equal to the expected one.
+func AssertEqual[T comparable](t *testing.T, got T, exp T) {
+	t.Helper()
+	if got == exp {
+		return
+	}
+	t.Errorf("got: %v\nexp: %v", got, exp)
 }
 
-func Test_AnalyzeTurn2(t *testing.T) {
-	trk := readTrackSample(t, turn2)
-	trk.gpxAnalyze(Sailing)
-	assertEqual(t, len(trk.Segments), 2)
-	assertEqual(t, trk.Segments[0].Mode, Moving)
-	assertEqual(t, len(trk.Segments[0].Points), 9)
-	assertEqual(t, trk.Segments[1].Mode, Moving)
-	assertEqual(t, len(trk.Segments[1].Points), 11)
-	assertEqual(t, trk.FileName(), "240824-0h01-00.1nm")
-	// logTrackPoints(t, trk)
+// ParseSample parses the track points of a sample into a GPX document with a single track segment.
+func ParseSample(t *testing.T, data string) *gpx.GPX {
+	g, err := gpx.ParseString(`<?xml version="1.0" encoding="UTF-8"?>
+	<gpx xmlns="http://www.topografix.com/GPX/1/1" version="1.1">
+	<trk><trkseg>` + data + `</trkseg></trk></gpx>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
 }
 
-func Test_AnalyzeTurn3(t *testing.T) {
-	trk := readTrackSample(t, turn3)
-	trk.gpxAnalyze(Sailing)
-	assertEqual(t, len(trk.Segments), 3)
-	assertEqual(t, trk.Segments[0].Mode, Moving)
-	assertEqual(t, len(trk.Segments[0].Points), 5)
-	assertEqual(t, trk.Segments[1].Mode, Turning)
-	assertEqual(t, len(trk.Segments[1].Points), 7)
-	assertEqual(t, trk.Segments[2].Mode, Moving)
-	assertEqual(t, len(trk.Segments[2].Points), 11)
-	assertEqual(t, trk.FileName(), "240824-0h01-00.1nm")
-	// logTrackPoints(t, trk)
+// SamplePoints returns the points of the sample.
+func SamplePoints(t *testing.T, data string) (points []*gpx.GPXPoint) {
+	g := ParseSample(t, data)
+	for i := range g.Tracks[0].Segments[0].Points {
+		points = append(points, &g.Tracks[0].Segments[0].Points[i])
+	}
+	return points
 }
 
 /*
@@ -47,7 +48,7 @@ func Test_AnalyzeTurn3(t *testing.T) {
 * 12 turning
 * 8 moving
  */
-const turn1 = `
+const Turn1 = `
 			<trkpt lat="44.08929976634681" lon="-76.90646200440824">
 				<ele>79</ele>
 				<time>2024-08-24T19:09:56Z</time>
@@ -142,7 +143,7 @@ const turn1 = `
 			</trkpt>
 `
 
-const turn2 = `
+const Turn2 = `
 <trkpt lat="44.08867908641696" lon="-76.90315953455865">
 <ele>95.5999984741211</ele>
 <time>2024-08-24T20:48:49Z</time>
@@ -225,7 +226,7 @@ const turn2 = `
 </trkpt>
 `
 
-const turn3 = `			<trkpt lat="44.10134145990014" lon="-76.922099115327">
+const Turn3 = `			<trkpt lat="44.10134145990014" lon="-76.922099115327">
 				<ele>84</ele>
 				<time>2024-08-24T19:17:00Z</time>
 			</trkpt>
@@ -317,3 +318,199 @@ const turn3 = `			<trkpt lat="44.10134145990014" lon="-76.922099115327">
 				<ele>84.19999694824219</ele>
 				<time>2024-08-24T19:18:55Z</time>
 			</trkpt>`
+
+// gpmfTestKLV builds a GPMF entry padded to 32 bits.
+func gpmfTestKLV(key string, typ byte, size, repeat int, data []byte) []byte {
+	b := append([]byte(key), typ, byte(size))
+	b = binary.BigEndian.AppendUint16(b, uint16(repeat))
+	b = append(b, data...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func gpmfTestInt32s(values ...float64) (b []byte) {
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v))))
+	}
+	return b
+}
+
+// gpmfTestPayload builds a GPMF payload with GPS5 readings of the positions.
+func gpmfTestPayload(fix uint32, utc time.Time, positions [][2]float64) []byte {
+	var gps5 []float64
+	for _, p := range positions {
+		gps5 = append(gps5, p[0]*1e7, p[1]*1e7, 80*1000, 3*1000, 3*100)
+	}
+	strm := bytes.Join([][]byte{
+		gpmfTestKLV("GPSF", 'L', 4, 1, binary.BigEndian.AppendUint32(nil, fix)),
+		gpmfTestKLV("GPSU", 'U', 16, 1, []byte(utc.Format("060102150405.000"))),
+		gpmfTestKLV("SCAL", 'l', 4, 5, gpmfTestInt32s(1e7, 1e7, 1000, 1000, 100)),
+		gpmfTestKLV("GPS5", 'l', 20, len(positions), gpmfTestInt32s(gps5...)),
+	}, nil)
+	return gpmfTestKLV("DEVC", 0, 1, len(strm)+8, gpmfTestKLV("STRM", 0, 1, len(strm), strm))
+}
+
+// gpmfTestVideo builds a GoPro like video file with the gpmd track containing the payloads, one per second.
+func gpmfTestVideo(payloads [][]byte) []byte {
+	ftyp := MP4Box("ftyp", []byte("mp41"))
+	mdat := MP4Box("mdat", bytes.Join(payloads, nil))
+	n := uint32(len(payloads))
+	stts := binary.BigEndian.AppendUint32([]byte{0, 0, 0, 0}, 1)
+	stts = binary.BigEndian.AppendUint32(stts, n)
+	stts = binary.BigEndian.AppendUint32(stts, 1000)
+	stsz := binary.BigEndian.AppendUint32([]byte{0, 0, 0, 0}, 0)
+	stsz = binary.BigEndian.AppendUint32(stsz, n)
+	stco := binary.BigEndian.AppendUint32([]byte{0, 0, 0, 0}, n)
+	offset := uint32(len(ftyp) + 8)
+	for _, p := range payloads {
+		stsz = binary.BigEndian.AppendUint32(stsz, uint32(len(p)))
+		stco = binary.BigEndian.AppendUint32(stco, offset)
+		offset += uint32(len(p))
+	}
+	stsc := gpmfTestInt32s(0, 1, 1, 1, 1)
+	stsd := append(gpmfTestInt32s(0, 1, 16), []byte("gpmd\x00\x00\x00\x00\x00\x00\x00\x01")...)
+	mdhd := gpmfTestInt32s(0, 0, 0, 1000, float64(1000*n), 0)
+	moov := MP4Box("moov",
+		MP4Box("mvhd", append(gpmfTestInt32s(0, 0, 0, 1000, float64(1000*n)), make([]byte, 80)...)),
+		MP4Box("trak", MP4Box("mdia",
+			MP4Box("mdhd", mdhd),
+			MP4Box("hdlr", append(gpmfTestInt32s(0, 0), []byte("meta")...)),
+			MP4Box("minf", MP4Box("stbl",
+				MP4Box("stsd", stsd),
+				MP4Box("stts", stts),
+				MP4Box("stsc", stsc),
+				MP4Box("stsz", stsz),
+				MP4Box("stco", stco),
+			)),
+		)),
+	)
+	return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+}
+
+// GoProVideo simulates a camera that starts recording at @start and follows the track points,
+// with the camera GPS time lagging by @lag.
+func GoProVideo(points []*gpx.GPXPoint, start time.Time, seconds int, lag time.Duration) []byte {
+	// position at the time, interpolated between the surrounding points
+	position := func(ts time.Time) ([2]float64, bool) {
+		for i := 1; i < len(points); i++ {
+			prev, next := points[i-1], points[i]
+			if ts.Before(prev.Timestamp) {
+				break
+			}
+			if !ts.After(next.Timestamp) {
+				frac := float64(ts.Sub(prev.Timestamp)) / float64(next.Timestamp.Sub(prev.Timestamp))
+				return [2]float64{prev.Latitude + (next.Latitude-prev.Latitude)*frac, prev.Longitude + (next.Longitude-prev.Longitude)*frac}, true
+			}
+		}
+		return [2]float64{}, false
+	}
+	var payloads [][]byte
+	for k := 0; k < seconds; k++ {
+		var positions [][2]float64
+		for i := 0; i < 5; i++ {
+			p, ok := position(start.Add(time.Duration(k)*time.Second + time.Duration(i)*200*time.Millisecond))
+			if !ok {
+				positions = nil
+				break
+			}
+			positions = append(positions, p)
+		}
+		utc := start.Add(time.Duration(k)*time.Second + lag)
+		if positions == nil {
+			// no fix before and after the track
+			payloads = append(payloads, gpmfTestPayload(0, utc, [][2]float64{{0, 0}}))
+		} else {
+			payloads = append(payloads, gpmfTestPayload(3, utc, positions))
+		}
+	}
+	return gpmfTestVideo(payloads)
+}
+
+// MP4Box builds a box with given type and content.
+func MP4Box(typ string, content ...[]byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(bytes.Join(content, nil))))
+	b = append(b, typ...)
+	return append(b, bytes.Join(content, nil)...)
+}
+
+// MP4Movie builds a minimal movie file with the movie header.
+func MP4Movie(version byte, created time.Time, timescale uint32, duration uint64) []byte {
+	creation := uint64(created.Unix() - time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	mvhd := []byte{version, 0, 0, 0}
+	if version == 1 {
+		mvhd = binary.BigEndian.AppendUint64(mvhd, creation)
+		mvhd = binary.BigEndian.AppendUint64(mvhd, creation)
+		mvhd = binary.BigEndian.AppendUint32(mvhd, timescale)
+		mvhd = binary.BigEndian.AppendUint64(mvhd, duration)
+	} else {
+		mvhd = binary.BigEndian.AppendUint32(mvhd, uint32(creation))
+		mvhd = binary.BigEndian.AppendUint32(mvhd, uint32(creation))
+		mvhd = binary.BigEndian.AppendUint32(mvhd, timescale)
+		mvhd = binary.BigEndian.AppendUint32(mvhd, uint32(duration))
+	}
+	mvhd = append(mvhd, make([]byte, 80)...) // rate, volume, matrix, etc
+	return bytes.Join([][]byte{
+		MP4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		MP4Box("free"),
+		MP4Box("moov", MP4Box("mvhd", mvhd), MP4Box("trak")),
+		MP4Box("mdat", make([]byte, 100)),
+	}, nil)
+}
+
+// NMEASentence adds the checksum to the sentence body.
+func NMEASentence(body string) string {
+	var x byte
+	for i := 0; i < len(body); i++ {
+		x ^= body[i]
+	}
+	return fmt.Sprintf("$%s*%02X", body, x)
+}
+
+// NMEALog encodes the points as the GGA and RMC sentences of an NMEA log.
+func NMEALog(points []*gpx.GPXPoint) string {
+	var b strings.Builder
+	coord := func(v float64, pos, neg string, width int) string {
+		hemisphere := pos
+		if v < 0 {
+			v, hemisphere = -v, neg
+		}
+		deg := int(v)
+		return fmt.Sprintf("%0*d%09.6f,%s", width, deg, (v-float64(deg))*60, hemisphere)
+	}
+	for _, p := range points {
+		ts := p.Timestamp.UTC()
+		pos := coord(p.Latitude, "N", "S", 2) + "," + coord(p.Longitude, "E", "W", 3)
+		fmt.Fprintln(&b, NMEASentence(fmt.Sprintf("GPGGA,%s,%s,1,08,0.9,%.1f,M,-34.0,M,,", ts.Format("150405.00"), pos, p.Elevation.Value())))
+		fmt.Fprintln(&b, NMEASentence(fmt.Sprintf("GPRMC,%s,A,%s,5.0,90.0,%s,,,A", ts.Format("150405.00"), pos, ts.Format("020106"))))
+	}
+	return b.String()
+}
+
+var Garmin = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx creator="Garmin Connect" version="1.1"
+  xsi:schemaLocation="http://www.topografix.com/GPX/1/1 http://www.topografix.com/GPX/11.xsd"
+  xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1"
+  xmlns="http://www.topografix.com/GPX/1/1"
+  xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <metadata>
+    <link href="connect.garmin.com">
+      <text>Garmin Connect</text>
+    </link>
+    <time>2024-08-24T19:09:56.000Z</time>
+  </metadata>
+  <trk>
+    <name>Kingston Sailing</name>
+    <type>sailing</type>
+    <trkseg>` + strings.ReplaceAll(Turn1, "</time>", `</time>
+				<extensions>
+					<gpxtpx:TrackPointExtension>
+						<gpxtpx:atemp>24.0</gpxtpx:atemp>
+						<gpxtpx:hr>121</gpxtpx:hr>
+						<gpxtpx:cad>0</gpxtpx:cad>
+					</gpxtpx:TrackPointExtension>
+				</extensions>`) + `
+    </trkseg>
+  </trk>
+</gpx>`
@@ -0,0 +1,21 @@
+// Package tracktest provides the track helpers shared by the tests of the packages using the track package.
+// It is separate from testutil, which the tests of the track package import too.
+package tracktest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/track"
+)
+
+// ReadTrack returns the track of the sample (see testutil), reporting a test error if the sample makes more than one track.
+func ReadTrack(t *testing.T, data string) *track.Track {
+	t.Helper()
+	ts := track.BuildTracks(track.GPXSegments(testutil.ParseSample(t, data), ""), time.Hour)
+	if len(ts) != 1 {
+		t.Fatalf("found %d tracks", len(ts))
+	}
+	return &ts[0]
+}
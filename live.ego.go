@@ -0,0 +1,62 @@
+// Generated by ego.
+// DO NOT EDIT
+
+//line live.ego:1
+
+package main
+
+import "fmt"
+import "html"
+import "io"
+import "context"
+
+import "github.com/mkobetic/gpx/render"
+import "github.com/mkobetic/gpx/track"
+
+func renderLive(w io.Writer, params *track.AnalysisParameters) {
+
+//line live.ego:9
+	_, _ = io.WriteString(w, "\n<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n<title>Live track</title>\n<style>\n")
+//line live.ego:16
+	_, _ = fmt.Fprint(w, liveCSS)
+//line live.ego:17
+	_, _ = io.WriteString(w, "\n</style>\n</head>\n<body>\n<div id=\"status\">\n    <span id=\"live-connection\" class=\"disconnected\">&#9679;</span>\n    <span id=\"live-time\"></span>\n    <span id=\"live-speed\"></span>\n    <span id=\"live-heading\"></span>\n    <span id=\"live-distance\"></span>\n    <span id=\"legend\">\n    ")
+//line live.ego:27
+	for _, mode := range []track.Mode{track.Moving, track.Turning, track.Static} {
+//line live.ego:28
+		_, _ = io.WriteString(w, "\n        <span style=\"color: ")
+//line live.ego:28
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("#%03x", render.ModeColors[mode]))))
+//line live.ego:28
+		_, _ = io.WriteString(w, "\">&#9632; ")
+//line live.ego:28
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(mode)))
+//line live.ego:28
+		_, _ = io.WriteString(w, "</span>\n    ")
+//line live.ego:29
+	}
+//line live.ego:30
+	_, _ = io.WriteString(w, "\n        <span class=\"pending\">&#9632; pending</span>\n    </span>\n</div>\n<svg id=\"map\" xmlns=\"http://www.w3.org/2000/svg\" preserveAspectRatio=\"xMidYMid meet\">\n    <g id=\"track\"></g>\n    <polyline id=\"pending\" points=\"\"/>\n    <circle id=\"boat\" r=\"6\" visibility=\"hidden\"/>\n</svg>\n<script>\nconst modeColors = ")
+//line live.ego:39
+	_, _ = fmt.Fprint(w, modeColorsData())
+//line live.ego:39
+	_, _ = io.WriteString(w, ";\nconst speedUnit = \"")
+//line live.ego:40
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(params.SpeedLabel())))
+//line live.ego:40
+	_, _ = io.WriteString(w, "\";\nconst distanceUnit = \"")
+//line live.ego:41
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(params.LongDistanceLabel())))
+//line live.ego:41
+	_, _ = io.WriteString(w, "\";\n")
+//line live.ego:42
+	_, _ = fmt.Fprint(w, liveScript)
+//line live.ego:43
+	_, _ = io.WriteString(w, "\n</script>\n</body>\n</html>\n")
+//line live.ego:46
+}
+
+var _ fmt.Stringer
+var _ io.Reader
+var _ context.Context
+var _ = html.EscapeString
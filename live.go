@@ -0,0 +1,173 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/mkobetic/gpx/render"
+	"github.com/mkobetic/gpx/track"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+//go:embed live.css
+var liveCSS string
+
+//go:embed live.js
+var liveScript string
+
+// LiveOptions configure the live tracking of an NMEA stream (see -live).
+type LiveOptions struct {
+	Addr     string // address of the web server with the live map
+	Source   string // NMEA stream, see formats.OpenNMEA
+	Activity track.Activity
+}
+
+// liveTrack is a track growing with the points arriving from the stream.
+// The points are analyzed by an Analyzer, so the Mode of the latest points is determined with a lag.
+type liveTrack struct {
+	params   *track.AnalysisParameters
+	mu       sync.Mutex
+	analyzer *track.Analyzer
+	last     time.Time     // time of the last point
+	points   track.Points  // analyzed points
+	pending  track.Points  // points pending the analysis
+	distance float64       // distance of the analyzed points in long distance units
+	totals   []float64     // distance from the start to each analyzed point
+	changed  chan struct{} // closed and replaced when points are added
+}
+
+func newLiveTrack(params *track.AnalysisParameters) *liveTrack {
+	// the track bounds are not known, the analyzer uses the longitudinal adjustment of the start
+	return &liveTrack{params: params, analyzer: track.NewAnalyzer(nil, "", params), changed: make(chan struct{})}
+}
+
+// Live runs the web server with the live map of the track of the NMEA stream.
+// The stream is reconnected when it ends or fails, the points are appended to the same track.
+func Live(opts *LiveOptions) error {
+	t := newLiveTrack(opts.Activity.Params())
+	go func() {
+		for {
+			if err := t.read(opts.Source); err != nil {
+				fmt.Println(err)
+			} else {
+				fmt.Printf("%s: stream ended\n", opts.Source)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", t.page)
+	mux.HandleFunc("/events", t.events)
+	fmt.Printf("Tracking %s live at http://%s/\n", opts.Source, opts.Addr)
+	return http.ListenAndServe(opts.Addr, mux)
+}
+
+// read adds the points of the NMEA stream of the source until it ends.
+func (t *liveTrack) read(source string) error {
+	r, err := formats.OpenNMEA(source)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return formats.ReadNMEA(r, func(p *gpx.GPXPoint) error {
+		t.add(p)
+		return nil
+	})
+}
+
+// add appends the point to the track, the points that are not later than the last point are dropped.
+func (t *liveTrack) add(gp *gpx.GPXPoint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !gp.Timestamp.After(t.last) {
+		return
+	}
+	t.last = gp.Timestamp
+	points, _ := t.analyzer.Add(gp)
+	for _, p := range points {
+		t.distance += t.params.AsLongDistance(p.Distance)
+		t.totals = append(t.totals, t.distance)
+	}
+	t.points = append(t.points, points...)
+	t.pending = t.analyzer.Pending()
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// data returns the JSON of the analyzed points from the @from-th point and of the points pending the analysis,
+// the number of the analyzed points and the channel signalling more points.
+// The points are [time (unix ms), latitude, longitude, speed, heading, distance, mode].
+func (t *liveTrack) data(from int) (final, pending string, n int, changed <-chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	encode := func(ps track.Points, distance func(i int) float64) string {
+		data := make([][]any, len(ps))
+		for i, p := range ps {
+			data[i] = []any{
+				p.GPX.Timestamp.UnixMilli(), p.GPX.Latitude, p.GPX.Longitude,
+				render.Round(p.Speed, 10), p.Heading, render.Round(distance(i), 1000), p.Mode,
+			}
+		}
+		b, _ := json.Marshal(data)
+		return string(b)
+	}
+	final = encode(t.points[from:], func(i int) float64 { return t.totals[from+i] })
+	distance := t.distance
+	pending = encode(t.pending, func(i int) float64 {
+		distance += t.params.AsLongDistance(t.pending[i].Distance)
+		return distance
+	})
+	return final, pending, len(t.points), t.changed
+}
+
+// page serves the live map.
+func (t *liveTrack) page(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderLive(w, t.params)
+}
+
+// events streams the points to the live map as server-sent events,
+// a points event with the newly analyzed points and a pending event with the points pending the analysis.
+func (t *liveTrack) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	sent := 0
+	for {
+		final, pending, n, changed := t.data(sent)
+		if n > sent {
+			fmt.Fprintf(w, "event: points\ndata: %s\n\n", final)
+			sent = n
+		}
+		fmt.Fprintf(w, "event: pending\ndata: %s\n\n", pending)
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// modeColorsData returns the JSON of the colors of the modes for the live map.
+func modeColorsData() string {
+	colors := map[track.Mode]string{}
+	for mode, color := range render.ModeColors {
+		colors[mode] = fmt.Sprintf("#%03x", color)
+	}
+	b, _ := json.Marshal(colors)
+	return string(b)
+}
@@ -0,0 +1,97 @@
+// live map of the track streamed from the server (see liveTrack.events)
+// the points are [time (unix ms), latitude, longitude, speed, heading, distance, mode]
+const svgNS = "http://www.w3.org/2000/svg";
+const svg = document.getElementById("map");
+const trackLayer = document.getElementById("track");
+const pendingLine = document.getElementById("pending");
+const boat = document.getElementById("boat");
+const border = 20; // map padding in pixels
+
+// the positions are projected using Web Mercator into meters (roughly) from the first point
+let origin;
+function project(p) {
+  if (origin === undefined) {
+    origin = { lon: p[2], y: mercator(p[1]), k: 111320 * Math.cos((p[1] * Math.PI) / 180) };
+  }
+  return [(p[2] - origin.lon) * origin.k, -(mercator(p[1]) - origin.y) * origin.k];
+}
+
+function mercator(lat) {
+  return (Math.log(Math.tan(Math.PI / 4 + (lat * Math.PI) / 360)) * 180) / Math.PI;
+}
+
+let final = []; // analyzed points
+let pending = []; // points pending the analysis
+let bounds; // [minX, minY, maxX, maxY] of the projected points
+let run; // polyline of the last run of the analyzed points in the same mode
+
+function extend(xy) {
+  if (bounds === undefined) {
+    bounds = [xy[0], xy[1], xy[0], xy[1]];
+    return;
+  }
+  bounds = [Math.min(bounds[0], xy[0]), Math.min(bounds[1], xy[1]), Math.max(bounds[2], xy[0]), Math.max(bounds[3], xy[1])];
+}
+
+function addPoints(points) {
+  for (const p of points) {
+    const xy = project(p);
+    extend(xy);
+    if (run === undefined || run.dataset.mode !== p[6]) {
+      // the runs are joined at the first point of the next run
+      const last = final.length > 0 ? project(final[final.length - 1]) + " " : "";
+      run = document.createElementNS(svgNS, "polyline");
+      run.dataset.mode = p[6];
+      run.setAttribute("stroke", modeColors[p[6]] || "#000");
+      run.setAttribute("points", last);
+      trackLayer.appendChild(run);
+    }
+    run.setAttribute("points", run.getAttribute("points") + xy + " ");
+    final.push(p);
+  }
+}
+
+function setPending(points) {
+  pending = points;
+  const tail = final.length > 0 ? [final[final.length - 1], ...pending] : pending;
+  pendingLine.setAttribute("points", tail.map((p) => { const xy = project(p); extend(xy); return xy; }).join(" "));
+}
+
+function redraw() {
+  const last = pending.length > 0 ? pending[pending.length - 1] : final[final.length - 1];
+  if (last === undefined) {
+    return;
+  }
+  // fit the track into the map, keeping the scale at least 1 pixel per meter for a single point
+  const w = Math.max(bounds[2] - bounds[0], 10);
+  const h = Math.max(bounds[3] - bounds[1], 10);
+  const rect = svg.getBoundingClientRect();
+  const scale = Math.max(w / Math.max(rect.width - 2 * border, 1), h / Math.max(rect.height - 2 * border, 1));
+  const cx = (bounds[0] + bounds[2]) / 2;
+  const cy = (bounds[1] + bounds[3]) / 2;
+  const vw = rect.width * scale;
+  const vh = rect.height * scale;
+  svg.setAttribute("viewBox", `${cx - vw / 2} ${cy - vh / 2} ${vw} ${vh}`);
+  const xy = project(last);
+  boat.setAttribute("cx", xy[0]);
+  boat.setAttribute("cy", xy[1]);
+  boat.setAttribute("r", 6 * scale);
+  boat.setAttribute("visibility", "visible");
+  document.getElementById("live-time").textContent = new Date(last[0]).toLocaleTimeString();
+  document.getElementById("live-speed").textContent = `${last[3].toFixed(1)} ${speedUnit}`;
+  document.getElementById("live-heading").textContent = `${last[4]}°`;
+  document.getElementById("live-distance").textContent = `${last[5].toFixed(2)} ${distanceUnit}`;
+}
+
+const connection = document.getElementById("live-connection");
+const events = new EventSource("events");
+events.addEventListener("open", () => { connection.className = "connected"; });
+events.addEventListener("error", () => { connection.className = "disconnected"; });
+events.addEventListener("points", (e) => {
+  addPoints(JSON.parse(e.data));
+});
+events.addEventListener("pending", (e) => {
+  setPending(JSON.parse(e.data));
+  redraw();
+});
+window.addEventListener("resize", redraw);
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// replayNMEA serves the NMEA log to the first TCP client, a stand-in for a multiplexer.
+func replayNMEA(t *testing.T, log string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.Write([]byte(log))
+	}()
+	return "tcp://" + l.Addr().String()
+}
+
+func Test_LiveTrack(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	var points []*gpx.GPXPoint
+	for i := range trk.GPX.Segments[0].Points {
+		points = append(points, &trk.GPX.Segments[0].Points[i])
+	}
+	lt := newLiveTrack(track.Sailing.Params())
+	if err := lt.read(replayNMEA(t, testutil.NMEALog(points))); err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(lt.points)+len(lt.pending), len(points))
+	// the last points are pending the analysis for the lack of the points following them
+	testutil.AssertEqual(t, len(lt.points), 20)
+	// the analyzed points match the analysis of the whole track
+	trk.Analyze(track.Sailing, nil)
+	var batch track.Points
+	for _, s := range trk.Segments {
+		batch = append(batch, s.Points...)
+	}
+	for i, p := range lt.points {
+		testutil.AssertEqual(t, p.Mode, batch[i].Mode)
+		testutil.AssertEqual(t, p.HeadingChange, batch[i].HeadingChange)
+		testutil.AssertEqual(t, p.Heading, batch[i].Heading)
+	}
+	for _, p := range lt.pending {
+		testutil.AssertEqual(t, p.Mode, track.Mode(""))
+	}
+}
+
+func Test_LiveEvents(t *testing.T) {
+	lt := newLiveTrack(track.Sailing.Params())
+	s := httptest.NewServer(http.HandlerFunc(lt.events))
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", s.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	testutil.AssertEqual(t, resp.Header.Get("Content-Type"), "text/event-stream")
+	r := bufio.NewReader(resp.Body)
+	event := func() (name, data string) {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "":
+				return name, data
+			case strings.HasPrefix(line, "event: "):
+				name = line[7:]
+			case strings.HasPrefix(line, "data: "):
+				data = line[6:]
+			}
+		}
+	}
+	name, data := event()
+	testutil.AssertEqual(t, name+" "+data, "pending []")
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	for i := range trk.GPX.Segments[0].Points {
+		lt.add(&trk.GPX.Segments[0].Points[i])
+	}
+	// the events of the first points may be skipped, the client gets all the points added since the last event
+	var final int
+	for final < 20 {
+		name, data = event()
+		if name == "points" {
+			final += strings.Count(data, "[") - 1
+		}
+	}
+	testutil.AssertEqual(t, final, 20)
+	testutil.AssertEqual(t, strings.Contains(data, `"moving"]`), true)
+	name, data = event()
+	testutil.AssertEqual(t, name, "pending")
+	testutil.AssertEqual(t, strings.Count(data, `""]`), 3)
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/tkrajina/gpxgo/gpx"
+	"github.com/mkobetic/gpx/formats"
+	"github.com/mkobetic/gpx/render"
+	"github.com/mkobetic/gpx/track"
 )
 
 var (
@@ -24,16 +26,21 @@ var (
 const usage = `Usage: gpx [flags] files...
 
 Simple GPS track processor for sailing race tracks:
-* reads all gpx files specified on the command line
+* reads all gpx files (or FIT activity files, or GoPro videos with GPS telemetry) specified on the command line
 * pulls out all track segments
 * discards any duplicate or superfluous (very short) segments
 * combines segments that are no more than 1h apart into tracks
 * renders each track into a map saved as an SVG file
+* (optional) renders each track map into a PNG image as well (-png)
+* (optional) generates an HTML report with the map, stats, charts and segments of each track (-html)
+* (optional) crops the tracks to a time range (-from, -to)
 * saves each track into a new GPX file
 * (optional) analyses each track and splits it into straight moving, turning and static segments (-a)
 * (optional) point of sail analysis and classification of the segments (-wd)
 * (optional) generates subtitles file with gps metrics that can be embedded in a video file (-vo)
 * (optional) generates chapter metadata file with a chapter for each segment that can be embedded in a video file (-vo)
+* (optional) serves a local web UI for browsing the tracks of a directory (-serve)
+* (optional) tracks a live NMEA 0183 stream on a map in the browser (-live)
 (see https://github.com/mkobetic/gpx/blob/master/README.md for more details)
 
 Flags:`
@@ -50,37 +57,99 @@ func main() {
 	// flags
 	var usage string
 	out := flag.String("o", ".", "directory for generated files")
-	fMinSegmentLength := flag.Int("ss", 20, "discard segments that are shorter than this number of points")
+	fMinSegmentLength := flag.Int("ss", 20, "discard segments with this number of points or fewer")
 	fVersion := flag.Bool("version", false, "print version information")
 	fVerbose := flag.Bool("v", false, "verbose, print more processing details")
 
-	var fActivity Activity
-	usage = "analyze tracks using specified activity type\nsupported types: " + strings.Join(KnownActivities, ", ")
+	fServe := flag.String("serve", "", "serve a local web UI for browsing the tracks of the input files and directories at the address, e.g. localhost:8080\n"+
+		"the files are analyzed when first listed (-a sail is implied) and the results are cached (see -cache)")
+	fLive := flag.String("live", "", "track the NMEA 0183 stream of the source live, tcp://host:port or udp://[host]:port (e.g. udp://:10110)\n"+
+		"the live map is served at the -serve address (default localhost:8080), -a sail is implied")
+	fCache := flag.String("cache", "", "directory for the cached results of -serve (default gpx in the user cache directory)")
+
+	fReport := flag.Bool("html", false, "generate also a self-contained HTML report with the map, stats and charts of each track")
+
+	var fMapImageWidth, fMapImageHeight int
+	flag.Func("png", "render the map also as a PNG image of the specified size, e.g. 1600x1200", func(size string) (err error) {
+		fMapImageWidth, fMapImageHeight, err = render.ParseSize(size)
+		return err
+	})
+
+	fMapOptions := &render.MapOptions{}
+	usage = "embed offline map tiles from an MBTiles file or a z/x/y.png tile directory as the background of the map"
+	flag.Func("bg", usage, func(path string) (err error) {
+		fMapOptions.Background, err = render.OpenTiles(path)
+		return err
+	})
+
+	usage = "track coloring of the map (default speed), can be changed in the map viewer too\n" +
+		"attitude (upwind/beam/downwind), vmg and type (point of sail and turn type) require point of sail analysis (-wd)\n" +
+		"type colors the segment types of the activities not powered by wind (-a), e.g. work/easy or planing/displacement\n" +
+		"supported colorings: " + strings.Join(render.KnownMapColorings, ", ")
+	flag.Func("color", usage, func(c string) error {
+		for _, known := range render.KnownMapColorings {
+			if known == c {
+				fMapOptions.Coloring = render.MapColoring(c)
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not a recognized map coloring\nknown colorings are "+strings.Join(render.KnownMapColorings, ", "), c)
+	})
+
+	usage = "range of speeds covered by the speed coloring of the map as MIN:MAX, e.g. 4:12 (default auto)\n" +
+		"auto spreads the colors over the speeds of the track (2nd to 98th percentile), 0:24 is the fixed range of the earlier versions"
+	flag.Func("sr", usage, func(sr string) (err error) {
+		fMapOptions.SpeedRange, err = render.ParseSpeedColorRange(sr)
+		return err
+	})
+
+	usage = "color ramp of the speed coloring of the map (default rainbow), or a comma separated list of colors, e.g. #00f,#ff0,#f00\n" +
+		"supported ramps: " + strings.Join(render.KnownRamps, ", ") + " (viridis, cividis and magma are colorblind-safe)"
+	flag.Func("ramp", usage, func(ramp string) (err error) {
+		fMapOptions.Ramp, err = render.ParseRamp(ramp)
+		return err
+	})
+
+	var fCrop track.TimeRange
+	usage = "crop the tracks to start at this time, as time of day in the track timezone, e.g. 15:04 or 15:04:05,\n" +
+		"or as timestamp, e.g. 2006-01-02T15:04:05Z07:00"
+	flag.Func("from", usage, func(from string) (err error) {
+		fCrop.From, err = track.ParseCropTime(from)
+		return err
+	})
+	usage = "crop the tracks to end at this time, see -from\nthe map viewer shows the -from and -to options of a timeline selection"
+	flag.Func("to", usage, func(to string) (err error) {
+		fCrop.To, err = track.ParseCropTime(to)
+		return err
+	})
+
+	var fActivity track.Activity
+	usage = "analyze tracks using specified activity type\nsupported types: " + strings.Join(track.KnownActivities, ", ")
 	flag.Func("a", usage, func(at string) error {
-		for a, params := range Activities {
+		for a, params := range track.Activities {
 			if a == at {
 				fActivity = params
 				return nil
 			}
 		}
-		return fmt.Errorf("%s is not a recognized activity type\nknown activities are "+strings.Join(KnownActivities, ", "), at)
+		return fmt.Errorf("%s is not a recognized activity type\nknown activities are "+strings.Join(track.KnownActivities, ", "), at)
 	})
 
-	var fWindDirection *direction
-	usage = "wind direction to use for analyzing the track, e.g. NE, or SSW\nif UNK then deduce direction from the track\nimplies -a sail"
+	var fWindDirection *track.Direction
+	usage = "wind direction to use for analyzing the track, e.g. NE, or SSW\nif UNK then deduce direction from the track\nimplies -a sail unless specified, ignored by activities not powered by wind"
 	flag.Func("wd", usage, func(wd string) error {
-		for d, s := range directionToString {
-			if s == wd {
-				fActivity = Sailing
-				fWindDirection = &d
-				return nil
+		if d, ok := track.ParseDirection(wd); ok {
+			if fActivity == nil {
+				fActivity = track.Sailing
 			}
+			fWindDirection = &d
+			return nil
 		}
-		return fmt.Errorf("%s is not a recognized wind direction\nvalid values are "+strings.Join(WindDirections, ", "), wd)
+		return fmt.Errorf("%s is not a recognized wind direction\nvalid values are "+strings.Join(track.WindDirections, ", "), wd)
 	})
 
 	var fVideoOffset *time.Duration
-	usage = "video time offset for subtitles or chapters file, e.g -3.5m or 5m22s\npositive offset means video starts ahead of the track\nrequires -a"
+	usage = "video time offset for subtitles or chapters file, e.g -3.5m or 5m22s\npositive offset means video starts ahead of the track\nwith -video it is the offset of the first video if it is specified with duration only\nrequires -a"
 	flag.Func("vo", usage, func(ts string) error {
 		if fActivity == nil {
 			return fmt.Errorf("option -vo requires analysis (option -a)")
@@ -93,6 +162,141 @@ func main() {
 		return nil
 	})
 
+	var fVideos []string
+	usage = "video file (MP4/MOV) to compute the video offset from, using its GPS telemetry (GoPro) or creation time\n" +
+		"can be repeated or comma separated for a session of several videos in recording order, e.g. GoPro chapters\n" +
+		"a video can be also specified by hand as name=start (time of day) or name=duration (continues the previous video)\n" +
+		"generates subtitles and chapters for each video that overlaps the track\nalternative to -vo, requires -a"
+	flag.Func("video", usage, func(fns string) error {
+		if fActivity == nil {
+			return fmt.Errorf("option -video requires analysis (option -a)")
+		}
+		fVideos = append(fVideos, strings.Split(fns, ",")...)
+		return nil
+	})
+
+	fVideoCorrection := flag.Duration("vc", 0, "camera clock correction for -video added to the video creation time\ne.g. -90s if the camera clock is 90s ahead")
+
+	fVideoTimezone := time.UTC
+	usage = "timezone of the camera clock for -video, e.g. America/Toronto (default UTC)\nlocal means the timezone of the track location (cameras often record local time as UTC)"
+	flag.Func("vtz", usage, func(tz string) error {
+		if tz == "local" {
+			fVideoTimezone = nil
+			return nil
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return err
+		}
+		fVideoTimezone = loc
+		return nil
+	})
+
+	fSubtitleFormats := []string{"vtt"}
+	usage = "comma separated list of subtitle file formats to generate with -vo (default vtt)\nsupported formats: " + strings.Join(render.KnownSubtitleFormats, ", ")
+	flag.Func("sf", usage, func(sf string) error {
+		fSubtitleFormats = nil
+		for _, f := range strings.Split(sf, ",") {
+			if _, ok := render.SubtitleFormats[f]; !ok {
+				return fmt.Errorf("%s is not a recognized subtitle format\nknown formats are "+strings.Join(render.KnownSubtitleFormats, ", "), f)
+			}
+			fSubtitleFormats = append(fSubtitleFormats, f)
+		}
+		return nil
+	})
+
+	fChapterFormats := []string{"ffmetadata"}
+	usage = "comma separated list of chapter file formats to generate with -vo (default ffmetadata)\nsupported formats: " + strings.Join(render.KnownChapterFormats, ", ")
+	flag.Func("cf", usage, func(cf string) error {
+		fChapterFormats = nil
+		for _, f := range strings.Split(cf, ",") {
+			if _, ok := render.ChapterFormats[f]; !ok {
+				return fmt.Errorf("%s is not a recognized chapter format\nknown formats are "+strings.Join(render.KnownChapterFormats, ", "), f)
+			}
+			fChapterFormats = append(fChapterFormats, f)
+		}
+		return nil
+	})
+
+	fChapterOptions := &render.ChapterOptions{}
+	flag.IntVar(&fChapterOptions.FrameRate, "fps", 30, "video frame rate for chapter formats using timecodes (edl, fcpxml)")
+
+	usage = "chapter granularity, which segments make up a chapter (default segment)\n" +
+		"segment - chapter for each segment, type - consecutive segments of the same type are merged\n" +
+		"leg - chapter for each maneuver (tack, gybe) and each leg between them, maneuver - chapters for maneuvers only"
+	flag.Func("cg", usage, func(cg string) error {
+		for _, g := range render.KnownChapterGroupings {
+			if g == cg {
+				fChapterOptions.Grouping = render.ChapterGrouping(cg)
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not a recognized chapter granularity\nknown values are "+strings.Join(render.KnownChapterGroupings, ", "), cg)
+	})
+
+	flag.DurationVar(&fChapterOptions.MinDuration, "cmin", 0, "minimum chapter length, e.g. 1m\nshorter chapters are merged into adjacent chapters, or dropped if there are none (e.g. -cg maneuver)")
+
+	usage = "chapter title template using Go template syntax (https://pkg.go.dev/text/template), or @file to read it from a file\nsee README.md for the available fields"
+	flag.Func("ct", usage, func(ct string) error {
+		if fn, ok := strings.CutPrefix(ct, "@"); ok {
+			b, err := os.ReadFile(fn)
+			if err != nil {
+				return err
+			}
+			ct = string(b)
+		}
+		tmpl, err := render.NewChapterTemplate(ct)
+		if err != nil {
+			return err
+		}
+		fChapterOptions.Template = tmpl
+		return nil
+	})
+
+	var fOverlayOptions *render.OverlayOptions
+	usage = "render a transparent PNG frame sequence with speedometer, compass and mini-map of the specified size, e.g. 1920x1080\n" +
+		"to be composited over the video, requires -vo or -video"
+	flag.Func("overlay", usage, func(size string) error {
+		w, h, err := render.ParseSize(size)
+		if err != nil {
+			return err
+		}
+		fOverlayOptions = &render.OverlayOptions{Width: w, Height: h}
+		return nil
+	})
+	fOverlayFrameRate := flag.Int("ofps", 2, "frame rate of the overlay frame sequence")
+
+	fSubtitleOptions := &render.SubtitleOptions{}
+	usage = "subtitle text template using Go template syntax (https://pkg.go.dev/text/template), or @file to read it from a file\nsee README.md for the available fields"
+	flag.Func("st", usage, func(st string) error {
+		if fn, ok := strings.CutPrefix(st, "@"); ok {
+			b, err := os.ReadFile(fn)
+			if err != nil {
+				return err
+			}
+			st = string(b)
+		}
+		tmpl, err := render.NewSubtitleTemplate(st)
+		if err != nil {
+			return err
+		}
+		fSubtitleOptions.Template = tmpl
+		return nil
+	})
+
+	usage = "emit subtitle cues at fixed interval with interpolated values, e.g. 2s\nby default there is a cue for each track point"
+	flag.Func("si", usage, func(si string) error {
+		d, err := time.ParseDuration(si)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("subtitle interval must be positive")
+		}
+		fSubtitleOptions.Interval = d
+		return nil
+	})
+
 	flag.Parse()
 
 	if *fVersion {
@@ -101,6 +305,56 @@ func main() {
 		fmt.Println("built with " + GoVersion)
 		os.Exit(0)
 	}
+	if fMapOptions.Background != nil {
+		defer fMapOptions.Background.Close()
+	}
+	fMapOptions.Command = withoutOptions(os.Args[:len(os.Args)-flag.NArg()], "from", "to")
+	fMapOptions.Inputs = flag.Args()
+
+	if *fLive != "" {
+		opts := &LiveOptions{Addr: *fServe, Source: *fLive, Activity: fActivity}
+		if opts.Addr == "" {
+			opts.Addr = "localhost:8080"
+		}
+		if opts.Activity == nil {
+			opts.Activity = track.Sailing
+		}
+		if err := Live(opts); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
+
+	if *fServe != "" {
+		opts := &ServeOptions{
+			Addr:             *fServe,
+			Inputs:           flag.Args(),
+			Cache:            *fCache,
+			Activity:         fActivity,
+			Wind:             fWindDirection,
+			MinSegmentLength: *fMinSegmentLength,
+			Map:              fMapOptions,
+			Command:          withoutOptions(fMapOptions.Command, "serve", "cache"),
+		}
+		if len(opts.Inputs) == 0 {
+			opts.Inputs = []string{"."}
+		}
+		if opts.Activity == nil {
+			opts.Activity = track.Sailing
+		}
+		if opts.Cache == "" {
+			dir, err := os.UserCacheDir()
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			opts.Cache = filepath.Join(dir, "gpx")
+		}
+		if err := Serve(opts); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
 
 	// args
 	if len(flag.Args()) == 0 {
@@ -109,59 +363,128 @@ func main() {
 		return
 	}
 
+	videos, err := track.ParseVideos(fVideos)
+	if err != nil {
+		fmt.Printf("Error reading video %s\n", err)
+		return
+	}
+	for _, video := range videos {
+		video.Correction = *fVideoCorrection
+		video.Timezone = fVideoTimezone
+	}
+
 	// Collect all the original segments from the parsed files.
 	// Using Segment instead of gpx.GPXTrackSegment so that we can attach the filenames that they came from.
-	var protoSegments Segments
+	var protoSegments track.Segments
 	for _, fn := range flag.Args() {
-		g, err := gpx.ParseFile(fn)
+		src, err := formats.ReadSource(fn)
 		if err != nil {
 			fmt.Printf("Error opening %s: %s\n", fn, err)
 			return
 		}
-		protoSegments = append(protoSegments, gpxGetSegments(g, filepath.Base(fn))...)
+		protoSegments = append(protoSegments, track.SourceSegments(src)...)
 	}
-	sort.Sort(protoSegments)
-	sn := len(protoSegments)
-	protoSegments = gpxDedupeSegments(protoSegments, *fMinSegmentLength)
-	protoSegments = gpxSplitSegments(protoSegments, time.Hour)
-	protoSegments = gpxDedupeSegments(protoSegments, *fMinSegmentLength)
-	fmt.Printf("Dropped %d duplicate and short segments\n", sn-len(protoSegments))
-
 	// Reassemble tracks from gathered proto-segments and process them.
-	for _, t := range gpxBuildTracks(protoSegments, time.Hour) {
-		if fActivity != nil {
-			t.gpxAnalyze(Sailing)
-			if fWindDirection != nil {
-				windDirection := *fWindDirection
-				if windDirection == UNK {
-					windDirection = t.windDirection()
-				}
-				if windDirection == UNK {
-					fmt.Printf("%s\n  WARNING: Could not determine wind direction, skipping point of sail analysis\n", t.String())
-				} else {
-					t.posClassify(windDirection)
-				}
+	tracks, dropped := track.Collect(protoSegments, *fMinSegmentLength)
+	fmt.Printf("Dropped %d duplicate and short segments\n", dropped)
+	for _, t := range tracks {
+		if !fCrop.IsZero() && !t.Crop(fCrop, *fMinSegmentLength) {
+			if *fVerbose {
+				fmt.Printf("%s nothing left after cropping, skipping\n", t.GPX.TimeBounds().StartTime.In(t.Timezone()).Format(track.TimeFormat))
 			}
+			continue
+		}
+		if fActivity != nil && !t.Analyze(fActivity, fWindDirection) {
+			fmt.Printf("%s\n  WARNING: Could not determine wind direction, skipping point of sail analysis\n", t.String())
 		}
 		fmt.Println(t.String())
+		for _, m := range t.Metrics() {
+			fmt.Printf("  %s\n", m)
+		}
 		if *fVerbose {
 			for i, s := range t.Segments {
 				fmt.Printf("%d: %s\n", i, s.String())
 			}
 		}
-		if err := t.WriteMapFile(*out); err != nil {
+		if err := render.WriteMapFile(&t, *out, fMapOptions); err != nil {
 			fmt.Println(err)
 		}
-		if fActivity != nil && fVideoOffset != nil {
-			if err := t.WriteSubtitleFile(*out, *fVideoOffset); err != nil {
+		if *fReport {
+			if err := render.WriteReportFile(&t, *out, fMapOptions); err != nil {
 				fmt.Println(err)
 			}
-			if err := t.WriteChapterFile(*out, *fVideoOffset); err != nil {
+		}
+		if fMapImageWidth > 0 {
+			if err := render.WriteMapImage(&t, *out, fMapImageWidth, fMapImageHeight, fMapOptions); err != nil {
 				fmt.Println(err)
 			}
 		}
+		var spans []track.VideoSpan
+		if len(videos) > 0 {
+			for _, span := range videos.Spans(&t, fVideoOffset) {
+				if span.Overlaps {
+					fmt.Printf("  video %s offset %s\n", span.Video, span.Offset)
+					spans = append(spans, span)
+				} else if *fVerbose {
+					fmt.Printf("  video %s doesn't overlap the track\n", span.Video)
+				}
+			}
+		} else if fVideoOffset != nil {
+			spans = []track.VideoSpan{{Offset: *fVideoOffset}}
+		}
+		if fActivity != nil {
+			for _, span := range spans {
+				// files are named after the videos only if there are several
+				var name string
+				if len(videos) > 1 {
+					name = span.Video.Name()
+				}
+				opts := *fSubtitleOptions
+				opts.Duration = span.Duration
+				opts.Map = fMapOptions
+				for _, format := range fSubtitleFormats {
+					if err := render.WriteSubtitleFile(&t, *out, name, span.Offset, format, &opts); err != nil {
+						fmt.Println(err)
+					}
+				}
+				if fOverlayOptions != nil {
+					overlayOpts := *fOverlayOptions
+					overlayOpts.FrameRate = *fOverlayFrameRate
+					overlayOpts.Duration = span.Duration
+					overlayOpts.Map = fMapOptions
+					if err := render.WriteOverlayFrames(&t, *out, name, span.Offset, &overlayOpts); err != nil {
+						fmt.Println(err)
+					}
+				}
+				chapterOpts := *fChapterOptions
+				chapterOpts.Duration = span.Duration
+				for _, format := range fChapterFormats {
+					if err := render.WriteChapterFile(&t, *out, name, span.Offset, format, &chapterOpts); err != nil {
+						fmt.Println(err)
+					}
+				}
+			}
+		}
 		if err := t.WriteGpxFile(*out); err != nil {
 			fmt.Println(err)
 		}
 	}
 }
+
+// withoutOptions returns the command line without the named options and the end of options marker (--),
+// e.g. so that the map viewer can add the crop options (-from, -to) of a timeline selection.
+func withoutOptions(args []string, names ...string) (command []string) {
+	for i := 0; i < len(args); i++ {
+		name, _, value := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		switch {
+		case i > 0 && strings.HasPrefix(args[i], "-") && slices.Contains(names, name):
+			if !value {
+				i++ // skip the value
+			}
+		case args[i] == "--":
+		default:
+			command = append(command, args[i])
+		}
+	}
+	return command
+}
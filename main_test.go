@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+func Test_WithoutOptions(t *testing.T) {
+	args := []string{"gpx", "-a", "sail", "-from", "15:00", "--to=16:00", "-o", "to", "-serve", ":8080", "--"}
+	testutil.AssertEqual(t, fmt.Sprint(withoutOptions(args, "from", "to")), "[gpx -a sail -o to -serve :8080]")
+	testutil.AssertEqual(t, fmt.Sprint(withoutOptions(args, "serve", "cache")), "[gpx -a sail -from 15:00 --to=16:00 -o to]")
+}
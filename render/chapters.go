@@ -0,0 +1,466 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+)
+
+// ChapterFormat renders chapters for a track into a file with the extension.
+type ChapterFormat struct {
+	Extension string
+	Render    func(t *track.Track, w io.Writer, videoOffset time.Duration, opts *ChapterOptions)
+}
+
+// ChapterFormats maps format names to the corresponding chapter renderers.
+var ChapterFormats = map[string]ChapterFormat{
+	"ffmetadata": {"chapters", renderChapters},
+	"fcpxml":     {"fcpxml", renderFcpxmlChapters},
+	"edl":        {"edl", renderEdlChapters},
+	"mkv":        {"chapters.xml", renderMkvChapters},
+	"youtube":    {"youtube.txt", renderYoutubeChapters},
+}
+
+var KnownChapterFormats []string
+
+func init() {
+	for f := range ChapterFormats {
+		KnownChapterFormats = append(KnownChapterFormats, f)
+	}
+	sort.Strings(KnownChapterFormats)
+}
+
+// ChapterOptions control the content and timing of the chapters.
+type ChapterOptions struct {
+	Duration    time.Duration      // length of the video, chapters past it are dropped, zero means no limit
+	FrameRate   int                // video frame rate for the formats using timecodes, zero means 30fps
+	Grouping    ChapterGrouping    // which segments make up a chapter, zero value means a chapter for each segment
+	MinDuration time.Duration      // shorter chapters are merged with adjacent chapters or dropped (see mergeChapters)
+	Template    *template.Template // chapter title executed with a *chapter, nil means DefaultChapterTemplate
+}
+
+func (opts *ChapterOptions) frameRate() int64 {
+	if opts.FrameRate > 0 {
+		return int64(opts.FrameRate)
+	}
+	return 30
+}
+
+// frames converts the video time to a number of frames, negative times are clamped to 0.
+func (opts *ChapterOptions) frames(ts time.Duration) int64 {
+	if ts < 0 {
+		return 0
+	}
+	return (int64(ts)*opts.frameRate() + int64(time.Second)/2) / int64(time.Second)
+}
+
+// ChapterGrouping determines which segments of the track make up a chapter.
+type ChapterGrouping string
+
+const (
+	BySegment  ChapterGrouping = "segment"  // a chapter for each segment
+	ByType     ChapterGrouping = "type"     // consecutive segments of the same type are merged
+	ByLeg      ChapterGrouping = "leg"      // a chapter for each maneuver and each leg between maneuvers
+	ByManeuver ChapterGrouping = "maneuver" // a chapter for each maneuver only
+)
+
+var KnownChapterGroupings = []string{string(BySegment), string(ByType), string(ByLeg), string(ByManeuver)}
+
+// group splits the segments into the chapter groups, all segments are included
+// except for ByManeuver where only maneuvers are.
+func (g ChapterGrouping) group(segments []*track.Segment) (groups [][]*track.Segment) {
+	for i, s := range segments {
+		if g == ByManeuver && !s.IsManeuver() {
+			continue
+		}
+		if n := len(groups); n > 0 && i > 0 {
+			prev := segments[i-1]
+			last := groups[n-1]
+			switch {
+			case g == ByType && s.TypeKey() == prev.TypeKey(),
+				g == ByLeg && !s.IsManeuver() && !prev.IsManeuver():
+				groups[n-1] = append(last, s)
+				continue
+			}
+		}
+		groups = append(groups, []*track.Segment{s})
+	}
+	return groups
+}
+
+// DefaultChapterTemplate is the chapter title template used when none is specified.
+const DefaultChapterTemplate = `{{.Time.Format "15:04:05"}} {{.Type}} ({{.Summary}})`
+
+// NewChapterTemplate parses the chapter title template.
+// Besides the standard template functions, hms formats a duration as h:mm:ss.
+func NewChapterTemplate(text string) (*template.Template, error) {
+	return template.New("chapter").Funcs(template.FuncMap{"hms": HMS}).Parse(text)
+}
+
+var defaultChapterTemplate = template.Must(NewChapterTemplate(DefaultChapterTemplate))
+
+// chapter is a section of the video corresponding to one or more segments of the track.
+// It is also the data passed to the chapter template.
+type chapter struct {
+	Index      int           // sequence number of the chapter starting from 1
+	Start, End time.Duration // video time of the chapter
+	Time       time.Time     // track time of the start of the chapter in the track timezone
+	Elapsed    time.Duration // time since the start of the track
+	Duration   time.Duration // track duration of the chapter segments
+	Distance   float64       // distance covered in distance units
+	Title      string
+	Segment    *track.Segment   // the first segment of the chapter
+	Segments   []*track.Segment // all segments of the chapter
+	Track      *track.Track
+}
+
+func (c *chapter) Count() int               { return len(c.Segments) }
+func (c *chapter) SpeedUnit() string        { return c.Track.Params.SpeedLabel() }
+func (c *chapter) DistanceUnit() string     { return c.Track.Params.DistanceLabel() }
+func (c *chapter) LongDistanceUnit() string { return c.Track.Params.LongDistanceLabel() }
+func (c *chapter) LongDistance() float64    { return c.Track.Params.AsLongDistance(c.Distance) }
+
+// Speed returns the average speed of the chapter segments.
+func (c *chapter) Speed() float64 {
+	var speed float64
+	for _, s := range c.Segments {
+		speed += s.Speed.Avg * float64(s.Duration)
+	}
+	if c.Duration <= 0 {
+		return c.Segment.Speed.Avg
+	}
+	return speed / float64(c.Duration)
+}
+
+// Type returns the type of the segment, or for chapters with several segments
+// the type (or mode if unknown) that takes up most of the chapter.
+func (c *chapter) Type() string {
+	if len(c.Segments) == 1 {
+		return c.Segment.TypeString()
+	}
+	durations := make(map[string]time.Duration)
+	var dominant string
+	for _, s := range c.Segments {
+		key := s.TypeKey()
+		durations[key] += s.Duration
+		if durations[key] > durations[dominant] {
+			dominant = key
+		}
+	}
+	return dominant
+}
+
+// Summary returns the stats of the segment, or a shorter summary for chapters with several segments.
+func (c *chapter) Summary() string {
+	if len(c.Segments) == 1 {
+		return c.Segment.ShortString()
+	}
+	return fmt.Sprintf("%.0fm/%.0fs @ %.1f %s, %d segments",
+		c.Distance, c.Duration.Seconds(), c.Speed(), c.SpeedUnit(), len(c.Segments))
+}
+
+// trackChapters returns the chapters of the track that fall into the video,
+// the segments are grouped into chapters according to opts.Grouping.
+// Positive @videoOffset means the video starts ahead of the track, the timestamps will be adjusted accordingly.
+// Negative @videoOffset means the video starts later and therefore the corresponding initial part of the track will be skipped.
+// Positive opts.Duration drops the chapters past the end of the video.
+func trackChapters(t *track.Track, videoOffset time.Duration, opts *ChapterOptions) (chapters []chapter) {
+	// video time of each segment
+	starts := make(map[*track.Segment]time.Duration)
+	start := videoOffset
+	for _, segment := range t.Segments {
+		starts[segment] = start
+		start += segment.Duration
+	}
+	var all []chapter
+	for _, group := range opts.Grouping.group(t.Segments) {
+		c := chapter{Segment: group[0], Segments: group, Track: t, Start: starts[group[0]]}
+		for _, s := range group {
+			c.Duration += s.Duration
+			c.Distance += s.Distance
+		}
+		c.End = c.Start + c.Duration
+		all = append(all, c)
+	}
+	for _, c := range mergeChapters(all, opts.MinDuration) {
+		if c.End < 0 {
+			continue
+		}
+		if opts.Duration > 0 {
+			if c.Start >= opts.Duration {
+				break
+			}
+			c.End = min(c.End, opts.Duration)
+		}
+		c.Index = len(chapters) + 1
+		c.Time = c.Segment.Start.In(t.Timezone())
+		c.Elapsed = c.Segment.Start.Sub(t.Start)
+		c.Title = chapterTitle(t, &c, opts)
+		chapters = append(chapters, c)
+	}
+	return chapters
+}
+
+// mergeChapters merges chapters shorter than @minDuration with the adjacent chapters,
+// the merged chapter keeps the title of the longer one. Short chapters that are not adjacent
+// to any other chapter are dropped, unless there is only one.
+func mergeChapters(chapters []chapter, minDuration time.Duration) (merged []chapter) {
+	long := func(c *chapter) bool { return c.End-c.Start >= minDuration }
+	join := func(a *chapter, b chapter) {
+		if b.End-b.Start > a.End-a.Start {
+			a.Title = b.Title
+		}
+		a.End = b.End
+		a.Duration += b.Duration
+		a.Distance += b.Distance
+		a.Segments = append(a.Segments[:len(a.Segments):len(a.Segments)], b.Segments...)
+		if a.Segment == nil {
+			a.Segment = b.Segment
+		}
+	}
+	for _, c := range chapters {
+		n := len(merged)
+		if n > 0 && merged[n-1].End == c.Start && !(long(&merged[n-1]) && long(&c)) {
+			join(&merged[n-1], c)
+			continue
+		}
+		if n > 0 && !long(&merged[n-1]) {
+			merged = merged[:n-1]
+		}
+		merged = append(merged, c)
+	}
+	// the last chapter can still be short
+	if n := len(merged); n > 1 && !long(&merged[n-1]) {
+		last := merged[n-1]
+		merged = merged[:n-1]
+		if merged[n-2].End == last.Start {
+			join(&merged[n-2], last)
+		}
+	}
+	return merged
+}
+
+// chapterTitle returns the chapter title produced by the template, on a single line.
+func chapterTitle(t *track.Track, c *chapter, opts *ChapterOptions) string {
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = defaultChapterTemplate
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, c); err != nil {
+		return err.Error()
+	}
+	return strings.TrimSpace(strings.ReplaceAll(b.String(), "\n", " "))
+}
+
+// Renders an ffmpeg metadata file with a chapter for each segment of the track.
+// See https://ffmpeg.org/ffmpeg-formats.html#Metadata-2
+func renderChapters(t *track.Track, w io.Writer, videoOffset time.Duration, opts *ChapterOptions) {
+	fmt.Fprintln(w, ";FFMETADATA1")
+	fmt.Fprintf(w, "title=%s\n", t.FileName())
+	fmt.Fprintf(w, "created=%s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "source=%s\n", t.Filename)
+	fmt.Fprintf(w, "video_offset=%s\n", videoOffset)
+	fmt.Fprintln(w)
+
+	for _, c := range trackChapters(t, videoOffset, opts) {
+		fmt.Fprintln(w, "[CHAPTER]")
+		fmt.Fprintln(w, "TIMEBASE=1/1000")
+		fmt.Fprintf(w, "START=%d\n", c.Start.Milliseconds())
+		fmt.Fprintf(w, "END=%d\n", c.End.Milliseconds())
+		fmt.Fprintf(w, "title=%s\n", c.Title)
+		fmt.Fprintln(w)
+	}
+}
+
+// XML structures used to write FCPXML files.
+
+type fcpxml struct {
+	XMLName xml.Name     `xml:"fcpxml"`
+	Version string       `xml:"version,attr"`
+	Format  fcpxmlFormat `xml:"resources>format"`
+	Event   fcpxmlEvent  `xml:"library>event"`
+}
+
+type fcpxmlFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxmlEvent struct {
+	Name    string `xml:"name,attr"`
+	Project struct {
+		Name     string `xml:"name,attr"`
+		Sequence struct {
+			Format   string    `xml:"format,attr"`
+			Duration string    `xml:"duration,attr"`
+			TCStart  string    `xml:"tcStart,attr"`
+			TCFormat string    `xml:"tcFormat,attr"`
+			Gap      fcpxmlGap `xml:"spine>gap"`
+		} `xml:"sequence"`
+	} `xml:"project"`
+}
+
+type fcpxmlGap struct {
+	Name     string         `xml:"name,attr"`
+	Offset   string         `xml:"offset,attr"`
+	Start    string         `xml:"start,attr"`
+	Duration string         `xml:"duration,attr"`
+	Markers  []fcpxmlMarker `xml:"marker"`
+}
+
+type fcpxmlMarker struct {
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Value    string `xml:"value,attr"`
+}
+
+// Renders a Final Cut Pro XML project with a marker for each segment of the track.
+// The markers are placed on a gap spanning the video, so that they can be copied onto the video clip.
+// Times are rational numbers of seconds aligned to the frames of the video.
+// See https://developer.apple.com/documentation/professional_video_applications/fcpxml_reference
+func renderFcpxmlChapters(t *track.Track, w io.Writer, videoOffset time.Duration, opts *ChapterOptions) {
+	fps := opts.frameRate()
+	rational := func(frames int64) string {
+		return fmt.Sprintf("%d/%ds", frames, fps)
+	}
+	chapters := trackChapters(t, videoOffset, opts)
+	var duration int64
+	if len(chapters) > 0 {
+		duration = opts.frames(chapters[len(chapters)-1].End)
+	}
+	doc := fcpxml{
+		Version: "1.9",
+		Format:  fcpxmlFormat{ID: "r1", FrameDuration: rational(1), Width: 1920, Height: 1080},
+	}
+	doc.Event.Name = t.FileName()
+	doc.Event.Project.Name = t.FileName()
+	seq := &doc.Event.Project.Sequence
+	seq.Format, seq.Duration, seq.TCStart, seq.TCFormat = "r1", rational(duration), "0s", "NDF"
+	seq.Gap = fcpxmlGap{Name: "Gap", Offset: "0s", Start: "0s", Duration: rational(duration)}
+	for _, c := range chapters {
+		seq.Gap.Markers = append(seq.Gap.Markers, fcpxmlMarker{
+			Start:    rational(opts.frames(c.Start)),
+			Duration: rational(1),
+			Value:    c.Title,
+		})
+	}
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, "<!DOCTYPE fcpxml>")
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	enc.Encode(doc)
+	fmt.Fprintln(w)
+}
+
+// edlStart is the timecode of the start of the timeline, the default in DaVinci Resolve.
+const edlStart = time.Hour
+
+// Renders a CMX3600 EDL marker list with a marker for each segment of the track
+// spanning the segment, as imported by DaVinci Resolve (Timeline > Import > Timeline Markers from EDL).
+func renderEdlChapters(t *track.Track, w io.Writer, videoOffset time.Duration, opts *ChapterOptions) {
+	fmt.Fprintf(w, "TITLE: %s\n", t.FileName())
+	fmt.Fprintln(w, "FCM: NON-DROP FRAME")
+	fmt.Fprintln(w)
+	base := opts.frames(edlStart)
+	for i, c := range trackChapters(t, videoOffset, opts) {
+		start := base + opts.frames(c.Start)
+		length := max(1, opts.frames(c.End)-opts.frames(c.Start))
+		in, out := edlTimecode(start, opts.frameRate()), edlTimecode(start+1, opts.frameRate())
+		fmt.Fprintf(w, "%03d  001      V     C        %s %s %s %s  \n", i+1, in, out, in, out)
+		fmt.Fprintf(w, " |C:ResolveColorBlue |M:%s |D:%d\n", c.Title, length)
+		fmt.Fprintln(w)
+	}
+}
+
+// edlTimecode formats the frame number as a non-drop frame timecode hh:mm:ss:ff.
+func edlTimecode(frames, fps int64) string {
+	ff := frames % fps
+	s := frames / fps
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", s/3600, s/60%60, s%60, ff)
+}
+
+// XML structures used to write Matroska chapter files.
+
+type mkvChapters struct {
+	XMLName xml.Name         `xml:"Chapters"`
+	Atoms   []mkvChapterAtom `xml:"EditionEntry>ChapterAtom"`
+}
+
+type mkvChapterAtom struct {
+	Start   string `xml:"ChapterTimeStart"`
+	End     string `xml:"ChapterTimeEnd"`
+	Display struct {
+		String   string `xml:"ChapterString"`
+		Language string `xml:"ChapterLanguage"`
+	} `xml:"ChapterDisplay"`
+}
+
+// Renders a Matroska chapter XML file with a chapter for each segment of the track,
+// e.g. for mkvmerge --chapters.
+// See https://www.matroska.org/technical/chapters.html
+func renderMkvChapters(t *track.Track, w io.Writer, videoOffset time.Duration, opts *ChapterOptions) {
+	var doc mkvChapters
+	for _, c := range trackChapters(t, videoOffset, opts) {
+		atom := mkvChapterAtom{Start: mkvTimestamp(c.Start), End: mkvTimestamp(c.End)}
+		atom.Display.String, atom.Display.Language = c.Title, "eng"
+		doc.Atoms = append(doc.Atoms, atom)
+	}
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, `<!DOCTYPE Chapters SYSTEM "matroskachapters.dtd">`)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	enc.Encode(doc)
+	fmt.Fprintln(w)
+}
+
+func mkvTimestamp(ts time.Duration) string {
+	ts = max(ts, 0)
+	h, m, s, _ := splitTimestamp(ts)
+	return fmt.Sprintf("%02d:%02d:%02d.%09d", h, m, s, ts%time.Second)
+}
+
+// youtubeMinChapter is the minimum chapter length accepted by YouTube.
+const youtubeMinChapter = 10 * time.Second
+
+// Renders a chapter list for a YouTube video description.
+// See https://support.google.com/youtube/answer/9884579
+func renderYoutubeChapters(t *track.Track, w io.Writer, videoOffset time.Duration, opts *ChapterOptions) {
+	chapters := youtubeChapters(trackChapters(t, videoOffset, opts))
+	hours := len(chapters) > 0 && chapters[len(chapters)-1].Start >= time.Hour
+	for _, c := range chapters {
+		h, m, s, _ := splitTimestamp(c.Start)
+		if hours {
+			fmt.Fprintf(w, "%d:%02d:%02d %s\n", h, m, s, c.Title)
+		} else {
+			fmt.Fprintf(w, "%02d:%02d %s\n", m, s, c.Title)
+		}
+	}
+}
+
+// youtubeChapters adjusts the chapters to YouTube requirements.
+// The first chapter must start at 00:00, so if the video starts well ahead of the track
+// a Start chapter is added. Each chapter lasts until the next one starts and
+// chapters shorter than youtubeMinChapter are merged with their neighbours.
+func youtubeChapters(chapters []chapter) []chapter {
+	var contiguous []chapter
+	for i, c := range chapters {
+		c.Start = max(c.Start, 0)
+		if i == 0 && c.Start > 0 {
+			contiguous = append(contiguous, chapter{End: c.Start, Title: "Start"})
+		}
+		if i+1 < len(chapters) {
+			c.End = chapters[i+1].Start
+		}
+		contiguous = append(contiguous, c)
+	}
+	return mergeChapters(contiguous, youtubeMinChapter)
+}
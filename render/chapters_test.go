@@ -0,0 +1,127 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+)
+
+func Test_ChapterFormats(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn2)
+	trk.Analyze(track.Sailing, nil)
+	chapters := trackChapters(trk, 0, &ChapterOptions{})
+	for format, count := range map[string]string{
+		"ffmetadata": "[CHAPTER]",
+		"fcpxml":     "<marker ",
+		"edl":        "|M:",
+		"mkv":        "<ChapterAtom>",
+	} {
+		var b bytes.Buffer
+		ChapterFormats[format].Render(trk, &b, 0, &ChapterOptions{})
+		testutil.AssertEqual(t, strings.Count(b.String(), count), len(chapters))
+	}
+	// chapters past the end of the video are dropped
+	clipped := trackChapters(trk, -10*time.Second, &ChapterOptions{Duration: 30 * time.Second})
+	testutil.AssertEqual(t, clipped[0].Start < 0, true)
+	testutil.AssertEqual(t, clipped[len(clipped)-1].End, 30*time.Second)
+}
+
+func Test_EdlTimecode(t *testing.T) {
+	opts := &ChapterOptions{FrameRate: 25}
+	testutil.AssertEqual(t, edlTimecode(opts.frames(edlStart+83*time.Second+500*time.Millisecond), 25), "01:01:23:13")
+	testutil.AssertEqual(t, opts.frames(-time.Second), int64(0))
+}
+
+func Test_YoutubeChapters(t *testing.T) {
+	s := time.Second
+	for i, tt := range []struct {
+		chapters []chapter
+		exp      string
+	}{
+		{
+			[]chapter{{Start: 30 * s, End: 60 * s, Title: "a"}, {Start: 60 * s, End: 65 * s, Title: "b"}, {Start: 65 * s, End: 90 * s, Title: "c"}},
+			"0s-30s Start, 30s-1m5s a, 1m5s-1m30s c",
+		},
+		// the video starts shortly before the track
+		{
+			[]chapter{{Start: 5 * s, End: 60 * s, Title: "a"}, {Start: 60 * s, End: 80 * s, Title: "b"}, {Start: 80 * s, End: 85 * s, Title: "c"}},
+			"0s-1m0s a, 1m0s-1m25s b",
+		},
+		// the video starts after the track
+		{
+			[]chapter{{Start: -20 * s, End: 3 * s, Title: "a"}, {Start: 3 * s, End: 60 * s, Title: "b"}, {Start: 60 * s, End: 80 * s, Title: "c"}},
+			"0s-1m0s b, 1m0s-1m20s c",
+		},
+	} {
+		var got []string
+		for _, c := range youtubeChapters(tt.chapters) {
+			got = append(got, c.Start.String()+"-"+c.End.String()+" "+c.Title)
+		}
+		if strings.Join(got, ", ") != tt.exp {
+			t.Errorf("%d: got %s", i, strings.Join(got, ", "))
+		}
+	}
+}
+
+func Test_ChapterGrouping(t *testing.T) {
+	close, broad, broadSB := track.N.PointOfSail(45), track.N.PointOfSail(135), track.N.PointOfSail(225)
+	segments := []*track.Segment{
+		{Mode: track.Moving, Type: close},
+		{Mode: track.Moving, Type: close},
+		{Mode: track.Turning, Type: track.N.TurnType(close, broad)}, // bear away
+		{Mode: track.Moving, Type: broad},
+		{Mode: track.Turning, Type: track.N.TurnType(broad, broadSB)}, // gybe
+		{Mode: track.Moving, Type: broadSB},
+		{Mode: track.Turning, Heading: &track.HeadingRange{Variation: 90}},
+		{Mode: track.Turning, Heading: &track.HeadingRange{Variation: 30}},
+	}
+	for g, exp := range map[ChapterGrouping][]int{
+		BySegment:  {1, 1, 1, 1, 1, 1, 1, 1},
+		ByType:     {2, 1, 1, 1, 1, 2},
+		ByLeg:      {4, 1, 1, 1, 1},
+		ByManeuver: {1, 1},
+	} {
+		var got []int
+		for _, group := range g.group(segments) {
+			got = append(got, len(group))
+		}
+		testutil.AssertEqual(t, fmt.Sprint(got), fmt.Sprint(exp))
+	}
+}
+
+func Test_MergeChapters(t *testing.T) {
+	s := time.Second
+	chapters := []chapter{
+		{Start: 0, End: 5 * s, Title: "a"},
+		{Start: 5 * s, End: 30 * s, Title: "b"},
+		{Start: 30 * s, End: 35 * s, Title: "c"},
+		{Start: 50 * s, End: 55 * s, Title: "d"},
+		{Start: 70 * s, End: 90 * s, Title: "e"},
+	}
+	var got []string
+	for _, c := range mergeChapters(chapters, 10*s) {
+		got = append(got, c.Start.String()+"-"+c.End.String()+" "+c.Title)
+	}
+	testutil.AssertEqual(t, strings.Join(got, ", "), "0s-35s b, 1m10s-1m30s e")
+	testutil.AssertEqual(t, len(mergeChapters(chapters, 0)), len(chapters))
+}
+
+func Test_ChapterTemplate(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn2)
+	trk.Analyze(track.Sailing, nil)
+	trk.Classify(track.N)
+	tmpl, err := NewChapterTemplate("{{.Index}}. {{.Type}}\n{{hms .Elapsed}} {{printf \"%.1f\" .Speed}} {{.SpeedUnit}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chapters := trackChapters(trk, 0, &ChapterOptions{Template: tmpl, Grouping: ByType})
+	c := chapters[0]
+	testutil.AssertEqual(t, c.Title, fmt.Sprintf("1. %s 0:00:00 %.1f kts", c.Type(), c.Speed()))
+	testutil.AssertEqual(t, strings.HasPrefix(chapters[1].Title, "2. "), true)
+}
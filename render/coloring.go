@@ -0,0 +1,482 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+)
+
+// MapColoring is the scheme used to color the track on the map.
+type MapColoring string
+
+const (
+	ColorBySpeed    MapColoring = "speed"    // speed palette
+	ColorByAttitude MapColoring = "attitude" // upwind, beam or downwind, same as the timeline
+	ColorByVMG      MapColoring = "vmg"      // velocity made good towards (upwind) or away from (downwind) the wind
+	ColorByMode     MapColoring = "mode"     // moving, turning or static
+	ColorByType     MapColoring = "type"     // point of sail and tack of the moving segments, turn type of the turning segments, or the activity specific segment type
+	ColorByHeading  MapColoring = "heading"  // color wheel of the heading
+	ColorByTime     MapColoring = "time"     // time elapsed from the start of the track
+)
+
+var KnownMapColorings = []string{string(ColorBySpeed), string(ColorByAttitude), string(ColorByVMG),
+	string(ColorByMode), string(ColorByType), string(ColorByHeading), string(ColorByTime)}
+
+// needsWind tells whether the coloring requires point of sail analysis.
+func (c MapColoring) needsWind() bool {
+	return c == ColorByAttitude || c == ColorByVMG
+}
+
+// mapColors assigns the track points their colors for a coloring scheme.
+type mapColors struct {
+	Coloring MapColoring
+	Legend   []legendEntry
+	color    func(s *track.Segment, p *track.Point) int // 12-bit RGB color code (see palette) of the step ending with the point
+}
+
+// legendEntry is a color in the legend of the map, labeled if the label is not empty.
+type legendEntry struct {
+	Color int
+	Label string
+}
+
+// newMapColors returns the colors of the coloring scheme for the track, nil if the coloring requires
+// point of sail analysis and the track wasn't classified, or if the type coloring finds no segment types.
+func newMapColors(t *track.Track, c MapColoring, opts *MapOptions) *mapColors {
+	if c.needsWind() && t.Wind == track.UNK {
+		return nil
+	}
+	mc := &mapColors{Coloring: c}
+	unit := t.Params.SpeedLabel()
+	switch c {
+	case ColorBySpeed:
+		ss := opts.speedScale(t)
+		for i, color := range ss.colors {
+			mc.add(color, "")
+			if i%5 == 0 {
+				mc.Legend[i].Label = ss.label(i) + unit
+			}
+		}
+		mc.color = func(_ *track.Segment, p *track.Point) int { return ss.color(p.Speed) }
+	case ColorByAttitude:
+		mc.add(attitudeColors[track.Upwind], "upwind").add(attitudeColors[track.Beam], "beam").add(attitudeColors[track.Downwind], "downwind")
+		mc.color = func(s *track.Segment, _ *track.Point) int { return attitudeColors[s.WindAttitude()] }
+	case ColorByVMG:
+		// diverging palette symmetric around 0, steps of one fifth of the maximum speed
+		var maxSpeed float64
+		for _, s := range t.Segments {
+			maxSpeed = max(maxSpeed, s.Speed.Max)
+		}
+		step := max(5, math.Ceil(maxSpeed/5)*5) / 5
+		n := len(vmgPalette) / 2
+		for i, color := range vmgPalette {
+			// the unit is on the middle label, so that the last label fits in its entry
+			label := ""
+			if i == 0 || i == len(vmgPalette)-1 {
+				label = fmt.Sprint(float64(i-n) * step)
+			} else if i == n {
+				label = "0" + unit
+			}
+			mc.add(color, label)
+		}
+		mc.color = func(_ *track.Segment, p *track.Point) int {
+			vmg := t.Wind.VMG(p.Heading, p.Speed)
+			if t.Wind.WindAngle(p.Heading) > 90 {
+				vmg = -vmg // downwind
+			}
+			i := int(math.Round(vmg/step)) + n
+			return vmgPalette[max(0, min(len(vmgPalette)-1, i))]
+		}
+	case ColorByMode:
+		for _, mode := range []track.Mode{track.Moving, track.Turning, track.Static} {
+			mc.add(ModeColors[mode], string(mode))
+		}
+		mc.color = func(s *track.Segment, _ *track.Point) int { return ModeColors[s.Mode] }
+	case ColorByType:
+		if t.Wind == track.UNK {
+			return newActivityTypeColors(t)
+		}
+		for _, pos := range []struct {
+			track.PointOfSail
+			label string
+		}{
+			{track.ClosePT, "close PT"}, {track.BeamPT, "beam PT"}, {track.BroadPT, "broad PT"},
+			{track.CloseSB, "close SB"}, {track.BeamSB, "beam SB"}, {track.BroadSB, "broad SB"},
+			{track.Irons, "irons"}, {track.Run, "run"},
+		} {
+			mc.add(posColors[pos.PointOfSail], pos.label)
+		}
+		for _, kind := range []string{"tack", "gybe", "roundup", "bearaway", "drifting"} {
+			mc.add(turnColors[kind], kind)
+		}
+		mc.color = func(s *track.Segment, _ *track.Point) int {
+			st, ok := s.Type.(*track.SegmentType)
+			switch {
+			case ok && s.Mode == track.Moving:
+				return posColors[st.PointOfSail]
+			case ok && st.Turn.Kind() != "":
+				return turnColors[st.Turn.Kind()]
+			}
+			return turnColors["drifting"]
+		}
+	case ColorByHeading:
+		for i := 0; i < 360; i += 15 {
+			label := ""
+			if i%90 == 0 {
+				label = track.Direction(i).String()
+			}
+			mc.add(hueColor(float64(i)), label)
+		}
+		mc.color = func(_ *track.Segment, p *track.Point) int { return hueColor(math.Round(float64(p.Heading)/15) * 15) }
+	case ColorByTime:
+		tz := t.Timezone()
+		n := len(timePalette)
+		for i, color := range timePalette {
+			label := ""
+			if i%8 == 0 {
+				at := t.Start.Add(t.Duration * time.Duration(i) / time.Duration(n))
+				label = at.In(tz).Format("15:04")
+			}
+			mc.add(color, label)
+		}
+		mc.color = func(_ *track.Segment, p *track.Point) int {
+			if t.Duration <= 0 {
+				return timePalette[0]
+			}
+			i := int(float64(n) * float64(p.GPX.Timestamp.Sub(t.Start)) / float64(t.Duration))
+			return timePalette[max(0, min(n-1, i))]
+		}
+	default:
+		return nil
+	}
+	return mc
+}
+
+// newActivityTypeColors returns the type coloring of the segments classified by an activity not powered by wind,
+// e.g. work and easy when rowing (see Segment.TypeKey), the segments without a type are colored by their mode.
+// Returns nil if no segment has a type.
+func newActivityTypeColors(t *track.Track) *mapColors {
+	var types []string
+	for _, s := range t.Segments {
+		if s.Type != nil && !slices.Contains(types, s.TypeKey()) {
+			types = append(types, s.TypeKey())
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	sort.Strings(types)
+	colors := map[string]int{}
+	mc := &mapColors{Coloring: ColorByType}
+	for i, key := range types {
+		colors[key] = typePalette[i%len(typePalette)]
+		mc.add(colors[key], key)
+	}
+	for _, mode := range []track.Mode{track.Moving, track.Turning, track.Static} {
+		mc.add(ModeColors[mode], string(mode))
+	}
+	mc.color = func(s *track.Segment, _ *track.Point) int {
+		if color, ok := colors[s.TypeKey()]; ok && s.Type != nil {
+			return color
+		}
+		return ModeColors[s.Mode]
+	}
+	return mc
+}
+
+func (mc *mapColors) add(color int, label string) *mapColors {
+	mc.Legend = append(mc.Legend, legendEntry{color, label})
+	return mc
+}
+
+// categorical tells whether all the legend entries are labeled, i.e. the colors are categories rather than a scale.
+func (mc *mapColors) categorical() bool {
+	for _, e := range mc.Legend {
+		if e.Label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// entryWidth returns the width of a legend entry in SVG coordinates,
+// categorical entries are wide enough to fit their labels.
+func (mc *mapColors) entryWidth() int {
+	w := 30
+	if mc.categorical() {
+		for _, e := range mc.Legend {
+			w = max(w, 7*len(e.Label)+10)
+		}
+	}
+	return w
+}
+
+func (mc *mapColors) legendWidth() int {
+	return len(mc.Legend) * mc.entryWidth()
+}
+
+// Color returns the SVG color of the step ending with the point.
+func (mc *mapColors) Color(s *track.Segment, p *track.Point) string {
+	return fmt.Sprintf("#%03x", mc.color(s, p))
+}
+
+// labelColor returns the color of a label that is readable over the 12-bit RGB color.
+func labelColor(c int) string {
+	r, g, b := c>>8&15, c>>4&15, c&15
+	if 299*r+587*g+114*b < 1000*8 {
+		return "white"
+	}
+	return "black"
+}
+
+var (
+	attitudeColors = map[track.WindAttitude]int{track.Upwind: 0xf00, track.Beam: 0x080, track.Downwind: 0x00f}
+	ModeColors     = map[track.Mode]int{track.Moving: 0x06c, track.Turning: 0xe80, track.Static: 0x999}
+	// port tack is red and starboard tack is green like the navigation lights, darker closer to the wind
+	posColors = map[track.PointOfSail]int{
+		track.ClosePT: 0xa00, track.BeamPT: 0xe44, track.BroadPT: 0xf99,
+		track.CloseSB: 0x060, track.BeamSB: 0x3a3, track.BroadSB: 0x9d9,
+		track.Irons: 0x666, track.Run: 0x00c,
+	}
+	// activity specific segment types, distinct from the mode colors
+	typePalette = []int{0xd33, 0x3a3, 0x80c, 0x0cc, 0x630, 0xc6c}
+	turnColors  = map[string]int{"tack": 0x000, "gybe": 0x0cc, "roundup": 0xe80, "bearaway": 0xa0a, "drifting": 0xbbb}
+	// from fast downwind (blue) through slow (gray) to fast upwind (red)
+	vmgPalette = []int{0x00f, 0x33f, 0x66f, 0x99f, 0xbbe, 0xccc, 0xebb, 0xf99, 0xf66, 0xf33, 0xf00}
+	// the time coloring uses viridis regardless of the speed ramp
+	timePalette = newRamp(Ramps["viridis"], 24)
+)
+
+// Ramps are the named color ramps for the speed coloring, the anchor colors are interpolated into as many colors as needed.
+var Ramps = map[string][]int{
+	// blue through green to red, the original fixed speed palette
+	"rainbow": palette,
+	// from dark purple through blue and green to yellow, perceptually uniform
+	"viridis": {0x405, 0x359, 0x299, 0x4c6, 0xce2, 0xfe1},
+	// from dark blue through gray to yellow, perceptually uniform and readable with color vision deficiency
+	"cividis": {0x025, 0x347, 0x667, 0x997, 0xcb6, 0xfe4},
+	// from black through red and orange to light yellow
+	"magma": {0x000, 0x315, 0x719, 0xc37, 0xf96, 0xffb},
+}
+
+var KnownRamps = []string{"rainbow", "viridis", "cividis", "magma"}
+
+// ParseRamp returns the named color ramp, or the ramp given as a comma separated list of at least two colors, e.g. #00f,#ff0,#f00.
+func ParseRamp(s string) ([]int, error) {
+	if ramp, ok := Ramps[s]; ok {
+		return ramp, nil
+	}
+	var ramp []int
+	for _, c := range strings.Split(s, ",") {
+		hex := strings.TrimPrefix(strings.TrimSpace(c), "#")
+		v, err := strconv.ParseUint(hex, 16, 32)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("invalid color %s", c)
+		case len(hex) == 3:
+			ramp = append(ramp, int(v))
+		case len(hex) == 6:
+			// reduce to the 12-bit colors of the palette
+			ramp = append(ramp, int(math.Round(float64(v>>16)/17))<<8|int(math.Round(float64(v>>8&255)/17))<<4|int(math.Round(float64(v&255)/17)))
+		default:
+			return nil, fmt.Errorf("invalid color %s, expected #rgb or #rrggbb", c)
+		}
+	}
+	if len(ramp) < 2 {
+		return nil, fmt.Errorf("%s is not a known ramp or a list of at least two colors", s)
+	}
+	return ramp, nil
+}
+
+// newRamp interpolates the anchor colors into n colors.
+func newRamp(anchors []int, n int) (ramp []int) {
+	if n == len(anchors) {
+		return anchors
+	}
+	for i := 0; i < n; i++ {
+		f := float64(i) / float64(max(n-1, 1)) * float64(len(anchors)-1)
+		j := min(int(f), len(anchors)-2)
+		a, b := anchors[j], anchors[j+1]
+		c := 0
+		for shift := 8; shift >= 0; shift -= 4 {
+			ca, cb := float64(a>>shift&15), float64(b>>shift&15)
+			c = c<<4 | int(math.Round(ca+(cb-ca)*(f-float64(j))))
+		}
+		ramp = append(ramp, c)
+	}
+	return ramp
+}
+
+// SpeedColorRange is the range of speeds covered by the speed coloring.
+// The zero value means that the range is derived from the speeds of the track (see speedScale).
+type SpeedColorRange struct {
+	Min, Max float64
+}
+
+// ParseSpeedColorRange parses the speed range in the form MIN:MAX, or auto for the zero value.
+func ParseSpeedColorRange(s string) (r SpeedColorRange, err error) {
+	if s == "auto" {
+		return r, nil
+	}
+	if _, err := fmt.Sscanf(s, "%g:%g", &r.Min, &r.Max); err != nil || r.Min < 0 || r.Max <= r.Min {
+		return r, fmt.Errorf("invalid speed range %s, expected MIN:MAX, e.g. 4:12, or auto", s)
+	}
+	return r, nil
+}
+
+// speedScale maps speeds to the colors of a ramp in bins of a round size.
+type speedScale struct {
+	min, step float64
+	colors    []int
+}
+
+const maxSpeedBins = 24
+
+// newSpeedScale returns the scale covering the speed range with at most maxSpeedBins colors of the ramp.
+// If the range is zero, it covers the 2nd to 98th percentile of the speeds in the moving segments of the track,
+// so that the colors are spread over the speeds that actually occur.
+func newSpeedScale(t *track.Track, r SpeedColorRange, ramp []int) *speedScale {
+	if r == (SpeedColorRange{}) {
+		r = speedPercentiles(t, 0.02, 0.98)
+	}
+	if r.Max <= r.Min {
+		r.Max = r.Min + 1
+	}
+	ss := &speedScale{step: 10}
+	for _, step := range []float64{0.1, 0.2, 0.25, 0.5, 1, 2, 2.5, 5, 10} {
+		if (r.Max-math.Floor(r.Min/step)*step)/step <= maxSpeedBins+1e-9 {
+			ss.step = step
+			break
+		}
+	}
+	ss.min = math.Floor(r.Min/ss.step) * ss.step
+	n := max(2, int(math.Ceil((r.Max-ss.min)/ss.step-1e-9)))
+	ss.colors = newRamp(ramp, n)
+	return ss
+}
+
+// speedScale returns the scale of the speed coloring of the track with the options (SpeedRange and Ramp),
+// the default scale if opts is nil. The map, the ASS subtitles and the overlay share it, so that their colors match.
+func (opts *MapOptions) speedScale(t *track.Track) *speedScale {
+	var r SpeedColorRange
+	ramp := palette
+	if opts != nil {
+		r = opts.SpeedRange
+		if opts.Ramp != nil {
+			ramp = opts.Ramp
+		}
+	}
+	return newSpeedScale(t, r, ramp)
+}
+
+// speedPercentiles returns the speeds at the percentiles of the moving segments, or all segments if none are moving.
+func speedPercentiles(t *track.Track, low, high float64) SpeedColorRange {
+	var speeds []float64
+	for _, moving := range []bool{true, false} {
+		for _, s := range t.Segments {
+			if moving && s.Mode != track.Moving {
+				continue
+			}
+			for _, p := range s.Points {
+				if !math.IsNaN(p.Speed) && !math.IsInf(p.Speed, 0) {
+					speeds = append(speeds, p.Speed)
+				}
+			}
+		}
+		if len(speeds) > 0 {
+			break
+		}
+	}
+	if len(speeds) == 0 {
+		return SpeedColorRange{0, 1}
+	}
+	sort.Float64s(speeds)
+	at := func(q float64) float64 { return speeds[int(q*float64(len(speeds)-1))] }
+	return SpeedColorRange{at(low), at(high)}
+}
+
+func (ss *speedScale) color(speed float64) int {
+	i := int(math.Floor((speed - ss.min) / ss.step))
+	return ss.colors[max(0, min(len(ss.colors)-1, i))]
+}
+
+// label returns the lower bound of the i-th bin.
+func (ss *speedScale) label(i int) string {
+	return strconv.FormatFloat(math.Round((ss.min+float64(i)*ss.step)*100)/100, 'f', -1, 64)
+}
+
+// hueColor returns the fully saturated 12-bit RGB color of the hue in degrees.
+func hueColor(hue float64) int {
+	h := math.Mod(hue+360, 360) / 60
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	c := func(v float64) int { return int(math.Round(v * 14)) }
+	return c(r)<<8 | c(g)<<4 | c(b)
+}
+
+// eachStep calls f for each step of the track in the order they are rendered in the map,
+// i.e. with the segment and the end point of the step joining it to the previous segment and of the steps within it.
+func eachStep(t *track.Track, f func(s *track.Segment, next *track.Point)) {
+	for i, s := range t.Segments {
+		for j, p := range s.Points {
+			if i > 0 || j > 0 {
+				f(s, p)
+			}
+		}
+	}
+}
+
+// coloringData returns the available colorings of the track as a JavaScript object literal,
+// used by map.js to recolor the track and redraw the legend when the coloring is changed.
+// The colors of the steps are indexes into the palette of the coloring.
+func (m *Map) coloringData(t *track.Track) string {
+	type coloring struct {
+		Name    MapColoring `json:"name"`
+		Width   int         `json:"width"`
+		Legend  [][3]string `json:"legend"` // color, label, label color
+		Palette []string    `json:"palette"`
+		Steps   []int       `json:"steps"`
+	}
+	var data []*coloring
+	for _, mc := range m.colorings {
+		c := &coloring{Name: mc.Coloring, Width: mc.entryWidth()}
+		for _, e := range mc.Legend {
+			c.Legend = append(c.Legend, [3]string{fmt.Sprintf("#%03x", e.Color), e.Label, labelColor(e.Color)})
+		}
+		index := map[int]int{}
+		eachStep(t, func(s *track.Segment, p *track.Point) {
+			color := mc.color(s, p)
+			i, ok := index[color]
+			if !ok {
+				i = len(c.Palette)
+				index[color] = i
+				c.Palette = append(c.Palette, fmt.Sprintf("#%03x", color))
+			}
+			c.Steps = append(c.Steps, i)
+		})
+		data = append(data, c)
+	}
+	b, _ := json.Marshal(data)
+	return string(b)
+}
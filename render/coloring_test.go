@@ -0,0 +1,134 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+)
+
+func Test_MapColorings(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	// colorings based on point of sail are not available without wind direction
+	m, _ := newMap(trk, &MapOptions{Coloring: ColorByVMG})
+	testutil.AssertEqual(t, len(m.colorings), 4)
+	testutil.AssertEqual(t, m.colors.Coloring, ColorBySpeed)
+
+	trk.Classify(track.N)
+	m, _ = newMap(trk, &MapOptions{Coloring: ColorByType})
+	testutil.AssertEqual(t, len(m.colorings), len(KnownMapColorings))
+	testutil.AssertEqual(t, m.colors.Coloring, ColorByType)
+	testutil.AssertEqual(t, m.colors.categorical(), true)
+
+	var steps int
+	eachStep(trk, func(*track.Segment, *track.Point) { steps++ })
+	var points int
+	for _, s := range trk.Segments {
+		points += len(s.Points)
+	}
+	testutil.AssertEqual(t, steps, points-1)
+
+	var data []struct {
+		Name    string
+		Palette []string
+		Steps   []int
+	}
+	if err := json.Unmarshal([]byte(m.coloringData(trk)), &data); err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(data), len(KnownMapColorings))
+	for _, c := range data {
+		testutil.AssertEqual(t, len(c.Steps), steps)
+	}
+
+	var b bytes.Buffer
+	m.render(&b, trk)
+	svg := b.String()
+	testutil.AssertEqual(t, strings.Contains(svg, `<option value="type" selected="selected">`), true)
+	testutil.AssertEqual(t, strings.Count(svg, `<line class="step"`), steps)
+	testutil.AssertEqual(t, strings.Contains(svg, ">close PT</text>"), true)
+}
+
+func Test_ActivityTypeColoring(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn3)
+	trk.Analyze(track.Motorboating, nil)
+	// the type coloring is available for the segment types of an activity not powered by wind
+	m, _ := newMap(trk, &MapOptions{Coloring: ColorByType})
+	testutil.AssertEqual(t, len(m.colorings), 5)
+	testutil.AssertEqual(t, m.colors.Coloring, ColorByType)
+	testutil.AssertEqual(t, m.colors.Legend[0].Label, "displacement")
+	for _, s := range trk.Segments {
+		exp := ModeColors[s.Mode]
+		if s.Type != nil {
+			exp = typePalette[0]
+		}
+		testutil.AssertEqual(t, m.colors.color(s, s.Points[0]), exp)
+	}
+	// and not without them
+	trk.Analyze(track.Sailing, nil)
+	m, _ = newMap(trk, &MapOptions{Coloring: ColorByType})
+	testutil.AssertEqual(t, m.colors.Coloring, ColorBySpeed)
+}
+
+func Test_HueColor(t *testing.T) {
+	testutil.AssertEqual(t, hueColor(0), 0xe00)
+	testutil.AssertEqual(t, hueColor(120), 0x0e0)
+	testutil.AssertEqual(t, hueColor(240), 0x00e)
+	testutil.AssertEqual(t, hueColor(360), 0xe00)
+	testutil.AssertEqual(t, hueColor(60), 0xee0)
+}
+
+func Test_SpeedScale(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	// the fixed range of whole speed units matches the original palette
+	ss := newSpeedScale(trk, SpeedColorRange{0, 24}, palette)
+	testutil.AssertEqual(t, ss.step, 1.0)
+	testutil.AssertEqual(t, len(ss.colors), len(palette))
+	for _, speed := range []float64{0, 3.5, 12, 30} {
+		testutil.AssertEqual(t, ss.color(speed), palette[min(int(speed), len(palette)-1)])
+	}
+
+	ss = newSpeedScale(trk, SpeedColorRange{4, 7}, palette)
+	testutil.AssertEqual(t, ss.step, 0.2)
+	testutil.AssertEqual(t, len(ss.colors), 15)
+	testutil.AssertEqual(t, ss.label(5), "5")
+	testutil.AssertEqual(t, ss.label(6), "5.2")
+	testutil.AssertEqual(t, ss.color(3), palette[0])
+	testutil.AssertEqual(t, ss.color(10), palette[len(palette)-1])
+
+	// auto range covers the speeds of the track
+	r := speedPercentiles(trk, 0.02, 0.98)
+	ss = newSpeedScale(trk, SpeedColorRange{}, palette)
+	testutil.AssertEqual(t, ss.min <= r.Min, true)
+	testutil.AssertEqual(t, ss.min+float64(len(ss.colors))*ss.step >= r.Max, true)
+	testutil.AssertEqual(t, len(ss.colors) <= maxSpeedBins, true)
+}
+
+func Test_ParseRamp(t *testing.T) {
+	ramp, err := ParseRamp("cividis")
+	testutil.AssertEqual(t, err, nil)
+	testutil.AssertEqual(t, ramp[0], 0x025)
+	ramp, err = ParseRamp("#00f, #ffff00,#f00")
+	testutil.AssertEqual(t, err, nil)
+	testutil.AssertEqual(t, fmt.Sprint(ramp), fmt.Sprint([]int{0x00f, 0xff0, 0xf00}))
+	testutil.AssertEqual(t, fmt.Sprint(newRamp(ramp, 5)), fmt.Sprint([]int{0x00f, 0x888, 0xff0, 0xf80, 0xf00}))
+	_, err = ParseRamp("#00f")
+	testutil.AssertEqual(t, err != nil, true)
+	_, err = ParseRamp("blue,red")
+	testutil.AssertEqual(t, err != nil, true)
+
+	r, err := ParseSpeedColorRange("4.5:12")
+	testutil.AssertEqual(t, err, nil)
+	testutil.AssertEqual(t, r, SpeedColorRange{4.5, 12})
+	r, _ = ParseSpeedColorRange("auto")
+	testutil.AssertEqual(t, r, SpeedColorRange{})
+	_, err = ParseSpeedColorRange("12:4")
+	testutil.AssertEqual(t, err != nil, true)
+}
@@ -0,0 +1,118 @@
+package render
+
+import "strings"
+
+// font5x7 is a 5x7 pixel bitmap font for the printable ASCII characters and the degree sign,
+// used to draw text into raster images. Each glyph is 7 rows of 5 pixels separated by |.
+var font5x7 = map[rune]string{
+	' ':  ".....|.....|.....|.....|.....|.....|.....",
+	'!':  "..#..|..#..|..#..|..#..|..#..|.....|..#..",
+	'"':  ".#.#.|.#.#.|.#.#.|.....|.....|.....|.....",
+	'#':  ".#.#.|.#.#.|#####|.#.#.|#####|.#.#.|.#.#.",
+	'$':  "..#..|.####|#.#..|.###.|..#.#|####.|..#..",
+	'%':  "##...|##..#|...#.|..#..|.#...|#..##|...##",
+	'&':  ".##..|#..#.|#.#..|.#...|#.#.#|#..#.|.##.#",
+	'\'': "..#..|..#..|..#..|.....|.....|.....|.....",
+	'(':  "...#.|..#..|.#...|.#...|.#...|..#..|...#.",
+	')':  ".#...|..#..|...#.|...#.|...#.|..#..|.#...",
+	'*':  ".....|..#..|#.#.#|.###.|#.#.#|..#..|.....",
+	'+':  ".....|..#..|..#..|#####|..#..|..#..|.....",
+	',':  ".....|.....|.....|.....|.##..|..#..|.#...",
+	'-':  ".....|.....|.....|#####|.....|.....|.....",
+	'.':  ".....|.....|.....|.....|.....|.##..|.##..",
+	'/':  ".....|....#|...#.|..#..|.#...|#....|.....",
+	'0':  ".###.|#...#|#..##|#.#.#|##..#|#...#|.###.",
+	'1':  "..#..|.##..|..#..|..#..|..#..|..#..|.###.",
+	'2':  ".###.|#...#|....#|...#.|..#..|.#...|#####",
+	'3':  "#####|...#.|..#..|...#.|....#|#...#|.###.",
+	'4':  "...#.|..##.|.#.#.|#..#.|#####|...#.|...#.",
+	'5':  "#####|#....|####.|....#|....#|#...#|.###.",
+	'6':  "..##.|.#...|#....|####.|#...#|#...#|.###.",
+	'7':  "#####|....#|...#.|..#..|.#...|.#...|.#...",
+	'8':  ".###.|#...#|#...#|.###.|#...#|#...#|.###.",
+	'9':  ".###.|#...#|#...#|.####|....#|...#.|.##..",
+	':':  ".....|.##..|.##..|.....|.##..|.##..|.....",
+	';':  ".....|.##..|.##..|.....|.##..|..#..|.#...",
+	'<':  "...#.|..#..|.#...|#....|.#...|..#..|...#.",
+	'=':  ".....|.....|#####|.....|#####|.....|.....",
+	'>':  ".#...|..#..|...#.|....#|...#.|..#..|.#...",
+	'?':  ".###.|#...#|....#|...#.|..#..|.....|..#..",
+	'@':  ".###.|#...#|....#|.##.#|#.#.#|#.#.#|.###.",
+	'A':  ".###.|#...#|#...#|#####|#...#|#...#|#...#",
+	'B':  "####.|#...#|#...#|####.|#...#|#...#|####.",
+	'C':  ".###.|#...#|#....|#....|#....|#...#|.###.",
+	'D':  "###..|#..#.|#...#|#...#|#...#|#..#.|###..",
+	'E':  "#####|#....|#....|####.|#....|#....|#####",
+	'F':  "#####|#....|#....|####.|#....|#....|#....",
+	'G':  ".###.|#...#|#....|#.###|#...#|#...#|.####",
+	'H':  "#...#|#...#|#...#|#####|#...#|#...#|#...#",
+	'I':  ".###.|..#..|..#..|..#..|..#..|..#..|.###.",
+	'J':  "..###|...#.|...#.|...#.|...#.|#..#.|.##..",
+	'K':  "#...#|#..#.|#.#..|##...|#.#..|#..#.|#...#",
+	'L':  "#....|#....|#....|#....|#....|#....|#####",
+	'M':  "#...#|##.##|#.#.#|#.#.#|#...#|#...#|#...#",
+	'N':  "#...#|#...#|##..#|#.#.#|#..##|#...#|#...#",
+	'O':  ".###.|#...#|#...#|#...#|#...#|#...#|.###.",
+	'P':  "####.|#...#|#...#|####.|#....|#....|#....",
+	'Q':  ".###.|#...#|#...#|#...#|#.#.#|#..#.|.##.#",
+	'R':  "####.|#...#|#...#|####.|#.#..|#..#.|#...#",
+	'S':  ".####|#....|#....|.###.|....#|....#|####.",
+	'T':  "#####|..#..|..#..|..#..|..#..|..#..|..#..",
+	'U':  "#...#|#...#|#...#|#...#|#...#|#...#|.###.",
+	'V':  "#...#|#...#|#...#|#...#|#...#|.#.#.|..#..",
+	'W':  "#...#|#...#|#...#|#.#.#|#.#.#|#.#.#|.#.#.",
+	'X':  "#...#|#...#|.#.#.|..#..|.#.#.|#...#|#...#",
+	'Y':  "#...#|#...#|#...#|.#.#.|..#..|..#..|..#..",
+	'Z':  "#####|....#|...#.|..#..|.#...|#....|#####",
+	'[':  ".###.|.#...|.#...|.#...|.#...|.#...|.###.",
+	'\\': ".....|#....|.#...|..#..|...#.|....#|.....",
+	']':  ".###.|...#.|...#.|...#.|...#.|...#.|.###.",
+	'^':  "..#..|.#.#.|#...#|.....|.....|.....|.....",
+	'_':  ".....|.....|.....|.....|.....|.....|#####",
+	'`':  ".#...|..#..|...#.|.....|.....|.....|.....",
+	'a':  ".....|.....|.###.|....#|.####|#...#|.####",
+	'b':  "#....|#....|#.##.|##..#|#...#|#...#|####.",
+	'c':  ".....|.....|.###.|#....|#....|#...#|.###.",
+	'd':  "....#|....#|.##.#|#..##|#...#|#...#|.####",
+	'e':  ".....|.....|.###.|#...#|#####|#....|.###.",
+	'f':  "..##.|.#..#|.#...|###..|.#...|.#...|.#...",
+	'g':  ".....|.####|#...#|#...#|.####|....#|.###.",
+	'h':  "#....|#....|#.##.|##..#|#...#|#...#|#...#",
+	'i':  "..#..|.....|.##..|..#..|..#..|..#..|.###.",
+	'j':  "...#.|.....|..##.|...#.|...#.|#..#.|.##..",
+	'k':  "#....|#....|#..#.|#.#..|##...|#.#..|#..#.",
+	'l':  ".##..|..#..|..#..|..#..|..#..|..#..|.###.",
+	'm':  ".....|.....|##.#.|#.#.#|#.#.#|#...#|#...#",
+	'n':  ".....|.....|#.##.|##..#|#...#|#...#|#...#",
+	'o':  ".....|.....|.###.|#...#|#...#|#...#|.###.",
+	'p':  ".....|.....|####.|#...#|####.|#....|#....",
+	'q':  ".....|.....|.##.#|#..##|.####|....#|....#",
+	'r':  ".....|.....|#.##.|##..#|#....|#....|#....",
+	's':  ".....|.....|.###.|#....|.###.|....#|####.",
+	't':  ".#...|.#...|###..|.#...|.#...|.#..#|..##.",
+	'u':  ".....|.....|#...#|#...#|#...#|#..##|.##.#",
+	'v':  ".....|.....|#...#|#...#|#...#|.#.#.|..#..",
+	'w':  ".....|.....|#...#|#...#|#.#.#|#.#.#|.#.#.",
+	'x':  ".....|.....|#...#|.#.#.|..#..|.#.#.|#...#",
+	'y':  ".....|.....|#...#|#...#|.####|....#|.###.",
+	'z':  ".....|.....|#####|...#.|..#..|.#...|#####",
+	'{':  "...#.|..#..|..#..|.#...|..#..|..#..|...#.",
+	'|':  "..#..|..#..|..#..|..#..|..#..|..#..|..#..",
+	'}':  ".#...|..#..|..#..|...#.|..#..|..#..|.#...",
+	'~':  ".....|.....|.#...|#.#.#|...#.|.....|.....",
+	'°':  ".##..|#..#.|#..#.|.##..|.....|.....|.....",
+}
+
+const (
+	fontWidth  = 5
+	fontHeight = 7
+)
+
+// glyph returns the rows of the character bitmap, unknown characters are drawn as ?.
+func glyph(r rune) []string {
+	g, ok := font5x7[r]
+	if !ok {
+		g = font5x7['?']
+	}
+	return strings.Split(g, "|")
+}
@@ -0,0 +1,789 @@
+// Generated by ego.
+// DO NOT EDIT
+
+//line map.ego:1
+
+package render
+
+import "fmt"
+import "html"
+import "io"
+import "context"
+
+import "time"
+import "strconv"
+import "github.com/mkobetic/gpx/track"
+
+func (m *Map) render(w io.Writer, t *track.Track) {
+
+//line map.ego:10
+	_, _ = io.WriteString(w, "\n<svg version=\"1.1\" xmlns=\"http://www.w3.org/2000/svg\"\n    width=\"100%\" height=\"100%\" id=\"root\">\n    <style type=\"text/css\" >\n        <![CDATA[\n")
+//line map.ego:14
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(css)))
+//line map.ego:15
+	_, _ = io.WriteString(w, "\n        ]]>\n    </style>\n    ")
+//line map.ego:17
+	colors := m.colors
+	ew := colors.entryWidth()
+
+//line map.ego:20
+	_, _ = io.WriteString(w, "\n    <g id=\"legend\">\n        ")
+//line map.ego:21
+	for i, e := range colors.Legend {
+//line map.ego:22
+		_, _ = io.WriteString(w, "\n        <rect x=\"")
+//line map.ego:22
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(ew*i)))
+//line map.ego:22
+		_, _ = io.WriteString(w, "\" y=\"0\" width=\"")
+//line map.ego:22
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(ew)))
+//line map.ego:22
+		_, _ = io.WriteString(w, "\" height=\"20\" fill=\"")
+//line map.ego:22
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("#%03x", e.Color))))
+//line map.ego:22
+		_, _ = io.WriteString(w, "\"/>\n        ")
+//line map.ego:23
+	}
+//line map.ego:24
+	_, _ = io.WriteString(w, "\n        ")
+//line map.ego:24
+	for i, e := range colors.Legend {
+		if e.Label == "" {
+			continue
+		}
+
+//line map.ego:27
+		_, _ = io.WriteString(w, "\n        <text x=\"")
+//line map.ego:27
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(ew*i+5)))
+//line map.ego:27
+		_, _ = io.WriteString(w, "\" y=\"16\" fill=\"")
+//line map.ego:27
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(labelColor(e.Color))))
+//line map.ego:27
+		_, _ = io.WriteString(w, "\">")
+//line map.ego:27
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(e.Label)))
+//line map.ego:27
+		_, _ = io.WriteString(w, "</text>\n        ")
+//line map.ego:28
+	}
+//line map.ego:29
+	_, _ = io.WriteString(w, "\n    </g>\n    <foreignObject id=\"controls\" x=\"")
+//line map.ego:30
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(colors.legendWidth()+10)))
+//line map.ego:30
+	_, _ = io.WriteString(w, "\" y=\"0\" width=\"1200\" height=\"22\">\n        <div xmlns=\"http://www.w3.org/1999/xhtml\" class=\"controls\">\n            <button id=\"playback-play\" title=\"play/pause the track playback\">&#9654;</button>\n            <select id=\"playback-speed\" title=\"playback speed\">\n            ")
+//line map.ego:34
+	for _, speed := range playbackSpeeds {
+//line map.ego:35
+		_, _ = io.WriteString(w, "\n                <option value=\"")
+//line map.ego:35
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(speed)))
+//line map.ego:35
+		_, _ = io.WriteString(w, "\"")
+//line map.ego:35
+		if speed == 10 {
+//line map.ego:35
+			_, _ = io.WriteString(w, " selected=\"selected\"")
+//line map.ego:35
+		}
+//line map.ego:35
+		_, _ = io.WriteString(w, ">")
+//line map.ego:35
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(speed)))
+//line map.ego:35
+		_, _ = io.WriteString(w, "x</option>\n            ")
+//line map.ego:36
+	}
+//line map.ego:37
+	_, _ = io.WriteString(w, "\n            </select>\n            <select id=\"coloring\" title=\"track coloring\">\n            ")
+//line map.ego:39
+	for _, mc := range m.colorings {
+//line map.ego:40
+		_, _ = io.WriteString(w, "\n                <option value=\"")
+//line map.ego:40
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(mc.Coloring)))
+//line map.ego:40
+		_, _ = io.WriteString(w, "\"")
+//line map.ego:40
+		if mc == colors {
+//line map.ego:40
+			_, _ = io.WriteString(w, " selected=\"selected\"")
+//line map.ego:40
+		}
+//line map.ego:40
+		_, _ = io.WriteString(w, ">")
+//line map.ego:40
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(mc.Coloring)))
+//line map.ego:40
+		_, _ = io.WriteString(w, "</option>\n            ")
+//line map.ego:41
+	}
+//line map.ego:42
+	_, _ = io.WriteString(w, "\n            </select>\n            ")
+//line map.ego:43
+	if len(m.tiles) > 0 {
+//line map.ego:44
+		_, _ = io.WriteString(w, "\n            <label><input type=\"checkbox\" data-layer=\"tiles\" checked=\"checked\"/>map</label>\n            ")
+//line map.ego:45
+	}
+//line map.ego:46
+	_, _ = io.WriteString(w, "\n            <label><input type=\"checkbox\" data-layer=\"timeline-heading\"/>heading</label>\n            ")
+//line map.ego:47
+	if t.Wind != track.UNK {
+//line map.ego:48
+		_, _ = io.WriteString(w, "\n            <label><input type=\"checkbox\" data-layer=\"timeline-vmg\"/>vmg</label>\n            ")
+//line map.ego:49
+	}
+//line map.ego:50
+	_, _ = io.WriteString(w, "\n            <button id=\"timeline-reset\" title=\"show the whole track after a timeline selection\">reset</button>\n            ")
+//line map.ego:51
+	if len(m.command) > 0 {
+//line map.ego:52
+		_, _ = io.WriteString(w, "\n            <button id=\"crop\" title=\"show the command cropping the track to the timeline selection\" disabled=\"disabled\">crop</button>\n            ")
+//line map.ego:53
+	}
+//line map.ego:54
+	_, _ = io.WriteString(w, "\n            <label><input type=\"checkbox\" data-layer=\"graticule\" checked=\"checked\"/>grid</label>\n            <label><input type=\"checkbox\" data-layer=\"arrows\" checked=\"checked\"/>arrows</label>\n            <label><input type=\"checkbox\" data-layer=\"maneuvers\" checked=\"checked\"/>maneuvers</label>\n            <label><input type=\"checkbox\" data-layer=\"start-end\" checked=\"checked\"/>start/end</label>\n            <label title=\"click two points of the track to measure the distance, bearing and time between them\"><input type=\"checkbox\" id=\"measure\"/>measure</label>\n            <span id=\"playback-stats\"></span>\n        </div>\n    </foreignObject>\n    <svg id=\"map\" x=\"0\" y=\"21\" width=\"100%\" viewBox=\"0 0 ")
+//line map.ego:62
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.w)))
+//line map.ego:62
+	_, _ = io.WriteString(w, " ")
+//line map.ego:62
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.h)))
+//line map.ego:62
+	_, _ = io.WriteString(w, "\">\n        <!-- Invisible rectangle covering the whole viewport is needed so that mouse events are captured\n            by the #map element whenever the mouse pointer is anywhere in the viewport -->\n        <rect id=\"background\" width=\"")
+//line map.ego:65
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.w)))
+//line map.ego:65
+	_, _ = io.WriteString(w, "\" height=\"")
+//line map.ego:65
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.h)))
+//line map.ego:65
+	_, _ = io.WriteString(w, "\" fill=\"transparent\"/>\n        ")
+//line map.ego:66
+	if len(m.tiles) > 0 {
+//line map.ego:67
+		_, _ = io.WriteString(w, "\n        <g id=\"tiles\" class=\"layer\">\n            ")
+//line map.ego:68
+		for _, tile := range m.tiles {
+//line map.ego:69
+			_, _ = io.WriteString(w, "\n            <image x=\"")
+//line map.ego:69
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tile.X)))
+//line map.ego:69
+			_, _ = io.WriteString(w, "\" y=\"")
+//line map.ego:69
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tile.Y)))
+//line map.ego:69
+			_, _ = io.WriteString(w, "\" width=\"")
+//line map.ego:69
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tile.Size)))
+//line map.ego:69
+			_, _ = io.WriteString(w, "\" height=\"")
+//line map.ego:69
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tile.Size)))
+//line map.ego:69
+			_, _ = io.WriteString(w, "\" preserveAspectRatio=\"none\" href=\"")
+//line map.ego:69
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tile.Href)))
+//line map.ego:69
+			_, _ = io.WriteString(w, "\"/>\n            ")
+//line map.ego:70
+		}
+//line map.ego:71
+		_, _ = io.WriteString(w, "\n        </g>\n        ")
+//line map.ego:72
+	}
+//line map.ego:73
+	_, _ = io.WriteString(w, "\n        <g id=\"graticule\" class=\"layer\"></g>\n    ")
+//line map.ego:74
+	totalDistance := float64(0)
+	var lastPoint *track.Point
+	for i, segment := range t.Segments {
+
+//line map.ego:78
+		_, _ = io.WriteString(w, "\n        <g class=\"segment\" id=\"s")
+//line map.ego:78
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(strconv.Itoa(i))))
+//line map.ego:78
+		_, _ = io.WriteString(w, "\">\n            ")
+//line map.ego:79
+		if lastPoint != nil {
+			prev, next := lastPoint, segment.Points[0]
+			x1, y1 := m.Point(prev.GPX)
+			x2, y2 := m.Point(next.GPX)
+			c := colors.Color(segment, next)
+			totalDistance += next.Distance
+			timestamp := next.GPX.Timestamp.In(t.Timezone()).Format(time.TimeOnly)
+
+//line map.ego:87
+			_, _ = io.WriteString(w, "\n            <line class=\"step\" x1=\"")
+//line map.ego:87
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(x1)))
+//line map.ego:87
+			_, _ = io.WriteString(w, "\" y1=\"")
+//line map.ego:87
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(y1)))
+//line map.ego:87
+			_, _ = io.WriteString(w, "\" x2=\"")
+//line map.ego:87
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(x2)))
+//line map.ego:87
+			_, _ = io.WriteString(w, "\" y2=\"")
+//line map.ego:87
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(y2)))
+//line map.ego:87
+			_, _ = io.WriteString(w, "\" stroke=\"")
+//line map.ego:87
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(c)))
+//line map.ego:87
+			_, _ = io.WriteString(w, "\">\n            <title>")
+//line map.ego:88
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(timestamp)))
+//line map.ego:88
+			_, _ = io.WriteString(w, " ")
+//line map.ego:88
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(next.ShortString())))
+//line map.ego:88
+			_, _ = io.WriteString(w, " = ")
+//line map.ego:88
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%0.2f nm", totalDistance))))
+//line map.ego:89
+			_, _ = io.WriteString(w, "\n")
+//line map.ego:89
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.String())))
+//line map.ego:90
+			_, _ = io.WriteString(w, "\n")
+//line map.ego:90
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.TypeString())))
+//line map.ego:90
+			_, _ = io.WriteString(w, "</title>\n            </line>\n            ")
+//line map.ego:92
+		}
+//line map.ego:93
+		_, _ = io.WriteString(w, "\n            ")
+//line map.ego:93
+		segment.EachPair(func(prev, next *track.Point) {
+			lastPoint = next
+			x1, y1 := m.Point(prev.GPX)
+			x2, y2 := m.Point(next.GPX)
+			c := colors.Color(segment, next)
+			totalDistance += next.Distance
+			timestamp := next.GPX.Timestamp.In(t.Timezone()).Format(time.TimeOnly)
+
+//line map.ego:101
+			_, _ = io.WriteString(w, "\n            <line class=\"step\" x1=\"")
+//line map.ego:101
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(x1)))
+//line map.ego:101
+			_, _ = io.WriteString(w, "\" y1=\"")
+//line map.ego:101
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(y1)))
+//line map.ego:101
+			_, _ = io.WriteString(w, "\" x2=\"")
+//line map.ego:101
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(x2)))
+//line map.ego:101
+			_, _ = io.WriteString(w, "\" y2=\"")
+//line map.ego:101
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(y2)))
+//line map.ego:101
+			_, _ = io.WriteString(w, "\" stroke=\"")
+//line map.ego:101
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(c)))
+//line map.ego:101
+			_, _ = io.WriteString(w, "\">\n            <title>")
+//line map.ego:102
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(timestamp)))
+//line map.ego:102
+			_, _ = io.WriteString(w, ": ")
+//line map.ego:102
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(next.ShortString())))
+//line map.ego:102
+			_, _ = io.WriteString(w, " = ")
+//line map.ego:102
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%0.2f nm", totalDistance/1852))))
+//line map.ego:103
+			_, _ = io.WriteString(w, "\n")
+//line map.ego:103
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.ShortString())))
+//line map.ego:104
+			_, _ = io.WriteString(w, "\n")
+//line map.ego:104
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.TypeString())))
+//line map.ego:104
+			_, _ = io.WriteString(w, "</title>\n            </line>\n            ")
+//line map.ego:106
+		})
+//line map.ego:107
+		_, _ = io.WriteString(w, "\n        </g>\n\t")
+//line map.ego:108
+	}
+//line map.ego:109
+	_, _ = io.WriteString(w, "\n    ")
+//line map.ego:109
+	size := m.markerSize()
+	tz := t.Timezone()
+
+//line map.ego:112
+	_, _ = io.WriteString(w, "\n        <defs>\n            <polygon id=\"arrow\" points=\"0,")
+//line map.ego:113
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size)))
+//line map.ego:113
+	_, _ = io.WriteString(w, " ")
+//line map.ego:113
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*0.6)))
+//line map.ego:113
+	_, _ = io.WriteString(w, ",")
+//line map.ego:113
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*0.6)))
+//line map.ego:113
+	_, _ = io.WriteString(w, " 0,")
+//line map.ego:113
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*0.2)))
+//line map.ego:113
+	_, _ = io.WriteString(w, " ")
+//line map.ego:113
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size*0.6)))
+//line map.ego:113
+	_, _ = io.WriteString(w, ",")
+//line map.ego:113
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*0.6)))
+//line map.ego:113
+	_, _ = io.WriteString(w, "\"/>\n            <circle id=\"maneuver-tack\" r=\"")
+//line map.ego:114
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size)))
+//line map.ego:114
+	_, _ = io.WriteString(w, "\"/>\n            <rect id=\"maneuver-gybe\" x=\"")
+//line map.ego:115
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size)))
+//line map.ego:115
+	_, _ = io.WriteString(w, "\" y=\"")
+//line map.ego:115
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size)))
+//line map.ego:115
+	_, _ = io.WriteString(w, "\" width=\"")
+//line map.ego:115
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(2*size)))
+//line map.ego:115
+	_, _ = io.WriteString(w, "\" height=\"")
+//line map.ego:115
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(2*size)))
+//line map.ego:115
+	_, _ = io.WriteString(w, "\"/>\n            <polygon id=\"maneuver-roundup\" points=\"0,")
+//line map.ego:116
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size*1.2)))
+//line map.ego:116
+	_, _ = io.WriteString(w, " ")
+//line map.ego:116
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size)))
+//line map.ego:116
+	_, _ = io.WriteString(w, ",")
+//line map.ego:116
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*0.8)))
+//line map.ego:116
+	_, _ = io.WriteString(w, " ")
+//line map.ego:116
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size)))
+//line map.ego:116
+	_, _ = io.WriteString(w, ",")
+//line map.ego:116
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*0.8)))
+//line map.ego:116
+	_, _ = io.WriteString(w, "\"/>\n            <polygon id=\"maneuver-bearaway\" points=\"0,")
+//line map.ego:117
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*1.2)))
+//line map.ego:117
+	_, _ = io.WriteString(w, " ")
+//line map.ego:117
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size)))
+//line map.ego:117
+	_, _ = io.WriteString(w, ",")
+//line map.ego:117
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size*0.8)))
+//line map.ego:117
+	_, _ = io.WriteString(w, " ")
+//line map.ego:117
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size)))
+//line map.ego:117
+	_, _ = io.WriteString(w, ",")
+//line map.ego:117
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size*0.8)))
+//line map.ego:117
+	_, _ = io.WriteString(w, "\"/>\n            <polygon id=\"maneuver-turn\" points=\"0,")
+//line map.ego:118
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size*1.2)))
+//line map.ego:118
+	_, _ = io.WriteString(w, " ")
+//line map.ego:118
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*1.2)))
+//line map.ego:118
+	_, _ = io.WriteString(w, ",0 0,")
+//line map.ego:118
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size*1.2)))
+//line map.ego:118
+	_, _ = io.WriteString(w, " ")
+//line map.ego:118
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-size*1.2)))
+//line map.ego:118
+	_, _ = io.WriteString(w, ",0\"/>\n        </defs>\n        <g id=\"arrows\" class=\"layer\">\n        ")
+//line map.ego:121
+	for i, segment := range t.Segments {
+		if segment.Mode != track.Moving {
+			continue
+		}
+		for _, a := range m.arrows(segment, 8*size) {
+
+//line map.ego:125
+			_, _ = io.WriteString(w, "\n            <use class=\"arrow\" href=\"#arrow\" transform=\"")
+//line map.ego:125
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(a.Transform())))
+//line map.ego:125
+			_, _ = io.WriteString(w, "\" data-segment=\"s")
+//line map.ego:125
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(strconv.Itoa(i))))
+//line map.ego:125
+			_, _ = io.WriteString(w, "\"/>\n        ")
+//line map.ego:126
+		}
+	}
+//line map.ego:127
+	_, _ = io.WriteString(w, "\n        </g>\n        <g id=\"maneuvers\" class=\"layer\">\n        ")
+//line map.ego:129
+	for i, segment := range t.Segments {
+		kind := segment.ManeuverKind()
+		if kind == "" {
+			continue
+		}
+		mm := m.marker(segment.Points[len(segment.Points)/2])
+		mm.Heading = 0
+
+//line map.ego:135
+		_, _ = io.WriteString(w, "\n            <use class=\"maneuver maneuver-")
+//line map.ego:135
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(kind)))
+//line map.ego:135
+		_, _ = io.WriteString(w, "\" href=\"#maneuver-")
+//line map.ego:135
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(kind)))
+//line map.ego:135
+		_, _ = io.WriteString(w, "\" transform=\"")
+//line map.ego:135
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(mm.Transform())))
+//line map.ego:135
+		_, _ = io.WriteString(w, "\" data-segment=\"s")
+//line map.ego:135
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(strconv.Itoa(i))))
+//line map.ego:135
+		_, _ = io.WriteString(w, "\">\n            <title>")
+//line map.ego:136
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.Start.In(tz).Format(time.TimeOnly))))
+//line map.ego:136
+		_, _ = io.WriteString(w, " ")
+//line map.ego:136
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.TypeKey())))
+//line map.ego:136
+		_, _ = io.WriteString(w, "</title>\n            </use>\n        ")
+//line map.ego:138
+	}
+//line map.ego:139
+	_, _ = io.WriteString(w, "\n        </g>\n        <g id=\"start-end\" class=\"layer\">\n        ")
+//line map.ego:141
+	first := t.Segments[0].Points[0]
+	last := t.Segments[len(t.Segments)-1].Points[len(t.Segments[len(t.Segments)-1].Points)-1]
+	start, end := m.marker(first), m.marker(last)
+
+//line map.ego:145
+	_, _ = io.WriteString(w, "\n            <circle class=\"start\" cx=\"")
+//line map.ego:145
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(start.X)))
+//line map.ego:145
+	_, _ = io.WriteString(w, "\" cy=\"")
+//line map.ego:145
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(start.Y)))
+//line map.ego:145
+	_, _ = io.WriteString(w, "\" r=\"")
+//line map.ego:145
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(size)))
+//line map.ego:145
+	_, _ = io.WriteString(w, "\">\n            <title>start ")
+//line map.ego:146
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Start.In(tz).Format(time.TimeOnly))))
+//line map.ego:146
+	_, _ = io.WriteString(w, "</title>\n            </circle>\n            <rect class=\"end\" x=\"")
+//line map.ego:148
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(end.X-size)))
+//line map.ego:148
+	_, _ = io.WriteString(w, "\" y=\"")
+//line map.ego:148
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(end.Y-size)))
+//line map.ego:148
+	_, _ = io.WriteString(w, "\" width=\"")
+//line map.ego:148
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(2*size)))
+//line map.ego:148
+	_, _ = io.WriteString(w, "\" height=\"")
+//line map.ego:148
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(2*size)))
+//line map.ego:148
+	_, _ = io.WriteString(w, "\">\n            <title>end ")
+//line map.ego:149
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.End.In(tz).Format(time.TimeOnly))))
+//line map.ego:149
+	_, _ = io.WriteString(w, "</title>\n            </rect>\n        </g>\n        <g id=\"measurement\"></g>\n    ")
+//line map.ego:153
+	boat := size
+//line map.ego:154
+	_, _ = io.WriteString(w, "\n        <g id=\"boat\" visibility=\"hidden\">\n            <polygon points=\"0,")
+//line map.ego:155
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-boat)))
+//line map.ego:155
+	_, _ = io.WriteString(w, " ")
+//line map.ego:155
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(boat/2)))
+//line map.ego:155
+	_, _ = io.WriteString(w, ",")
+//line map.ego:155
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(boat*2/3)))
+//line map.ego:155
+	_, _ = io.WriteString(w, " 0,")
+//line map.ego:155
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(boat/3)))
+//line map.ego:155
+	_, _ = io.WriteString(w, " ")
+//line map.ego:155
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-boat/2)))
+//line map.ego:155
+	_, _ = io.WriteString(w, ",")
+//line map.ego:155
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(boat*2/3)))
+//line map.ego:155
+	_, _ = io.WriteString(w, "\"/>\n        </g>\n    </svg>\n    <svg id=\"timeline\" x=\"20\" y=\"100\" width=\"95%\" height=\"50\" preserveAspectRatio=\"none\" viewBox=\"0 0 ")
+//line map.ego:158
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Duration.Seconds())))
+//line map.ego:158
+	_, _ = io.WriteString(w, " ")
+//line map.ego:158
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight)))
+//line map.ego:158
+	_, _ = io.WriteString(w, "\">\n        <!-- Invisible rectangle covering the whole viewport is needed so that mouse events are captured\n            by the #timeline element whenever the mouse pointer is anywhere in the viewport -->\n        <rect id=\"background\" width=\"100%\" height=\"100%\" fill=\"transparent\"/>\n        ")
+//line map.ego:162
+	for v := 5; v*tlUnitHeight < tlHeight; v += 5 {
+//line map.ego:163
+		_, _ = io.WriteString(w, "\n        <line class=\"timeline-grid\" x1=\"0\" y1=\"")
+//line map.ego:163
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight-v*tlUnitHeight)))
+//line map.ego:163
+		_, _ = io.WriteString(w, "\" x2=\"")
+//line map.ego:163
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Duration.Seconds())))
+//line map.ego:163
+		_, _ = io.WriteString(w, "\" y2=\"")
+//line map.ego:163
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight-v*tlUnitHeight)))
+//line map.ego:163
+		_, _ = io.WriteString(w, "\"/>\n        ")
+//line map.ego:164
+	}
+//line map.ego:165
+	_, _ = io.WriteString(w, "\n        ")
+//line map.ego:165
+
+	offset := 0
+	for _, segment := range t.Segments {
+		if kind := segment.ManeuverKind(); kind == "tack" || kind == "gybe" || kind == "turn" {
+
+//line map.ego:170
+			_, _ = io.WriteString(w, "\n        <rect class=\"timeline-maneuver maneuver-")
+//line map.ego:170
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(kind)))
+//line map.ego:170
+			_, _ = io.WriteString(w, "\" x=\"")
+//line map.ego:170
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(offset)))
+//line map.ego:170
+			_, _ = io.WriteString(w, "\" y=\"0\" width=\"")
+//line map.ego:170
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(int(segment.Duration.Seconds()))))
+//line map.ego:170
+			_, _ = io.WriteString(w, "\" height=\"")
+//line map.ego:170
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight)))
+//line map.ego:170
+			_, _ = io.WriteString(w, "\"/>\n        ")
+//line map.ego:171
+		}
+		offset += int(segment.Duration.Seconds())
+	}
+	offset = 0
+	for i, segment := range t.Segments {
+		width := int(segment.Duration.Seconds())
+		timestamp := segment.Start.In(t.Timezone()).Format(time.TimeOnly)
+		wa := segment.WindAttitude()
+		class := "timeline-segment"
+		if wa == track.Upwind {
+			class = "timeline-segment-upwind"
+		} else if wa == track.Downwind {
+			class = "timeline-segment-downwind"
+		}
+
+//line map.ego:183
+		_, _ = io.WriteString(w, "\n            <polygon class=\"")
+//line map.ego:183
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(class)))
+//line map.ego:183
+		_, _ = io.WriteString(w, "\" id=\"s")
+//line map.ego:183
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(strconv.Itoa(i))))
+//line map.ego:183
+		_, _ = io.WriteString(w, "\" points=\"")
+//line map.ego:183
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segmentTimeline(segment, offset))))
+//line map.ego:183
+		_, _ = io.WriteString(w, "\"/>\n            <rect class=\"timeline-segment-rect\" id=\"s")
+//line map.ego:184
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(strconv.Itoa(i))))
+//line map.ego:184
+		_, _ = io.WriteString(w, "\" x=\"")
+//line map.ego:184
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(offset)))
+//line map.ego:184
+		_, _ = io.WriteString(w, "\" y=\"0\" width=\"")
+//line map.ego:184
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(width)))
+//line map.ego:184
+		_, _ = io.WriteString(w, "\" height=\"")
+//line map.ego:184
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight)))
+//line map.ego:184
+		_, _ = io.WriteString(w, "\">\n            <title>")
+//line map.ego:185
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(timestamp)))
+//line map.ego:185
+		_, _ = io.WriteString(w, "  ")
+//line map.ego:185
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.TypeString())))
+//line map.ego:186
+		_, _ = io.WriteString(w, "\n")
+//line map.ego:186
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(segment.ShortString())))
+//line map.ego:186
+		_, _ = io.WriteString(w, "</title>\n            </rect>\n        ")
+//line map.ego:188
+
+		offset += int(segment.Duration.Seconds())
+	}
+
+//line map.ego:192
+	_, _ = io.WriteString(w, "\n        <g id=\"timeline-heading\" class=\"layer\" style=\"display: none\">\n            <path class=\"timeline-trace\" d=\"")
+//line map.ego:193
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(timelineTrace(t, 360, true, func(p *track.Point) float64 { return float64(p.Heading) }))))
+//line map.ego:193
+	_, _ = io.WriteString(w, "\">\n            <title>heading, 0° at the bottom, 360° at the top</title>\n            </path>\n        </g>\n        ")
+//line map.ego:197
+	if t.Wind != track.UNK {
+//line map.ego:198
+		_, _ = io.WriteString(w, "\n        <g id=\"timeline-vmg\" class=\"layer\" style=\"display: none\">\n            <path class=\"timeline-trace\" d=\"")
+//line map.ego:199
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(timelineTrace(t, tlHeight/tlUnitHeight, false, func(p *track.Point) float64 { return t.Wind.VMG(p.Heading, p.Speed) }))))
+//line map.ego:199
+		_, _ = io.WriteString(w, "\">\n            <title>VMG on the speed scale</title>\n            </path>\n        </g>\n        ")
+//line map.ego:203
+	}
+//line map.ego:204
+	_, _ = io.WriteString(w, "\n        <g id=\"playback-cursor\" visibility=\"hidden\">\n            <line class=\"playback-handle\" x1=\"0\" y1=\"0\" x2=\"0\" y2=\"")
+//line map.ego:205
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight)))
+//line map.ego:205
+	_, _ = io.WriteString(w, "\"/>\n            <line class=\"playback-line\" x1=\"0\" y1=\"0\" x2=\"0\" y2=\"")
+//line map.ego:206
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tlHeight)))
+//line map.ego:206
+	_, _ = io.WriteString(w, "\"/>\n        </g>\n    </svg>\n    <g id=\"timeline-speed-axis\">\n        ")
+//line map.ego:210
+	for v := 5; v*tlUnitHeight < tlHeight; v += 5 {
+//line map.ego:211
+		_, _ = io.WriteString(w, "\n        <text x=\"16\" y=\"")
+//line map.ego:211
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(float64(tlHeight-v*tlUnitHeight)/tlHeight*50+3)))
+//line map.ego:211
+		_, _ = io.WriteString(w, "\" text-anchor=\"end\">")
+//line map.ego:211
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(v)))
+//line map.ego:211
+		_, _ = io.WriteString(w, "</text>\n        ")
+//line map.ego:212
+	}
+//line map.ego:213
+	_, _ = io.WriteString(w, "\n        <text x=\"16\" y=\"-4\" text-anchor=\"end\">")
+//line map.ego:213
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Params.SpeedLabel())))
+//line map.ego:213
+	_, _ = io.WriteString(w, "</text>\n    </g>\n    <g id=\"timeline-time-axis\"></g>\n    <g id=\"scale-bar\">\n        <line x1=\"0\" y1=\"0\" x2=\"100\" y2=\"0\"/>\n        <line x1=\"0\" y1=\"-4\" x2=\"0\" y2=\"4\"/>\n        <line class=\"scale-bar-end\" x1=\"100\" y1=\"-4\" x2=\"100\" y2=\"4\"/>\n        <text x=\"0\" y=\"-8\"></text>\n    </g>\n    <g id=\"compass\">\n        <circle r=\"30\"/>\n        ")
+//line map.ego:224
+	for deg := 0; deg < 360; deg += 45 {
+//line map.ego:225
+		_, _ = io.WriteString(w, "\n        <line class=\"compass-tick\" x1=\"0\" y1=\"-30\" x2=\"0\" y2=\"")
+//line map.ego:225
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(-30+4+4*(1-deg%90/45))))
+//line map.ego:225
+		_, _ = io.WriteString(w, "\" transform=\"rotate(")
+//line map.ego:225
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(deg)))
+//line map.ego:225
+		_, _ = io.WriteString(w, ")\"/>\n        ")
+//line map.ego:226
+	}
+//line map.ego:227
+	_, _ = io.WriteString(w, "\n        <polygon class=\"compass-north\" points=\"0,-26 5,0 -5,0\"/>\n        <polygon class=\"compass-south\" points=\"0,26 5,0 -5,0\"/>\n        <text y=\"-34\" text-anchor=\"middle\">N</text>\n        ")
+//line map.ego:230
+	if t.Wind != track.UNK {
+//line map.ego:231
+		_, _ = io.WriteString(w, "\n        <g class=\"compass-wind\" transform=\"rotate(")
+//line map.ego:231
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(int(t.Wind))))
+//line map.ego:231
+		_, _ = io.WriteString(w, ")\">\n            <line x1=\"0\" y1=\"-44\" x2=\"0\" y2=\"-12\"/>\n            <polygon points=\"0,-8 5,-18 -5,-18\"/>\n        </g>\n        <text class=\"compass-wind\" y=\"46\" text-anchor=\"middle\">wind ")
+//line map.ego:235
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Wind.String())))
+//line map.ego:235
+		_, _ = io.WriteString(w, "</text>\n        ")
+//line map.ego:236
+	}
+//line map.ego:237
+	_, _ = io.WriteString(w, "\n    </g>\n    <foreignObject id=\"info-panel\" x=\"10\" y=\"30\" width=\"300\" height=\"200\" style=\"display: none\">\n        <div xmlns=\"http://www.w3.org/1999/xhtml\" class=\"info-panel\">\n            <button id=\"info-panel-close\" title=\"close\">&#215;</button>\n            <div id=\"info-panel-content\"></div>\n        </div>\n    </foreignObject>\n    <script>\nconst mapProjection = ")
+//line map.ego:245
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.projectionData(t))))
+//line map.ego:245
+	_, _ = io.WriteString(w, ";\nconst trackData = ")
+//line map.ego:246
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.playbackData(t))))
+//line map.ego:246
+	_, _ = io.WriteString(w, ";\nconst mapColorings = ")
+//line map.ego:247
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.coloringData(t))))
+//line map.ego:247
+	_, _ = io.WriteString(w, ";\nconst segmentStats = ")
+//line map.ego:248
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.segmentData(t))))
+//line map.ego:248
+	_, _ = io.WriteString(w, ";\nconst cropCommand = ")
+//line map.ego:249
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.cropData())))
+//line map.ego:249
+	_, _ = io.WriteString(w, ";\n")
+//line map.ego:250
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(script)))
+//line map.ego:251
+	_, _ = io.WriteString(w, "\n    </script>\n</svg>\n")
+//line map.ego:253
+}
+
+var _ fmt.Stringer
+var _ io.Reader
+var _ context.Context
+var _ = html.EscapeString
@@ -0,0 +1,325 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+const border = 20      // map padding area width in points of SVG coordinates
+const tlUnitHeight = 3 // timeline height of a vertical unit (e.g. a knot) in points of SVG coordinates
+const tlHeight = 25 * tlUnitHeight
+
+//go:embed map.js
+var script string
+
+//go:embed map.css
+var css string
+
+// Map is used to translate track coordinates into SVG coordinate system for rendering.
+// The positions are projected using Web Mercator, so that the map aligns with map tiles.
+type Map struct {
+	w, h        float64 // width, height in svg coordinates including the border
+	lw          float64 // width in lat/lon degrees
+	lx          float64 // left offset in lat/lon degrees
+	top         float64 // Web Mercator y of the top edge in degrees (see mercator)
+	k           float64 // SVG units per degree of longitude
+	*track.Area         // longitudinal adjustment of the track area
+
+	tiles     []mapTile    // background map tiles, see backgroundTiles
+	colors    *mapColors   // track coloring
+	colorings []*mapColors // all colorings available for the track, selectable in the viewer
+	command   []string     // command line generating the map, see MapOptions
+	inputs    []string
+}
+
+// MapOptions control the rendering of the map.
+type MapOptions struct {
+	Background TileSource      // map tiles embedded as the background of the map, if not nil
+	Coloring   MapColoring     // track coloring, speed if empty or not available for the track
+	SpeedRange SpeedColorRange // speeds covered by the speed coloring
+	Ramp       []int           // colors of the speed coloring, rainbow palette if nil
+	// Command line generating the map without the input files and the crop options (-from, -to),
+	// the viewer shows the command cropping the track to a timeline selection if it is set.
+	Command []string
+	Inputs  []string // input files of the command line
+}
+
+// setColoring sets up the colorings available for the track and selects the coloring of the options,
+// falls back to speed coloring if the coloring isn't available.
+func (m *Map) setColoring(t *track.Track, opts *MapOptions) {
+	m.colorings = nil
+	for _, name := range KnownMapColorings {
+		if mc := newMapColors(t, MapColoring(name), opts); mc != nil {
+			m.colorings = append(m.colorings, mc)
+			if mc.Coloring == opts.Coloring || m.colors == nil {
+				m.colors = mc
+			}
+		}
+	}
+}
+
+// NewMap returns a map of the area within the bounds, using the unit for the scale.
+func NewMap(b gpx.GpxBounds, unit track.Unit) *Map {
+	m := &Map{Area: track.NewArea(b), lx: b.MinLongitude}
+	// calculate lat/long width
+	m.lw = (b.MaxLongitude - b.MinLongitude) * m.Coef
+	// compute SVG dimensions as lat/long dimensions * unit
+	// i.e. one point in SVG coordinates is 1 unit
+	m.w = m.lw*m.Coef*float64(unit) + 2*border
+	m.k = (m.w - 2*border) / (b.MaxLongitude - b.MinLongitude)
+	m.top = mercator(b.MaxLatitude)
+	m.h = (m.top-mercator(b.MinLatitude))*m.k + 2*border
+	return m
+}
+
+// mercator returns the Web Mercator y coordinate of the latitude scaled to degrees,
+// i.e. the same scale as longitude, ranging from -180 to 180.
+func mercator(lat float64) float64 {
+	return math.Log(math.Tan(math.Pi/4+lat*math.Pi/360)) * 180 / math.Pi
+}
+
+// inverseMercator returns the latitude of the Web Mercator y coordinate in degrees.
+func inverseMercator(y float64) float64 {
+	return math.Atan(math.Sinh(y*math.Pi/180)) * 180 / math.Pi
+}
+
+// Point translates a GPS point into SVG coordinates.
+func (m *Map) Point(p *gpx.GPXPoint) (x, y int) {
+	fx, fy := m.project(p.Latitude, p.Longitude)
+	return int(fx), int(fy)
+}
+
+// project translates a position into SVG coordinates without rounding.
+func (m *Map) project(lat, lon float64) (x, y float64) {
+	x = (lon-m.lx)*m.k + border
+	y = (m.top-mercator(lat))*m.k + border
+	return x, y
+}
+
+// unproject translates SVG coordinates into a position.
+func (m *Map) unproject(x, y float64) (lat, lon float64) {
+	return inverseMercator(m.top - (y-border)/m.k), m.lx + (x-border)/m.k
+}
+
+var palette = func() (palette []int) {
+	for i := 0; i < 16; i += 2 {
+		palette = append(palette, i*16+15)
+	}
+	for i := 0; i < 16; i += 4 {
+		palette = append(palette, 15*16+15-i)
+	}
+	for i := 0; i < 16; i += 4 {
+		palette = append(palette, (i*16+15)*16)
+	}
+	for i := 0; i < 16; i += 2 {
+		palette = append(palette, (17*15-i)*16)
+	}
+	return palette
+}()
+
+// mapMarker is a marker placed on the map at x, y in SVG coordinates, rotated to the heading.
+type mapMarker struct {
+	X, Y    float64
+	Heading int
+}
+
+// markerSize returns the size of the map markers in SVG coordinates, so that they are visible at full extent of the map.
+func (m *Map) markerSize() float64 {
+	return max(m.w, m.h) / 100
+}
+
+// arrows returns direction of travel markers placed along the segment every @spacing SVG units.
+func (m *Map) arrows(s *track.Segment, spacing float64) (arrows []mapMarker) {
+	next := spacing / 2 // distance to the next arrow
+	s.EachPair(func(p1, p2 *track.Point) {
+		x1, y1 := m.project(p1.GPX.Latitude, p1.GPX.Longitude)
+		x2, y2 := m.project(p2.GPX.Latitude, p2.GPX.Longitude)
+		d := math.Hypot(x2-x1, y2-y1)
+		for ; next <= d; next += spacing {
+			f := next / d
+			arrows = append(arrows, mapMarker{X: x1 + (x2-x1)*f, Y: y1 + (y2-y1)*f, Heading: m.Heading(p1.GPX, p2.GPX)})
+		}
+		next -= d
+	})
+	return arrows
+}
+
+// marker returns a marker at the point.
+func (m *Map) marker(p *track.Point) mapMarker {
+	x, y := m.project(p.GPX.Latitude, p.GPX.Longitude)
+	return mapMarker{X: x, Y: y, Heading: p.Heading}
+}
+
+// Transform returns the SVG transform placing the marker.
+func (mm mapMarker) Transform() string {
+	return fmt.Sprintf("translate(%.1f %.1f) rotate(%d)", mm.X, mm.Y, mm.Heading)
+}
+
+// projectionData returns the map projection parameters as a JavaScript object literal,
+// used by map.js to convert between SVG coordinates and lat/lon degrees (graticule)
+// and distances (scale bar). The distance is in long distance units per SVG unit at the center of the map.
+func (m *Map) projectionData(t *track.Track) string {
+	b, _ := json.Marshal(map[string]any{
+		"lx": m.lx, "top": m.top, "k": m.k,
+		"border": border,
+		// a degree of longitude is coef degrees of the great circle
+		"distance":     m.Coef / m.k * float64(t.Params.LongDistanceUnit),
+		"distanceUnit": t.Params.LongDistanceLabel(),
+		// for measuring distances between positions the same way as Map.Distance
+		"coef": m.Coef,
+		"unit": float64(t.Params.LongDistanceUnit),
+	})
+	return string(b)
+}
+
+// cropData returns the command line of the map as a JavaScript object literal for building the crop command in the viewer,
+// null if the command line is unknown. The arguments are quoted for the shell.
+func (m *Map) cropData() string {
+	if len(m.command) == 0 {
+		return "null"
+	}
+	quote := func(args []string) string {
+		var quoted []string
+		for _, arg := range args {
+			quoted = append(quoted, shellQuote(arg))
+		}
+		return strings.Join(quoted, " ")
+	}
+	b, _ := json.Marshal(map[string]string{"command": quote(m.command), "inputs": quote(m.inputs)})
+	return string(b)
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// shellQuote quotes the argument for a POSIX shell if needed.
+func shellQuote(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// segmentData returns the stats of the track segments as a JavaScript array literal for the segment panel of the viewer.
+// The distance is in long distance units, the times are times of day in the track timezone.
+func (m *Map) segmentData(t *track.Track) string {
+	type segment struct {
+		Type     string     `json:"type"`
+		Mode     track.Mode `json:"mode"`
+		Start    string     `json:"start"`
+		End      string     `json:"end"`
+		Duration float64    `json:"duration"` // seconds
+		Distance float64    `json:"distance"`
+		Speed    [3]float64 `json:"speed"`   // min, avg, max
+		Heading  [3]int     `json:"heading"` // min, max, variation
+		Points   int        `json:"points"`
+	}
+	tz := t.Timezone()
+	data := []*segment{}
+	for _, s := range t.Segments {
+		data = append(data, &segment{
+			Type:     s.TypeKey(),
+			Mode:     s.Mode,
+			Start:    s.Start.In(tz).Format(time.TimeOnly),
+			End:      s.End.In(tz).Format(time.TimeOnly),
+			Duration: s.Duration.Seconds(),
+			Distance: Round(t.Params.AsLongDistance(s.Distance), 1000),
+			Speed:    [3]float64{Round(s.Speed.Min, 10), Round(s.Speed.Avg, 10), Round(s.Speed.Max, 10)},
+			Heading:  [3]int{s.Heading.Min, s.Heading.Max, s.Heading.Variation},
+			Points:   len(s.Points),
+		})
+	}
+	b, _ := json.Marshal(data)
+	return string(b)
+}
+
+// Round rounds the value to the precision (e.g. 100 for 2 decimal places) for the JSON data of the viewer,
+// which can't hold NaN or infinite values, those are replaced with 0.
+func Round(v float64, precision float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return math.Round(v*precision) / precision
+}
+
+// playbackSpeeds are the speed multipliers offered by the track playback in the SVG viewer.
+var playbackSpeeds = []int{1, 2, 5, 10, 30, 60}
+
+// playbackData returns the track points as a compact JavaScript object literal that drives the playback in map.js.
+// Each point is [timeline offset, time of day, x, y, speed, heading, track distance, segment index, latitude, longitude],
+// where the timeline offset is in seconds matching the timeline coordinates (i.e. the gaps between segments are skipped),
+// the time of day is in seconds in the track timezone and the track distance is in long distance units.
+// The measurements use the latitude and longitude, the x and y are rounded to the map grid.
+func (m *Map) playbackData(t *track.Track) string {
+	data := struct {
+		SpeedUnit    string      `json:"speedUnit"`
+		DistanceUnit string      `json:"distanceUnit"`
+		Segments     []string    `json:"segments"`
+		Points       [][]float64 `json:"points"`
+	}{SpeedUnit: t.Params.SpeedLabel(), DistanceUnit: t.Params.LongDistanceLabel()}
+	start := t.Start.In(t.Timezone())
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	offset, distance := 0, 0.0
+	for i, segment := range t.Segments {
+		data.Segments = append(data.Segments, segment.TypeKey())
+		for _, p := range segment.Points {
+			distance += p.Distance
+			x, y := m.Point(p.GPX)
+			data.Points = append(data.Points, []float64{
+				float64(offset) + p.GPX.Timestamp.Sub(segment.Start).Seconds(),
+				p.GPX.Timestamp.Sub(day).Seconds(),
+				float64(x), float64(y),
+				Round(p.Speed, 10),
+				float64(p.Heading),
+				Round(t.Params.AsLongDistance(distance), 1000),
+				float64(i),
+				p.GPX.Latitude, p.GPX.Longitude,
+			})
+		}
+		offset += int(segment.Duration.Seconds())
+	}
+	b, _ := json.Marshal(data) // can't fail, all the values are finite
+	return string(b)
+}
+
+// timelineTrace returns the SVG path of the point values in the timeline coordinates,
+// scaled so that @top is at the top of the timeline, higher values are clipped.
+// If @wraps is set, the path is broken where the value wraps around (e.g. heading from 350 to 10).
+func timelineTrace(t *track.Track, top float64, wraps bool, value func(p *track.Point) float64) string {
+	var path strings.Builder
+	prev := math.NaN()
+	offset := 0
+	for _, s := range t.Segments {
+		for _, p := range s.Points {
+			v := max(0, min(value(p), top))
+			op := "L"
+			if math.IsNaN(prev) || (wraps && math.Abs(v-prev) > top/2) {
+				op = "M"
+			}
+			x := float64(offset) + p.GPX.Timestamp.Sub(s.Start).Seconds()
+			fmt.Fprintf(&path, "%s%.0f,%.1f", op, x, tlHeight-v/top*tlHeight)
+			prev = v
+		}
+		offset += int(s.Duration.Seconds())
+	}
+	return path.String()
+}
+
+func segmentTimeline(s *track.Segment, offset int) string {
+	points := []string{fmt.Sprintf("%d,%d", offset, tlHeight)}
+	for _, p := range s.Points {
+		x := int(p.GPX.Timestamp.Sub(s.Start).Seconds())
+		y := tlHeight - int(p.Speed)*(tlUnitHeight)
+		points = append(points, fmt.Sprintf("%d,%d", offset+x, y))
+	}
+	x := int(s.Duration.Seconds())
+	points = append(points, fmt.Sprintf("%d,%d", offset+x, tlHeight))
+	return strings.Join(points, " ")
+}
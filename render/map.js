@@ -0,0 +1,748 @@
+
+// Tunable parameters
+const zoomSensitivityMouse = 0.001; // Adjust for zoom speed
+const zoomSensitivityTouchpad = 0.01; // Adjust for zoom speed
+const dragMultiplier = 2.5; // Adjust for drag speed
+
+const root = document.querySelector('svg#root');
+const map = document.querySelector('svg#map');
+const legend = document.querySelector('g#legend');
+const timeline = document.querySelector('svg#timeline');
+
+function updatePositions() {
+    const {width: rootWidth, height: rootHeight} = root.getBoundingClientRect(); 
+    const legendHeight = legend.getBoundingClientRect().height; 
+    let mapHeight = map.getBoundingClientRect().height; 
+    let timelineHeight = timeline.getBoundingClientRect().height;
+    mapHeight = rootHeight - legendHeight - timelineHeight
+    map.setAttribute('height', mapHeight)
+    timeline.setAttribute('y', rootHeight - timelineHeight-20)
+    timeline.setAttribute('width', rootWidth - 40)
+};
+
+updatePositions();
+
+window.addEventListener("resize", updatePositions)
+
+// map zooming/panning
+const [_minX, _minY, maxX, maxY] = map.getAttribute('viewBox').split(' ').map(Number);
+let startX, startY, initialDragViewBox;
+
+function clamp(x, max) {
+    if (0 > x) return 0;
+    if (x > max) return max;
+    return x
+}
+
+function mapDragStart(event) {
+    map.style.cursor = 'grabbing';
+    startX = event.clientX;
+    startY = event.clientY;
+    initialDragViewBox = map.getAttribute('viewBox').split(' ').map(Number);
+    map.addEventListener('mousemove', mapDragMove);
+
+};
+
+function mapDragMove(event) {
+    const deltaX = event.clientX - startX;
+    const deltaY = event.clientY - startY;
+
+    // Adjust the viewBox based on the mouse movement
+    const newMinX = clamp(initialDragViewBox[0] - deltaX*dragMultiplier, maxX);
+    const newMinY = clamp(initialDragViewBox[1] - deltaY*dragMultiplier, maxY);
+
+    map.setAttribute('viewBox', `${newMinX} ${newMinY} ${initialDragViewBox[2]} ${initialDragViewBox[3]}`);
+};
+
+function mapDragStop() {
+    map.removeEventListener('mousemove', mapDragMove);
+    map.style.cursor = 'auto';
+};
+
+function zoomSensitivity(event) {
+    // Check if deltaX or deltaY are very small and non-zero, which is typical for touchpads
+    if (1 > Math.abs(event.deltaY) || 1 > Math.abs(event.deltaX)) {
+        return zoomSensitivityTouchpad;
+    } else {
+        return zoomSensitivityMouse;
+    }
+}
+
+function mapWheelZoom(event) {
+    event.preventDefault(); // Prevent default scrolling
+
+    const delta = -event.deltaY * zoomSensitivity(event); // Normalize scroll direction and sensitivity
+
+    let [minX, minY, width, height] = map.getAttribute('viewBox').split(' ').map(Number);
+
+    // Calculate the point to zoom around (mouse position relative to SVG)
+    const rect = map.getBoundingClientRect();
+    const mouseX = event.clientX - rect.left;
+    const mouseY = event.clientY - rect.top;
+
+    // Calculate the focal point in the SVG's user coordinates
+    const focusX = minX + width * (mouseX / rect.width);
+    const focusY = minY + height * (mouseY / rect.height);
+
+    // Adjust width and height based on zoom delta
+    const newWidth = clamp(width * (1 + delta), maxX);
+    const newHeight = clamp(height * (1 + delta), maxY);
+
+    // Ensure width and height don't become zero or negative
+    if (0 == newWidth || 0 == newHeight) return;
+
+    // Adjust minX and minY to zoom around the focal point
+    const newMinX = clamp(focusX - (mouseX / rect.width) * newWidth, maxX);
+    const newMinY = clamp(focusY - (mouseY / rect.height) * newHeight, maxY);
+
+    map.setAttribute('viewBox', `${newMinX} ${newMinY} ${newWidth} ${newHeight}`);
+};
+
+map.addEventListener('mousedown', mapDragStart);
+map.addEventListener('mouseup', mapDragStop);
+map.addEventListener('mouseleave', mapDragStop);
+map.addEventListener('wheel', mapWheelZoom , { passive: false }); // passive: false is needed to preventDefault
+
+// map hover handling
+// * highlight the corresponding timeline segment
+
+function getMapSegmentId(event) {
+    const target = event.target
+    if(!target) return undefined
+    if(target.tagName != 'line') return undefined
+    const id = event.target.parentNode.getAttribute("id")
+    return id
+}
+
+function mapSegmentHoverStart(event) {
+    const id = getMapSegmentId(event)
+    if (!id) return
+    const selector = `rect#${id}`
+    const timelineSegment = timeline.querySelector(selector)
+    if (!timelineSegment) return
+    timelineSegment.classList.add("timeline-segment-rect-hovered")
+}
+
+function mapSegmentHoverStop(event) {
+    const id = getMapSegmentId(event)
+    if (!id) return
+    const selector = `rect#${id}`
+    const timelineSegment = timeline.querySelector(selector)
+    if (!timelineSegment) return
+    timelineSegment.classList.remove("timeline-segment-rect-hovered")
+}
+
+map.addEventListener('mouseover', mapSegmentHoverStart)
+map.addEventListener('mouseout', mapSegmentHoverStop)
+
+// timeline hover handling
+// * highlight corresponding map segment
+function getTimelineSegmentId(event) {
+    const target = event.target
+    if(!target) return undefined
+    if(target.tagName != 'rect' && target.tagName != 'polygon') return undefined
+    const id = event.target.getAttribute("id")
+    return id
+}
+
+function timelineSegmentHoverStart(event) {
+    const id = getTimelineSegmentId(event)
+    if (!id) return
+    const selector = `g#${id}.segment`
+    const mapSegment = map.querySelector(selector)
+    if (!mapSegment) return
+    mapSegment.classList.add("segment-hovered")
+}
+
+function timelineSegmentHoverStop(event) {
+    const id = getTimelineSegmentId(event)
+    if (!id) return
+    const mapSegment = map.querySelector(`g#${id}.segment`)
+    if (!mapSegment) return
+    mapSegment.classList.remove("segment-hovered")
+}
+
+timeline.addEventListener('mouseover', timelineSegmentHoverStart)
+timeline.addEventListener('mouseout', timelineSegmentHoverStop)
+
+// timeline period selection
+// * show box around selected segments
+// * hide everything but selected segments in the map
+// * narrow timeline viewbox to selected segments
+
+// Range represents a segment ID range as a continuous interval
+// (as opposed to a discrete set of IDs)
+class Range {
+    constructor(min, max) {
+        this.min = Range.toNum(min)
+        this.max = Range.toNum(max) ?? Range.toNum(min)
+    }
+
+    static toNum(id) {
+        if (!id) return undefined;
+        return parseInt(id.slice(1))
+    }
+
+    add(id) {
+        const val = Range.toNum(id)
+        if (this.min === undefined || val < this.min) this.min = val
+        if (this.max === undefined || val > this.max) this.max = val
+    }
+
+    has(id) {
+        const val = Range.toNum(id)
+        return this.min <= val && val <= this.max
+    }
+}
+
+let selectionBox = null; // tracks the visual timeline selection
+let selectedSegments = null; // tracks the selected segment range
+let timelineSelection = null; // the selected segment range after the selection stops
+
+function timelineSelectStart(event) {
+    const id = getTimelineSegmentId(event)
+    if (!id) return
+    // initialize the selecteSegments range and the selection box
+    selectedSegments = new Range(id);
+    const segmentBox = timeline.querySelector(`rect#${id}`)
+    selectionBox = document.createElementNS('http://www.w3.org/2000/svg', 'rect');
+    selectionBox.classList.add('timeline-selection-box')
+    selectionBox.setAttribute('id', 'selection-box');
+    selectionBox.setAttribute('x', segmentBox.getAttribute("x"));
+    selectionBox.setAttribute('y', 0);
+    selectionBox.setAttribute('width', segmentBox.getAttribute("width"));
+    selectionBox.setAttribute('height', "100%");
+    timeline.appendChild(selectionBox);
+    // start tracking the selection
+    timeline.addEventListener("mousemove", timelineSelectMove)
+}
+
+function timelineSelectMove(event) {
+    // add the target segment under cursor to the selectedSegments range.
+    const id = getTimelineSegmentId(event)
+    if (!id || id == selectionBox.getAttribute('id') || selectedSegments.has(id)) return
+    selectedSegments.add(id);
+    // expand the selectionBox to include the box of the added segment
+    const segmentBox = timeline.querySelector(`rect#${id}`)
+    const x = parseInt(selectionBox.getAttribute('x'))
+    let width = parseInt(selectionBox.getAttribute('width'))
+    width += parseInt(segmentBox.getAttribute('width')) 
+    selectionBox.setAttribute('width', width);
+}
+
+function timelineSelectStop(event) {
+    if (!selectionBox) return
+    // stop tracking selection
+    timeline.removeEventListener("mousemove", timelineSelectMove)
+    // zoom timeline viewport to show only the range of the selection box
+    let viewBox = timeline.getAttribute('viewBox')
+    const [_x, y, _width, height] = viewBox.split(' ').map(Number);
+    viewBox = `${selectionBox.getAttribute('x')} ${y} ${selectionBox.getAttribute('width')} ${height}`;
+    timeline.setAttribute('viewBox', viewBox)
+    // drop the selection box
+    selectionBox.remove()
+    selectionBox = null
+    // go over all map segments and hide the ones that aren't in selectedSegments range
+    for (const elem of map.querySelectorAll('g.segment')) {
+        if (selectedSegments.has(elem.getAttribute('id'))) continue;
+        elem.style.visibility = 'hidden';
+    }
+    // same for the segment markers in the map layers
+    for (const elem of map.querySelectorAll('[data-segment]')) {
+        if (selectedSegments.has(elem.dataset.segment)) continue;
+        elem.style.visibility = 'hidden';
+    }
+    timelineSelection = selectedSegments
+    selectedSegments = null
+    if (cropButton) cropButton.disabled = false
+}
+
+timeline.addEventListener("mousedown", timelineSelectStart)
+timeline.addEventListener("mouseup", timelineSelectStop)
+
+// reset the timeline selection, i.e. show the whole timeline and all the map segments
+const cropButton = root.querySelector('button#crop');
+const timelineViewBox = timeline.getAttribute('viewBox');
+
+function timelineReset() {
+    timeline.setAttribute('viewBox', timelineViewBox);
+    timelineSelection = null;
+    if (cropButton) cropButton.disabled = true;
+    for (const elem of map.querySelectorAll('g.segment, [data-segment]')) {
+        elem.style.visibility = '';
+    }
+}
+
+root.querySelector('#timeline-reset').addEventListener('click', timelineReset);
+
+// map layer toggles
+// * show/hide the map layers (background, grid, arrows, maneuvers and start/end markers)
+// * show/hide the heading and VMG traces in the timeline
+
+for (const toggle of root.querySelectorAll('input[data-layer]')) {
+    toggle.addEventListener('change', () => {
+        const layer = root.querySelector(`g#${toggle.dataset.layer}.layer`);
+        layer.style.display = toggle.checked ? '' : 'none';
+    });
+}
+
+// track coloring
+// * recolors the track steps and redraws the legend when another coloring is selected
+
+const coloringSelect = root.querySelector('select#coloring');
+const controls = root.querySelector('foreignObject#controls');
+
+function applyColoring(name) {
+    const coloring = mapColorings.find(c => c.name === name);
+    map.querySelectorAll('line.step').forEach((step, i) => {
+        step.setAttribute('stroke', coloring.palette[coloring.steps[i]]);
+    });
+    legend.replaceChildren();
+    coloring.legend.forEach(([color], i) => {
+        legend.appendChild(svgElement('rect', {x: coloring.width * i, y: 0, width: coloring.width, height: 20, fill: color}));
+    });
+    // labels go last so that they are not covered by the following entries
+    coloring.legend.forEach(([color, label, labelColor], i) => {
+        if (label) legend.appendChild(svgElement('text', {x: coloring.width * i + 5, y: 16, fill: labelColor}, label));
+    });
+    controls.setAttribute('x', coloring.width * coloring.legend.length + 10);
+}
+
+coloringSelect.addEventListener('change', () => applyColoring(coloringSelect.value));
+
+// graticule, scale bar and compass rose
+// * graticule lines are picked to fit the visible part of the map when it's zoomed or panned
+// * scale bar shows a round distance in long distance units matching the zoom level
+// * compass rose stays in the top right corner of the map
+
+const svgNS = 'http://www.w3.org/2000/svg';
+const graticule = map.querySelector('g#graticule');
+const scaleBar = root.querySelector('g#scale-bar');
+const compass = root.querySelector('g#compass');
+const graticuleSteps = [0.1, 0.2, 0.5, 1, 2, 5, 10, 15, 30, 60, 120, 300]; // in minutes
+const graticuleLines = 8; // maximum number of lines in each direction
+
+// conversions between map SVG coordinates and lat/lon degrees, see Map.project
+function mapX(lon) {
+    return (lon - mapProjection.lx) * mapProjection.k + mapProjection.border;
+}
+
+function mapY(lat) {
+    const mercator = Math.log(Math.tan(Math.PI / 4 + lat * Math.PI / 360)) * 180 / Math.PI;
+    return (mapProjection.top - mercator) * mapProjection.k + mapProjection.border;
+}
+
+function mapLon(x) {
+    return mapProjection.lx + (x - mapProjection.border) / mapProjection.k;
+}
+
+function mapLat(y) {
+    const mercator = mapProjection.top - (y - mapProjection.border) / mapProjection.k;
+    return Math.atan(Math.sinh(mercator * Math.PI / 180)) * 180 / Math.PI;
+}
+
+// formats the coordinate as degrees and minutes, e.g. 44°05.3'N
+function degreesMinutes(value, positive, negative, decimals) {
+    const hemisphere = value < 0 ? negative : positive;
+    value = Math.abs(value);
+    let degrees = Math.floor(value);
+    let minutes = ((value - degrees) * 60).toFixed(decimals);
+    if (Number(minutes) >= 60) {
+        degrees++;
+        minutes = (0).toFixed(decimals);
+    }
+    return `${degrees}°${minutes.padStart(decimals > 0 ? decimals + 3 : 2, '0')}'${hemisphere}`;
+}
+
+function svgElement(name, attributes, text) {
+    const elem = document.createElementNS(svgNS, name);
+    for (const [key, value] of Object.entries(attributes)) elem.setAttribute(key, value);
+    if (text) elem.textContent = text;
+    return elem;
+}
+
+function updateGraticule() {
+    const [minX, minY, width, height] = map.getAttribute('viewBox').split(' ').map(Number);
+    const [latMin, latMax] = [mapLat(minY + height), mapLat(minY)];
+    const [lonMin, lonMax] = [mapLon(minX), mapLon(minX + width)];
+    const span = Math.max(latMax - latMin, lonMax - lonMin) * 60;
+    const step = graticuleSteps.find(s => span / s <= graticuleLines) ?? graticuleSteps[graticuleSteps.length - 1];
+    const decimals = step < 1 ? 1 : 0;
+    const fontSize = height / 60;
+    graticule.replaceChildren();
+    for (let m = Math.ceil(latMin * 60 / step) * step; m <= latMax * 60; m += step) {
+        const y = mapY(m / 60);
+        graticule.appendChild(svgElement('line', {x1: minX, y1: y, x2: minX + width, y2: y}));
+        graticule.appendChild(svgElement('text', {x: minX + fontSize / 2, y: y - fontSize / 3, 'font-size': fontSize},
+            degreesMinutes(m / 60, 'N', 'S', decimals)));
+    }
+    for (let m = Math.ceil(lonMin * 60 / step) * step; m <= lonMax * 60; m += step) {
+        const x = mapX(m / 60);
+        graticule.appendChild(svgElement('line', {x1: x, y1: minY, x2: x, y2: minY + height}));
+        graticule.appendChild(svgElement('text', {x: x + fontSize / 3, y: minY + height - fontSize / 2, 'font-size': fontSize},
+            degreesMinutes(m / 60, 'E', 'W', decimals)));
+    }
+}
+
+function updateScaleBar() {
+    const rect = map.getBoundingClientRect();
+    const [_minX, _minY, width, height] = map.getAttribute('viewBox').split(' ').map(Number);
+    // screen pixels per SVG unit, the viewBox is scaled to fit the map element keeping the aspect ratio
+    const scale = Math.min(rect.width / width, rect.height / height);
+    const perPixel = mapProjection.distance / scale;
+    // round distance with a length of at most 150 pixels
+    const target = 150 * perPixel;
+    const magnitude = Math.pow(10, Math.floor(Math.log10(target)));
+    const distance = [5, 2, 1].map(n => n * magnitude).find(d => d <= target);
+    const length = distance / perPixel;
+    scaleBar.querySelector('line').setAttribute('x2', length);
+    for (const attr of ['x1', 'x2']) scaleBar.querySelector('line.scale-bar-end').setAttribute(attr, length);
+    scaleBar.querySelector('text').textContent = `${Number(distance.toPrecision(1))} ${mapProjection.distanceUnit}`;
+}
+
+function updateMapOverlays() {
+    const {width: rootWidth} = root.getBoundingClientRect();
+    const legendHeight = legend.getBoundingClientRect().height;
+    const timelineTop = Number(timeline.getAttribute('y'));
+    scaleBar.setAttribute('transform', `translate(20 ${timelineTop - 20})`);
+    compass.setAttribute('transform', `translate(${rootWidth - 60} ${legendHeight + 60})`);
+    updateGraticule();
+    updateScaleBar();
+}
+
+updateMapOverlays();
+window.addEventListener('resize', updateMapOverlays);
+new MutationObserver(updateMapOverlays).observe(map, {attributes: true, attributeFilter: ['viewBox']});
+
+// track playback
+// * little boat replaying the track with the point stats shown live
+// * play/pause and speed multiplier controls
+// * cursor on the timeline that can be dragged to scrub through the track
+
+const boat = map.querySelector('g#boat');
+const playbackCursor = timeline.querySelector('g#playback-cursor');
+const playbackButton = root.querySelector('#playback-play');
+const playbackSpeed = root.querySelector('#playback-speed');
+const playbackStats = root.querySelector('#playback-stats');
+// point fields, see Map.playbackData
+const [pTimeline, pTime, pX, pY, pSpeed, pHeading, pDistance, pSegment, pLat, pLon] = [0, 1, 2, 3, 4, 5, 6, 7, 8, 9];
+const points = trackData.points;
+const playbackEnd = points[points.length - 1][pTimeline];
+let playbackTime = 0; // current playback position in timeline coordinates (seconds)
+let playing = false;
+let lastFrame = null; // timestamp of the last animation frame
+
+// index of the last point at or before the time
+function playbackIndex(time) {
+    let lo = 0, hi = points.length - 1;
+    while (lo < hi) {
+        const mid = Math.ceil((lo + hi) / 2);
+        if (points[mid][pTimeline] <= time) lo = mid; else hi = mid - 1;
+    }
+    return lo;
+}
+
+function timeOfDay(seconds) {
+    seconds = Math.round(seconds) % 86400;
+    const pad = (n) => String(Math.floor(n)).padStart(2, '0');
+    return `${pad(seconds / 3600)}:${pad(seconds / 60 % 60)}:${pad(seconds % 60)}`;
+}
+
+function playbackUpdate() {
+    const i = playbackIndex(playbackTime);
+    const prev = points[i];
+    const next = points[Math.min(i + 1, points.length - 1)];
+    const span = next[pTimeline] - prev[pTimeline];
+    // don't interpolate across the gaps between segments
+    const f = span > 0 && prev[pSegment] == next[pSegment] ? (playbackTime - prev[pTimeline]) / span : 0;
+    const x = prev[pX] + (next[pX] - prev[pX]) * f;
+    const y = prev[pY] + (next[pY] - prev[pY]) * f;
+    const p = f > 0 ? next : prev;
+    boat.setAttribute('transform', `translate(${x} ${y}) rotate(${p[pHeading]})`);
+    boat.setAttribute('visibility', 'visible');
+    playbackCursor.setAttribute('transform', `translate(${playbackTime} 0)`);
+    playbackCursor.setAttribute('visibility', 'visible');
+    playbackStats.textContent = `${timeOfDay(prev[pTime] + playbackTime - prev[pTimeline])}: ` +
+        `${p[pSpeed].toFixed(1)} ${trackData.speedUnit} ↑ ${p[pHeading]}° = ` +
+        `${p[pDistance].toFixed(2)} ${trackData.distanceUnit} ${trackData.segments[p[pSegment]]}`;
+}
+
+function playbackFrame(now) {
+    if (!playing) return;
+    if (lastFrame != null) {
+        playbackTime = Math.min(playbackEnd, playbackTime + (now - lastFrame) / 1000 * Number(playbackSpeed.value));
+    }
+    lastFrame = now;
+    playbackUpdate();
+    if (playbackTime >= playbackEnd) {
+        playbackToggle();
+        return;
+    }
+    requestAnimationFrame(playbackFrame);
+}
+
+function playbackToggle() {
+    playing = !playing;
+    playbackButton.textContent = playing ? '❚❚' : '▶';
+    if (!playing) return;
+    if (playbackTime >= playbackEnd) playbackTime = 0;
+    lastFrame = null;
+    requestAnimationFrame(playbackFrame);
+}
+
+playbackButton.addEventListener('click', playbackToggle);
+
+// timeline coordinate of the mouse pointer
+function timelineTime(event) {
+    const point = timeline.createSVGPoint();
+    point.x = event.clientX;
+    point.y = event.clientY;
+    return clamp(point.matrixTransform(timeline.getScreenCTM().inverse()).x, playbackEnd);
+}
+
+function playbackScrubMove(event) {
+    playbackTime = timelineTime(event);
+    playbackUpdate();
+}
+
+function playbackScrubStart(event) {
+    // don't start the timeline selection
+    event.stopPropagation();
+    timeline.addEventListener('mousemove', playbackScrubMove);
+}
+
+function playbackScrubStop() {
+    timeline.removeEventListener('mousemove', playbackScrubMove);
+}
+
+playbackCursor.addEventListener('mousedown', playbackScrubStart);
+document.addEventListener('mouseup', playbackScrubStop);
+timeline.addEventListener('mouseleave', playbackScrubStop);
+
+playbackUpdate();
+
+// timeline axes
+// * time of day ticks below the timeline, picked to fit the visible part of the timeline when it's zoomed
+// * speed axis on the left of the timeline
+
+const timelineTimeAxis = root.querySelector('g#timeline-time-axis');
+const timelineSpeedAxis = root.querySelector('g#timeline-speed-axis');
+const tickSteps = [60, 120, 300, 600, 900, 1800, 3600, 7200, 14400]; // seconds
+const maxTicks = 10;
+// timeline offset and time of day of the start and the end of each segment, the timeline skips the gaps between segments
+const segmentSpans = [];
+for (const p of points) {
+    const span = segmentSpans[p[pSegment]];
+    if (span) {
+        span.end = p[pTimeline];
+    } else {
+        segmentSpans[p[pSegment]] = {start: p[pTimeline], end: p[pTimeline], time: p[pTime]};
+    }
+}
+
+function updateTimelineAxes() {
+    const [x0, _y, width, _height] = timeline.getAttribute('viewBox').split(' ').map(Number);
+    const left = Number(timeline.getAttribute('x'));
+    const top = Number(timeline.getAttribute('y'));
+    const pixels = Number(timeline.getAttribute('width'));
+    const bottom = top + Number(timeline.getAttribute('height'));
+    timelineSpeedAxis.setAttribute('transform', `translate(0 ${top})`);
+    const step = tickSteps.find(s => width / s <= maxTicks) ?? tickSteps[tickSteps.length - 1];
+    timelineTimeAxis.replaceChildren();
+    let lastX = -Infinity;
+    for (const span of segmentSpans) {
+        if (!span || span.end < x0 || span.start > x0 + width) continue;
+        for (let time = Math.ceil(span.time / step) * step; time <= span.time + span.end - span.start; time += step) {
+            const offset = span.start + time - span.time;
+            if (offset < x0 || offset > x0 + width) continue;
+            const x = left + (offset - x0) / width * pixels;
+            // skip ticks crowding the previous one, e.g. right after a gap
+            if (x - lastX < 40) continue;
+            lastX = x;
+            timelineTimeAxis.appendChild(svgElement('line', {x1: x, y1: bottom, x2: x, y2: bottom + 4}));
+            timelineTimeAxis.appendChild(svgElement('text', {x: x, y: bottom + 14, 'text-anchor': 'middle'}, timeOfDay(time).slice(0, 5)));
+        }
+    }
+}
+
+updateTimelineAxes();
+window.addEventListener('resize', updateTimelineAxes);
+new MutationObserver(updateTimelineAxes).observe(timeline, {attributes: true, attributeFilter: ['viewBox', 'y', 'width']});
+
+// segment stats panel and measurement
+// * clicking a segment on the map or the timeline pins its stats in the info panel, clicking it again unpins it
+// * with measure checked, clicking two points on the map measures the distance, bearing and time between them,
+//   the clicks snap to the nearest track point
+
+const infoPanel = root.querySelector('foreignObject#info-panel');
+const infoPanelContent = root.querySelector('#info-panel-content');
+const measureToggle = root.querySelector('input#measure');
+const measurement = map.querySelector('g#measurement');
+const htmlNS = 'http://www.w3.org/1999/xhtml';
+let pinnedSegment = null; // id of the pinned segment
+let measurePoints = []; // indexes of the measured points
+
+// distance in long distance units and heading in degrees between two positions, see Map.Distance and Map.Heading
+function mapDistance(lat1, lon1, lat2, lon2) {
+    const x = lat2 - lat1;
+    const y = (lon2 - lon1) * mapProjection.coef;
+    return mapProjection.unit * Math.sqrt(x * x + y * y);
+}
+
+function mapHeading(lat1, lon1, lat2, lon2) {
+    const deg = Math.round(Math.atan2((lon2 - lon1) * mapProjection.coef, lat2 - lat1) / Math.PI * 180);
+    return deg < 0 ? 360 + deg : deg;
+}
+
+function formatDuration(seconds) {
+    seconds = Math.round(Math.abs(seconds));
+    const pad = (n) => String(Math.floor(n)).padStart(2, '0');
+    const hours = Math.floor(seconds / 3600);
+    return hours > 0 ? `${hours}:${pad(seconds / 60 % 60)}:${pad(seconds % 60)}` : `${Math.floor(seconds / 60)}:${pad(seconds % 60)}`;
+}
+
+function showInfo(title, rows) {
+    const heading = document.createElementNS(htmlNS, 'b');
+    heading.textContent = title;
+    const table = document.createElementNS(htmlNS, 'table');
+    for (const row of rows) {
+        const tr = document.createElementNS(htmlNS, 'tr');
+        for (const text of row) {
+            const td = document.createElementNS(htmlNS, 'td');
+            td.textContent = text;
+            tr.appendChild(td);
+        }
+        table.appendChild(tr);
+    }
+    infoPanelContent.replaceChildren(heading, table);
+    infoPanel.style.display = '';
+}
+
+function hideInfo() {
+    infoPanel.style.display = 'none';
+    unpinSegment();
+    clearMeasurement();
+}
+
+function pinSegment(id) {
+    unpinSegment();
+    clearMeasurement();
+    pinnedSegment = id;
+    map.querySelector(`g#${id}.segment`)?.classList.add('segment-pinned');
+    timeline.querySelector(`rect#${id}`)?.classList.add('timeline-segment-rect-pinned');
+    const s = segmentStats[Range.toNum(id)];
+    showInfo(s.type, [
+        ['time', `${s.start} - ${s.end} (${formatDuration(s.duration)})`],
+        ['distance', `${s.distance.toFixed(2)} ${trackData.distanceUnit}`],
+        ['speed', `${s.speed.map(v => v.toFixed(1)).join(' / ')} ${trackData.speedUnit} (min/avg/max)`],
+        ['heading', `${s.heading[0]}° - ${s.heading[1]}° (${s.heading[2]}°)`],
+        ['points', `${s.points} ${s.mode}`],
+    ]);
+}
+
+function unpinSegment() {
+    if (!pinnedSegment) return;
+    map.querySelector(`g#${pinnedSegment}.segment`)?.classList.remove('segment-pinned');
+    timeline.querySelector(`rect#${pinnedSegment}`)?.classList.remove('timeline-segment-rect-pinned');
+    pinnedSegment = null;
+}
+
+function togglePin(id) {
+    if (id == pinnedSegment) {
+        hideInfo();
+    } else {
+        pinSegment(id);
+    }
+}
+
+// index of the track point nearest to the SVG position, ignoring the segments hidden by a timeline selection
+function nearestPoint(x, y) {
+    const hidden = new Set();
+    for (const elem of map.querySelectorAll('g.segment')) {
+        if (elem.style.visibility == 'hidden') hidden.add(Range.toNum(elem.getAttribute('id')));
+    }
+    let nearest = 0, min = Infinity;
+    points.forEach((p, i) => {
+        if (hidden.has(p[pSegment])) return;
+        const d = (p[pX] - x) ** 2 + (p[pY] - y) ** 2;
+        if (d < min) [nearest, min] = [i, d];
+    });
+    return nearest;
+}
+
+function measureClick(event) {
+    const point = map.createSVGPoint();
+    point.x = event.clientX;
+    point.y = event.clientY;
+    const {x, y} = point.matrixTransform(map.getScreenCTM().inverse());
+    // the third click starts a new measurement
+    if (measurePoints.length == 2) clearMeasurement();
+    measurePoints.push(nearestPoint(x, y));
+    drawMeasurement();
+}
+
+function drawMeasurement() {
+    const [_minX, _minY, width, _height] = map.getAttribute('viewBox').split(' ').map(Number);
+    const [a, b] = measurePoints.map(i => points[i]);
+    measurement.replaceChildren();
+    if (b) measurement.appendChild(svgElement('line', {x1: a[pX], y1: a[pY], x2: b[pX], y2: b[pY]}));
+    for (const p of [a, b]) {
+        if (p) measurement.appendChild(svgElement('circle', {cx: p[pX], cy: p[pY], r: width / 200}));
+    }
+    if (!b) {
+        showInfo('measure', [['from', timeOfDay(a[pTime])], ['to', 'click another point']]);
+        return;
+    }
+    const [latA, lonA, latB, lonB] = [a[pLat], a[pLon], b[pLat], b[pLon]];
+    const [first, last] = a[pTime] <= b[pTime] ? [a, b] : [b, a];
+    showInfo('measure', [
+        ['time', `${timeOfDay(first[pTime])} - ${timeOfDay(last[pTime])} (${formatDuration(last[pTime] - first[pTime])})`],
+        ['distance', `${mapDistance(latA, lonA, latB, lonB).toFixed(2)} ${trackData.distanceUnit}`],
+        ['bearing', `${mapHeading(latA, lonA, latB, lonB)}°`],
+        ['track distance', `${(last[pDistance] - first[pDistance]).toFixed(2)} ${trackData.distanceUnit}`],
+    ]);
+}
+
+function clearMeasurement() {
+    measurePoints = [];
+    measurement.replaceChildren();
+}
+
+function mapClick(event) {
+    // ignore the end of a map drag
+    if (Math.hypot(event.clientX - startX, event.clientY - startY) > 3) return;
+    if (measureToggle.checked) {
+        measureClick(event);
+        return;
+    }
+    const id = getMapSegmentId(event) ?? event.target.dataset?.segment;
+    if (id) togglePin(id);
+}
+
+function timelineClick(event) {
+    const id = getTimelineSegmentId(event);
+    if (id) togglePin(id);
+}
+
+map.addEventListener('click', mapClick);
+timeline.addEventListener('click', timelineClick);
+root.querySelector('#info-panel-close').addEventListener('click', hideInfo);
+measureToggle.addEventListener('change', () => {
+    hideInfo();
+    if (measureToggle.checked) showInfo('measure', [['from', 'click a point of the track']]);
+});
+
+// crop command
+// * shows the command line generating the outputs for just the timeline selection (-from, -to) and copies it to the clipboard
+
+function showCrop() {
+    if (!timelineSelection) return;
+    const from = segmentStats[timelineSelection.min].start;
+    const to = segmentStats[timelineSelection.max].end;
+    const command = `${cropCommand.command} -from ${from} -to ${to} ${cropCommand.inputs}`;
+    hideInfo();
+    showInfo('crop', [['from', from], ['to', to], ['command', command]]);
+    navigator.clipboard?.writeText(command);
+}
+
+cropButton?.addEventListener('click', showCrop);
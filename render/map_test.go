@@ -0,0 +1,188 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+func Test_Heading(t *testing.T) {
+	bounds := gpx.GpxBounds{MinLatitude: 44, MaxLatitude: 45, MinLongitude: -78, MaxLongitude: -77}
+	m := NewMap(bounds, 1000)
+	for i, tt := range []struct {
+		p1lon, p1lat, p2lon, p2lat float64
+		heading                    int // degrees
+		direction                  string
+		distance                   int // meters
+	}{
+		{-77.5, 44.5, -77.4, 44.6, 35, "NE", 13673},
+		{-77.5, 44.5, -77.4, 44.4, 145, "SE", 13673},
+		{-77.5, 44.5, -77.6, 44.4, 215, "SW", 13673},
+		{-77.5, 44.5, -77.6, 44.6, 325, "NW", 13673},
+	} {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			h := m.Heading(point(tt.p1lon, tt.p1lat), point(tt.p2lon, tt.p2lat))
+			if h != tt.heading {
+				t.Errorf("exp: %d, got %d", tt.heading, h)
+			}
+			d := int(m.Distance(point(tt.p1lon, tt.p1lat), point(tt.p2lon, tt.p2lat), track.Meter))
+			if d != tt.distance {
+				t.Errorf("exp: %d, got %d", tt.distance, d)
+			}
+		})
+	}
+}
+
+func point(lon, lat float64) *gpx.GPXPoint {
+	return &gpx.GPXPoint{Point: gpx.Point{
+		Longitude: lon,
+		Latitude:  lat,
+	}}
+}
+
+func Test_MapImage(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	m, _ := newMap(trk, &MapOptions{})
+	img := m.renderImage(trk, 300, 200)
+	testutil.AssertEqual(t, img.Bounds().Dx(), 300)
+	testutil.AssertEqual(t, img.Bounds().Dy(), 200)
+	// legend starts with the slowest color
+	testutil.AssertEqual(t, img.NRGBAAt(1, 1), rgb12(palette[0]))
+	// the track is drawn between the legend and the timeline
+	var track int
+	for y := 25; y < 110; y++ {
+		for x := 0; x < 300; x++ {
+			if img.NRGBAAt(x, y) != white {
+				track++
+			}
+		}
+	}
+	testutil.AssertEqual(t, track > 0, true)
+}
+
+func Test_PlaybackData(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	m := NewMap(trk.GPX.Bounds(), trk.Params.DistanceUnit)
+	var data struct {
+		Segments []string
+		Points   [][]float64
+	}
+	if err := json.Unmarshal([]byte(m.playbackData(trk)), &data); err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(data.Segments), len(trk.Segments))
+	testutil.AssertEqual(t, len(data.Points), len(trk.GPXPoints()))
+	first, last := data.Points[0], data.Points[len(data.Points)-1]
+	testutil.AssertEqual(t, first[0], 0.0)
+	testutil.AssertEqual(t, last[7], float64(len(trk.Segments)-1))
+	testutil.AssertEqual(t, last[6], math.Round(trk.Params.AsLongDistance(trk.Distance)*1000)/1000)
+	// the measurements use the exact position, not the map grid
+	p := trk.GPXPoints()[len(data.Points)-1]
+	testutil.AssertEqual(t, last[8], p.Latitude)
+	testutil.AssertEqual(t, last[9], p.Longitude)
+}
+
+func Test_MapArrows(t *testing.T) {
+	bounds := gpx.GpxBounds{MinLatitude: 44, MaxLatitude: 44.01, MinLongitude: -77.01, MaxLongitude: -77}
+	m := NewMap(bounds, track.Meter)
+	s := &track.Segment{Points: track.Points{
+		{GPX: point(-77.01, 44.005)},
+		{GPX: point(-77.005, 44.005)},
+		{GPX: point(-77, 44.005)},
+	}}
+	x1, _ := m.project(44.005, -77.01)
+	x2, _ := m.project(44.005, -77)
+	spacing := (x2 - x1) / 4
+	arrows := m.arrows(s, spacing)
+	testutil.AssertEqual(t, len(arrows), 4)
+	testutil.AssertEqual(t, math.Round(arrows[0].X-x1), math.Round(spacing/2))
+	testutil.AssertEqual(t, arrows[0].Heading, 90)
+	testutil.AssertEqual(t, arrows[3].Transform(), fmt.Sprintf("translate(%.1f %.1f) rotate(90)", arrows[3].X, arrows[3].Y))
+}
+
+func Test_ProjectionData(t *testing.T) {
+	bounds := gpx.GpxBounds{MinLatitude: 44, MaxLatitude: 44.1, MinLongitude: -77.1, MaxLongitude: -77}
+	m := NewMap(bounds, track.Meter)
+	trk := &track.Track{Params: track.Sailing.Params()}
+	var p struct {
+		Distance     float64
+		DistanceUnit string
+	}
+	if err := json.Unmarshal([]byte(m.projectionData(trk)), &p); err != nil {
+		t.Fatal(err)
+	}
+	// the height of the map in long distance units
+	testutil.AssertEqual(t, math.Round(p.Distance*(m.h-2*border)*100), math.Round(0.1*60*100))
+	testutil.AssertEqual(t, p.DistanceUnit, "nm")
+}
+
+func Test_Mercator(t *testing.T) {
+	bounds := gpx.GpxBounds{MinLatitude: 44, MaxLatitude: 44.1, MinLongitude: -77.1, MaxLongitude: -77}
+	m := NewMap(bounds, track.Meter)
+	x, y := m.project(44, -77.1)
+	testutil.AssertEqual(t, math.Round(x), float64(border))
+	testutil.AssertEqual(t, math.Round(y), math.Round(m.h-border))
+	lat, lon := m.unproject(m.project(44.05, -77.05))
+	testutil.AssertEqual(t, math.Round(lat*1e6), 44.05e6)
+	testutil.AssertEqual(t, math.Round(lon*1e6), -77.05e6)
+	testutil.AssertEqual(t, math.Round(mercator(0)*1e6), 0.0)
+	testutil.AssertEqual(t, math.Round(inverseMercator(mercator(60))*1e6), 60e6)
+}
+
+func Test_TimelineTrace(t *testing.T) {
+	start := time.Date(2016, 5, 25, 18, 0, 0, 0, time.UTC)
+	var points track.Points
+	for i, h := range []int{340, 355, 10, 20} {
+		p := &track.Point{GPX: point(-77, 44), Heading: h}
+		p.GPX.Timestamp = start.Add(time.Duration(i*10) * time.Second)
+		points = append(points, p)
+	}
+	trk := &track.Track{Segments: []*track.Segment{
+		{Points: points[:2], Start: start, Duration: 10 * time.Second},
+		{Points: points[2:], Start: start.Add(20 * time.Second), Duration: 10 * time.Second},
+	}}
+	// the trace breaks where the heading wraps around and the second segment continues right after the first one
+	testutil.AssertEqual(t, timelineTrace(trk, 360, true, func(p *track.Point) float64 { return float64(p.Heading) }),
+		"M0,4.2L10,1.0M10,72.9L20,70.8")
+	// without wrapping the values above the top are clipped
+	testutil.AssertEqual(t, timelineTrace(trk, 25, false, func(p *track.Point) float64 { return float64(p.Heading) }),
+		"M0,0.0L10,0.0L10,45.0L20,15.0")
+}
+
+func Test_SegmentData(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	m := NewMap(trk.GPX.Bounds(), trk.Params.DistanceUnit)
+	var data []struct {
+		Type     string
+		Start    string
+		Duration float64
+		Distance float64
+		Speed    []float64
+	}
+	if err := json.Unmarshal([]byte(m.segmentData(trk)), &data); err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(data), len(trk.Segments))
+	for i, s := range trk.Segments {
+		testutil.AssertEqual(t, data[i].Type, s.TypeKey())
+		testutil.AssertEqual(t, data[i].Duration, s.Duration.Seconds())
+		// the panel shows the same distance as the analysis
+		testutil.AssertEqual(t, data[i].Distance, math.Round(trk.Params.AsLongDistance(s.Distance)*1000)/1000)
+		testutil.AssertEqual(t, len(data[i].Speed), 3)
+	}
+}
+
+func Test_ShellQuote(t *testing.T) {
+	testutil.AssertEqual(t, shellQuote("-wd"), "-wd")
+	testutil.AssertEqual(t, shellQuote("my track's.gpx"), `'my track'\''s.gpx'`)
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+)
+
+var (
+	upwindColor   = color.NRGBA{255, 0, 0, 255}
+	downwindColor = color.NRGBA{0, 0, 255, 255}
+	beamColor     = color.NRGBA{0, 128, 0, 255}
+)
+
+// renderImage rasterizes the map of the track the same way it is laid out in the SVG,
+// i.e. the legend at the top, the colored track and the timeline at the bottom.
+// The sizes of the map elements are scaled to the image size.
+func (m *Map) renderImage(t *track.Track, width, height int) *canvas {
+	cv := newCanvas(width, height)
+	cv.fill(white)
+	u := max(1, float64(min(width, height))/600) // scaled size of an SVG pixel
+
+	// legend, the labels may overflow into the following entries
+	lh := math.Round(20 * u)
+	cw := min(float64(m.colors.entryWidth())*u, float64(width)/float64(len(m.colors.Legend)))
+	for i, e := range m.colors.Legend {
+		for y := 0; y < int(lh); y++ {
+			for x := int(float64(i) * cw); x < int(float64(i+1)*cw); x++ {
+				cv.SetNRGBA(x, y, rgb12(e.Color))
+			}
+		}
+	}
+	s := max(1, int(math.Round(lh*0.55/fontHeight)))
+	for i, e := range m.colors.Legend {
+		c := black
+		if labelColor(e.Color) == "white" {
+			c = white
+		}
+		cv.text(int(float64(i)*cw+5*u), int(lh-fontHeight*float64(s))/2, e.Label, s, c)
+	}
+
+	// timeline
+	tlMargin, tlH := math.Round(20*u), math.Round(50*u)
+	tlTop := float64(height) - tlMargin - tlH
+	m.renderTimeline(cv, t, tlMargin, tlTop, float64(width)-2*tlMargin, tlH, u)
+
+	// map fitted between the legend and the timeline
+	top, bottom := lh+1, tlTop-10*u
+	k := min(float64(width)/m.w, (bottom-top)/m.h)
+	left, top := (float64(width)-m.w*k)/2, top+(bottom-top-m.h*k)/2
+	var prev *track.Point
+	for _, segment := range t.Segments {
+		for _, next := range segment.Points {
+			if prev != nil {
+				x1, y1 := m.project(prev.GPX.Latitude, prev.GPX.Longitude)
+				x2, y2 := m.project(next.GPX.Latitude, next.GPX.Longitude)
+				cv.line(left+x1*k, top+y1*k, left+x2*k, top+y2*k, 2*u, rgb12(m.colors.color(segment, next)))
+			}
+			prev = next
+		}
+	}
+	return cv
+}
+
+// renderTimeline draws the speed profile of the segments colored by wind attitude into the box,
+// with the start and end time of the track below it.
+func (m *Map) renderTimeline(cv *canvas, t *track.Track, x0, y0, w, h, u float64) {
+	if t.Duration <= 0 {
+		return
+	}
+	perPixel := t.Duration.Seconds() / w
+	bottom := y0 + h
+	for _, segment := range t.Segments {
+		c := beamColor
+		switch segment.WindAttitude() {
+		case track.Upwind:
+			c = upwindColor
+		case track.Downwind:
+			c = downwindColor
+		}
+		from := segment.Start.Sub(t.Start).Seconds()
+		to := from + segment.Duration.Seconds()
+		prevX, prevY := -1.0, 0.0
+		j := 0
+		for col := math.Ceil(from / perPixel); col <= to/perPixel; col++ {
+			// interpolate the speed at the column time between the surrounding points
+			at := segment.Start.Add(time.Duration(col * perPixel * float64(time.Second)))
+			for j < len(segment.Points)-2 && !segment.Points[j+1].GPX.Timestamp.After(at) {
+				j++
+			}
+			speed := segment.Points[j].Speed
+			if j+1 < len(segment.Points) {
+				p1, p2 := segment.Points[j], segment.Points[j+1]
+				if span := p2.GPX.Timestamp.Sub(p1.GPX.Timestamp); span > 0 {
+					f := max(0, min(1, at.Sub(p1.GPX.Timestamp).Seconds()/span.Seconds()))
+					speed = p1.Speed + (p2.Speed-p1.Speed)*f
+				}
+			}
+			x := x0 + col
+			y := bottom - min(speed, tlHeight/tlUnitHeight)*h/(tlHeight/tlUnitHeight)
+			for py := int(y); py < int(bottom); py++ {
+				cv.blend(int(x), py, c, 0.5)
+			}
+			if prevX >= 0 {
+				cv.line(prevX, prevY, x, y, u, c)
+			}
+			prevX, prevY = x, y
+		}
+	}
+	s := max(1, int(math.Round(8*u/fontHeight)))
+	tz := t.Timezone()
+	cv.text(int(x0), int(bottom+4*u), t.Start.In(tz).Format(time.TimeOnly), s, black)
+	end := t.End.In(tz).Format(time.TimeOnly)
+	cv.text(int(x0+w)-textWidth(end, s), int(bottom+4*u), end, s, black)
+}
@@ -0,0 +1,281 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// OverlayOptions control the size and timing of the overlay frames.
+type OverlayOptions struct {
+	Width, Height int           // frame size in pixels
+	FrameRate     int           // frames per second
+	Duration      time.Duration // length of the video, frames past it are dropped, zero means no limit
+	Map           *MapOptions   // speed coloring of the map matched by the overlay, nil means the default
+}
+
+var (
+	white = color.NRGBA{255, 255, 255, 255}
+	black = color.NRGBA{0, 0, 0, 255}
+	blue  = color.NRGBA{64, 160, 255, 255}
+)
+
+// WriteOverlayFrames renders a transparent PNG frame sequence with the stats of the track
+// (speedometer, compass, mini-map and segment type) that can be composited over the video.
+// The frames are numbered from the start of the video and saved into a directory named after the track.
+// Non-empty @video is the name of the video of a multi-video session that the frames are for.
+func WriteOverlayFrames(t *track.Track, dir, video string, offset time.Duration, opts *OverlayOptions) error {
+	frames := filepath.Join(dir, videoFileName(t, video)+"-overlay")
+	if err := os.MkdirAll(frames, 0755); err != nil {
+		return err
+	}
+	o := newOverlay(t, opts)
+	interval := time.Second / time.Duration(max(opts.FrameRate, 1))
+	// frames of the video before the start of the track are all the same
+	var blank bytes.Buffer
+	if err := png.Encode(&blank, image.NewNRGBA(image.Rect(0, 0, opts.Width, opts.Height))); err != nil {
+		return err
+	}
+	// frames are rendered and encoded in parallel, the cues are copied since eachCue reuses them
+	type job struct {
+		frame int
+		cue   cue
+	}
+	jobs := make(chan job)
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if err := writePNG(filepath.Join(frames, overlayFrameName(j.frame)), o.render(&j.cue).NRGBA); err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+				}
+			}
+		}()
+	}
+	var frame int
+	var err error
+	eachCue(t, offset, &SubtitleOptions{Interval: interval, Duration: opts.Duration, Map: opts.Map}, func(c *cue) {
+		for ; frame < int(c.Start/interval) && err == nil; frame++ {
+			err = os.WriteFile(filepath.Join(frames, overlayFrameName(frame)), blank.Bytes(), 0644)
+		}
+		if err == nil {
+			jobs <- job{frame, *c}
+			frame++
+		}
+	})
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	select {
+	case err = <-errs:
+	default:
+	}
+	return err
+}
+
+func overlayFrameName(frame int) string {
+	return fmt.Sprintf("%06d.png", frame)
+}
+
+// overlay renders the overlay frames of a track.
+type overlay struct {
+	track    *track.Track
+	w, h     int
+	scale    float64 // relative to 1080p
+	maxSpeed float64 // speedometer range
+	speed    *speedScale
+	points   []*gpx.GPXPoint
+	static   *canvas // the mini-map and dials shared by all frames
+	project  func(lat, lon float64) (x, y float64)
+	margin   float64
+	radius   float64 // of the dials
+}
+
+// speedometer and compass centers
+func (o *overlay) speedometerCenter() (x, y float64) {
+	return o.margin + o.radius, float64(o.h) - o.margin - o.radius
+}
+
+func (o *overlay) compassCenter() (x, y float64) {
+	return float64(o.w) - o.margin - o.radius, float64(o.h) - o.margin - o.radius
+}
+
+func newOverlay(t *track.Track, opts *OverlayOptions) *overlay {
+	o := &overlay{track: t, w: opts.Width, h: opts.Height, scale: float64(opts.Height) / 1080, points: t.GPXPoints(), speed: opts.Map.speedScale(t)}
+	o.margin, o.radius = o.px(40), o.px(150)
+	for _, s := range t.Segments {
+		o.maxSpeed = max(o.maxSpeed, s.Speed.Max)
+	}
+	o.maxSpeed = max(5, math.Ceil(o.maxSpeed/5)*5)
+
+	// mini-map in the top right corner
+	size, margin := o.px(360), o.margin
+	m := NewMap(t.GPX.Bounds(), t.Params.DistanceUnit)
+	k := min(size/m.w, size/m.h)
+	left := float64(o.w) - margin - m.w*k
+	o.project = func(lat, lon float64) (x, y float64) {
+		x, y = m.project(lat, lon)
+		return left + x*k, margin + y*k
+	}
+	o.static = newCanvas(o.w, o.h)
+	for x := int(left); x < int(left+m.w*k); x++ {
+		for y := int(margin); y < int(margin+m.h*k); y++ {
+			o.static.blend(x, y, black, 0.35)
+		}
+	}
+	var prev *track.Point
+	for _, s := range t.Segments {
+		for _, p := range s.Points {
+			if prev != nil {
+				x0, y0 := o.project(prev.GPX.Latitude, prev.GPX.Longitude)
+				x1, y1 := o.project(p.GPX.Latitude, p.GPX.Longitude)
+				o.static.line(x0, y0, x1, y1, o.px(3), rgb12(o.speed.color(p.Speed)))
+			}
+			prev = p
+		}
+	}
+	o.speedometerDial(o.static)
+	o.compassRose(o.static)
+	return o
+}
+
+// px scales the size in 1080p pixels to the frame size.
+func (o *overlay) px(v float64) float64 {
+	return v * o.scale
+}
+
+// textScale returns the font scale for the text height in 1080p pixels.
+func (o *overlay) textScale(height float64) int {
+	return max(1, int(math.Round(o.px(height)/fontHeight)))
+}
+
+// render draws the frame for the cue.
+func (o *overlay) render(c *cue) *canvas {
+	cv := &canvas{image.NewNRGBA(image.Rect(0, 0, o.w, o.h))}
+	copy(cv.Pix, o.static.Pix)
+	if lat, lon, ok := track.Interpolate(o.points, c.Time); ok {
+		x, y := o.project(lat, lon)
+		cv.disc(x, y, o.px(9), black)
+		cv.disc(x, y, o.px(6), white)
+	}
+	o.speedometer(cv, c)
+	o.compass(cv, c)
+
+	// segment type and time in the top left corner
+	s := o.textScale(42)
+	x, y := int(o.margin), int(o.margin)
+	cv.label(x, y, c.SegmentType(), s, -1, white)
+	y += (fontHeight + 4) * s
+	cv.label(x, y, c.Time.Format(time.TimeOnly), s, -1, white)
+	y += (fontHeight + 4) * s
+	cv.label(x, y, fmt.Sprintf("%.2f %s", c.LongDistance(), c.LongDistanceUnit()), s, -1, white)
+	return cv
+}
+
+// speedAngle returns the angle of the speedometer needle for the speed.
+func (o *overlay) speedAngle(speed float64) float64 {
+	const from, to = -120.0, 120.0
+	return from + (to-from)*min(speed, o.maxSpeed)/o.maxSpeed
+}
+
+// speedometerDial draws the speedometer dial with the speed range colored with the speed coloring of the map.
+func (o *overlay) speedometerDial(cv *canvas) {
+	cx, cy := o.speedometerCenter()
+	r := o.radius
+	cv.disc(cx, cy, r, withAlpha(black, 0.35))
+	step := min(1, o.speed.step)
+	for speed := 0.0; speed < o.maxSpeed; speed += step {
+		cv.arc(cx, cy, r-o.px(12), o.px(12), o.speedAngle(speed), o.speedAngle(speed+step), rgb12(o.speed.color(speed+step/2)))
+	}
+	s := o.textScale(21)
+	for speed := 0.0; speed <= o.maxSpeed; speed++ {
+		x0, y0 := polar(cx, cy, r-o.px(18), o.speedAngle(speed))
+		x1, y1 := polar(cx, cy, r-o.px(30), o.speedAngle(speed))
+		if int(speed)%5 == 0 {
+			cv.line(x0, y0, x1, y1, o.px(4), white)
+			x, y := polar(cx, cy, r-o.px(50), o.speedAngle(speed))
+			cv.label(int(x), int(y)-fontHeight*s/2, fmt.Sprint(speed), s, 0, white)
+		} else {
+			cv.line(x0, y0, x1, y1, o.px(2), white)
+		}
+	}
+}
+
+// speedometer draws the needle and the value of the speed.
+func (o *overlay) speedometer(cv *canvas, c *cue) {
+	cx, cy := o.speedometerCenter()
+	a := o.speedAngle(c.Speed)
+	tipX, tipY := polar(cx, cy, o.radius-o.px(20), a)
+	lx, ly := polar(cx, cy, o.px(10), a-90)
+	rx, ry := polar(cx, cy, o.px(10), a+90)
+	cv.polygon(rgb12(o.speed.color(c.Speed)), tipX, tipY, lx, ly, rx, ry)
+	cv.disc(cx, cy, o.px(12), white)
+
+	// value in the gap at the bottom of the dial
+	s := o.textScale(42)
+	y := int(cy + o.px(62))
+	cv.label(int(cx), y, fmt.Sprintf("%.1f", c.Speed), s, 0, white)
+	cv.label(int(cx), y+(fontHeight+2)*s, c.SpeedUnit(), o.textScale(21), 0, white)
+}
+
+// compassRose draws the compass dial and the wind direction if known.
+func (o *overlay) compassRose(cv *canvas) {
+	cx, cy := o.compassCenter()
+	r := o.radius
+	cv.disc(cx, cy, r, withAlpha(black, 0.35))
+	cv.arc(cx, cy, r-o.px(4), o.px(3), 0, 0, white)
+	for deg := 0; deg < 360; deg += 10 {
+		length := o.px(8)
+		if deg%90 == 0 {
+			length = o.px(16)
+		}
+		x0, y0 := polar(cx, cy, r-o.px(6), float64(deg))
+		x1, y1 := polar(cx, cy, r-o.px(6)-length, float64(deg))
+		cv.line(x0, y0, x1, y1, o.px(2), white)
+	}
+	s := o.textScale(28)
+	for i, name := range []string{"N", "E", "S", "W"} {
+		x, y := polar(cx, cy, r-o.px(42), float64(90*i))
+		cv.label(int(x), int(y)-fontHeight*s/2, name, s, 0, white)
+	}
+	if wind := o.track.Wind; wind != track.UNK {
+		// wind arrow at the rim pointing to the center from where the wind blows
+		a := float64(wind)
+		tipX, tipY := polar(cx, cy, r-o.px(30), a)
+		lx, ly := polar(cx, cy, r+o.px(6), a-8)
+		rx, ry := polar(cx, cy, r+o.px(6), a+8)
+		cv.polygon(blue, tipX, tipY, lx, ly, rx, ry)
+	}
+}
+
+// compass draws the heading arrow and the heading above the compass.
+func (o *overlay) compass(cv *canvas, c *cue) {
+	cx, cy := o.compassCenter()
+	a := float64(c.Heading)
+	tipX, tipY := polar(cx, cy, o.radius-o.px(60), a)
+	lx, ly := polar(cx, cy, o.px(40), a+150)
+	bx, by := polar(cx, cy, o.px(15), a+180)
+	rx, ry := polar(cx, cy, o.px(40), a-150)
+	cv.polygon(white, tipX, tipY, lx, ly, bx, by, rx, ry)
+	s := o.textScale(35)
+	cv.label(int(cx), int(cy-o.radius-o.px(10))-fontHeight*s, fmt.Sprintf("%d° %s", c.Heading, c.Direction()), s, 0, white)
+}
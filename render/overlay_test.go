@@ -0,0 +1,72 @@
+package render
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+)
+
+func Test_Canvas(t *testing.T) {
+	testutil.AssertEqual(t, rgb12(0xF80), rgb12(0xF80))
+	testutil.AssertEqual(t, rgb12(0xF80).R, uint8(255))
+	testutil.AssertEqual(t, rgb12(0xF80).G, uint8(136))
+	testutil.AssertEqual(t, rgb12(0xF80).B, uint8(0))
+	testutil.AssertEqual(t, textWidth("", 2), 0)
+	testutil.AssertEqual(t, textWidth("ab", 2), 22)
+
+	cv := newCanvas(20, 20)
+	cv.disc(10, 10, 5, white)
+	testutil.AssertEqual(t, cv.NRGBAAt(10, 10).A, uint8(255))
+	testutil.AssertEqual(t, cv.NRGBAAt(2, 2).A, uint8(0))
+	cv.line(0, 18.5, 20, 18.5, 1, black)
+	testutil.AssertEqual(t, cv.NRGBAAt(10, 18), black)
+	testutil.AssertEqual(t, cv.NRGBAAt(10, 16).A, uint8(0))
+}
+
+func Test_OverlayFrames(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	trk.Classify(track.N)
+	dir := t.TempDir()
+	opts := &OverlayOptions{Width: 320, Height: 180, FrameRate: 1, Duration: 40 * time.Second}
+	if err := WriteOverlayFrames(trk, dir, "", 10*time.Second, opts); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := filepath.Glob(filepath.Join(dir, trk.FileName()+"-overlay", "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(frames), 40)
+	testutil.AssertEqual(t, filepath.Base(frames[0]), "000000.png")
+	// the track starts 10s into the video, frames before it are blank
+	testutil.AssertEqual(t, overlayPixels(t, frames[9]), 0)
+	testutil.AssertEqual(t, overlayPixels(t, frames[10]) > 0, true)
+}
+
+// overlayPixels returns the number of non-transparent pixels of the frame.
+func overlayPixels(t *testing.T, fn string) (n int) {
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,212 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+)
+
+// canvas is a raster image with a few anti-aliased drawing primitives,
+// used to render PNG images without external dependencies.
+// Coordinates are in pixels with the origin in the top left corner,
+// angles are in degrees clockwise from the top (same as headings).
+type canvas struct {
+	*image.NRGBA
+}
+
+func newCanvas(w, h int) *canvas {
+	return &canvas{image.NewNRGBA(image.Rect(0, 0, w, h))}
+}
+
+// rgb12 converts a 12-bit RGB color code (see palette) into a color.
+func rgb12(c int) color.NRGBA {
+	return color.NRGBA{R: uint8((c >> 8 & 15) * 17), G: uint8((c >> 4 & 15) * 17), B: uint8((c & 15) * 17), A: 255}
+}
+
+// withAlpha returns the color with the alpha channel set to a (0-1).
+func withAlpha(c color.NRGBA, a float64) color.NRGBA {
+	c.A = uint8(math.Round(a * 255))
+	return c
+}
+
+// blend paints the color over the pixel with coverage a (0-1).
+func (cv *canvas) blend(x, y int, c color.NRGBA, a float64) {
+	if a <= 0 || !(image.Point{x, y}.In(cv.Rect)) {
+		return
+	}
+	i := cv.PixOffset(x, y)
+	d := cv.Pix[i : i+4 : i+4]
+	srcA := float64(c.A) / 255 * min(a, 1)
+	dstA := float64(d[3]) / 255
+	outA := srcA + dstA*(1-srcA)
+	if outA == 0 {
+		return
+	}
+	for j, v := range []uint8{c.R, c.G, c.B} {
+		d[j] = uint8(math.Round((float64(v)*srcA + float64(d[j])*dstA*(1-srcA)) / outA))
+	}
+	d[3] = uint8(math.Round(outA * 255))
+}
+
+// fill paints the whole canvas with the color.
+func (cv *canvas) fill(c color.NRGBA) {
+	for y := cv.Rect.Min.Y; y < cv.Rect.Max.Y; y++ {
+		for x := cv.Rect.Min.X; x < cv.Rect.Max.X; x++ {
+			cv.SetNRGBA(x, y, c)
+		}
+	}
+}
+
+// shade calls f for each pixel center in the box around the point extended by r,
+// painting the pixel with the coverage returned by f.
+func (cv *canvas) shade(x0, y0, x1, y1, r float64, c color.NRGBA, f func(x, y float64) float64) {
+	minX, maxX := int(math.Floor(min(x0, x1)-r)), int(math.Ceil(max(x0, x1)+r))
+	minY, maxY := int(math.Floor(min(y0, y1)-r)), int(math.Ceil(max(y0, y1)+r))
+	b := cv.Rect
+	for y := max(minY, b.Min.Y); y <= min(maxY, b.Max.Y-1); y++ {
+		for x := max(minX, b.Min.X); x <= min(maxX, b.Max.X-1); x++ {
+			cv.blend(x, y, c, f(float64(x)+0.5, float64(y)+0.5))
+		}
+	}
+}
+
+// coverage converts the distance of a pixel center from the edge of a shape into its coverage,
+// negative distance is inside the shape.
+func coverage(d float64) float64 {
+	return max(0, min(1, 0.5-d))
+}
+
+// line draws a line of the specified width with round caps.
+func (cv *canvas) line(x0, y0, x1, y1, width float64, c color.NRGBA) {
+	dx, dy := x1-x0, y1-y0
+	l2 := dx*dx + dy*dy
+	cv.shade(x0, y0, x1, y1, width/2+1, c, func(x, y float64) float64 {
+		t := 0.0
+		if l2 > 0 {
+			t = max(0, min(1, ((x-x0)*dx+(y-y0)*dy)/l2))
+		}
+		return coverage(math.Hypot(x-x0-t*dx, y-y0-t*dy) - width/2)
+	})
+}
+
+// disc draws a filled circle.
+func (cv *canvas) disc(cx, cy, r float64, c color.NRGBA) {
+	cv.shade(cx, cy, cx, cy, r+1, c, func(x, y float64) float64 {
+		return coverage(math.Hypot(x-cx, y-cy) - r)
+	})
+}
+
+// arc draws a circular arc of the specified width between the angles, a full circle if from == to.
+func (cv *canvas) arc(cx, cy, r, width, from, to float64, c color.NRGBA) {
+	sweep := math.Mod(to-from+720, 360)
+	cv.shade(cx, cy, cx, cy, r+width/2+1, c, func(x, y float64) float64 {
+		if sweep > 0 {
+			angle := math.Mod(math.Atan2(x-cx, cy-y)*180/math.Pi-from+720, 360)
+			if angle > sweep {
+				return 0
+			}
+		}
+		return coverage(math.Abs(math.Hypot(x-cx, y-cy)-r) - width/2)
+	})
+}
+
+// polar returns the point at the distance and angle from the center.
+func polar(cx, cy, r, angle float64) (x, y float64) {
+	rad := angle * math.Pi / 180
+	return cx + r*math.Sin(rad), cy - r*math.Cos(rad)
+}
+
+// polygon fills the polygon given as a list of x, y coordinates,
+// the edges are anti-aliased by 4x4 supersampling.
+func (cv *canvas) polygon(c color.NRGBA, xy ...float64) {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for i := 0; i < len(xy); i += 2 {
+		minX, maxX = min(minX, xy[i]), max(maxX, xy[i])
+		minY, maxY = min(minY, xy[i+1]), max(maxY, xy[i+1])
+	}
+	inside := func(x, y float64) bool {
+		in := false
+		for i, j := 0, len(xy)-2; i < len(xy); j, i = i, i+2 {
+			xi, yi, xj, yj := xy[i], xy[i+1], xy[j], xy[j+1]
+			if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+				in = !in
+			}
+		}
+		return in
+	}
+	cv.shade(minX, minY, maxX, maxY, 1, c, func(x, y float64) float64 {
+		n := 0
+		for sy := 0; sy < 4; sy++ {
+			for sx := 0; sx < 4; sx++ {
+				if inside(x-0.375+float64(sx)*0.25, y-0.375+float64(sy)*0.25) {
+					n++
+				}
+			}
+		}
+		return float64(n) / 16
+	})
+}
+
+// textWidth returns the width of the text in pixels drawn at the scale.
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (n*(fontWidth+1) - 1) * scale
+}
+
+// text draws the text with its top left corner at x, y, each font pixel is scale x scale pixels.
+func (cv *canvas) text(x, y int, s string, scale int, c color.NRGBA) {
+	for _, r := range s {
+		for row, bits := range glyph(r) {
+			for col, bit := range bits {
+				if bit != '#' {
+					continue
+				}
+				for py := 0; py < scale; py++ {
+					for px := 0; px < scale; px++ {
+						cv.blend(x+col*scale+px, y+row*scale+py, c, 1)
+					}
+				}
+			}
+		}
+		x += (fontWidth + 1) * scale
+	}
+}
+
+// label draws the text with a dark shadow so that it is readable over any background,
+// align is -1 for left, 0 for center and 1 for right alignment to x.
+func (cv *canvas) label(x, y int, s string, scale, align int, c color.NRGBA) {
+	x -= (align + 1) * textWidth(s, scale) / 2
+	shadow := max(1, scale/2)
+	cv.text(x+shadow, y+shadow, s, scale, color.NRGBA{A: c.A / 2})
+	cv.text(x, y, s, scale, c)
+}
+
+// writePNG saves the image as a PNG file, favouring speed over size since frame sequences can have thousands of images.
+func writePNG(fn string, img image.Image) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(w, img); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ParseSize parses image size in the form WIDTHxHEIGHT, e.g. 1920x1080.
+func ParseSize(s string) (w, h int, err error) {
+	if _, err := fmt.Sscanf(s, "%dx%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %s, expected WIDTHxHEIGHT, e.g. 1920x1080", s)
+	}
+	return w, h, nil
+}
@@ -0,0 +1,108 @@
+// Package render renders the analyzed tracks as HTML maps and reports, map images,
+// video subtitles, chapters and overlays.
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+)
+
+// WriteMapFile generates an SVG map of the track into the specified directory.
+func WriteMapFile(t *track.Track, dir string, opts *MapOptions) error {
+	m, err := newMap(t, opts)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, t.FileName()+".svg"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m.render(f, t)
+	return nil
+}
+
+// WriteReportFile generates a self-contained HTML report of the track with the map,
+// summary stats, charts and segment table into the specified directory.
+func WriteReportFile(t *track.Track, dir string, opts *MapOptions) error {
+	m, err := newMap(t, opts)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, t.FileName()+".html"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m.renderReport(f, t)
+	return nil
+}
+
+// newMap creates the map of the track with the coloring, the background tiles and the command line from the options.
+func newMap(t *track.Track, opts *MapOptions) (*Map, error) {
+	m := NewMap(t.GPX.Bounds(), t.Params.DistanceUnit)
+	m.setColoring(t, opts)
+	m.command, m.inputs = opts.Command, opts.Inputs
+	if opts.Background != nil {
+		tiles, err := m.backgroundTiles(opts.Background)
+		if err != nil {
+			return nil, fmt.Errorf("map background: %w", err)
+		}
+		m.tiles = tiles
+	}
+	return m, nil
+}
+
+// WriteMapImage renders the map of the track into a PNG image of the specified size in the specified directory.
+// The background tiles are not rendered in the image.
+func WriteMapImage(t *track.Track, dir string, width, height int, opts *MapOptions) error {
+	m := NewMap(t.GPX.Bounds(), t.Params.DistanceUnit)
+	m.setColoring(t, opts)
+	return writePNG(filepath.Join(dir, t.FileName()+".png"), m.renderImage(t, width, height).NRGBA)
+}
+
+// WriteSubtitleFile generates a video subtitles file with the stats in the specified format.
+// Non-empty @video is the name of the video of a multi-video session that the file is for.
+func WriteSubtitleFile(t *track.Track, dir, video string, offset time.Duration, format string, opts *SubtitleOptions) error {
+	render, ok := SubtitleFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown subtitle format %s", format)
+	}
+	f, err := os.Create(filepath.Join(dir, videoFileName(t, video)+"."+format))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	render(t, f, offset, opts)
+	return nil
+}
+
+// WriteChapterFile generates a video chapters file in the specified format
+// that defines a chapter for each segment of the track.
+// Non-empty @video is the name of the video of a multi-video session that the file is for.
+func WriteChapterFile(t *track.Track, dir, video string, offset time.Duration, format string, opts *ChapterOptions) error {
+	cf, ok := ChapterFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown chapter format %s", format)
+	}
+	f, err := os.Create(filepath.Join(dir, videoFileName(t, video)+"."+cf.Extension))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cf.Render(t, f, offset, opts)
+	return nil
+}
+
+// videoFileName generates a file name for the video files of the track,
+// including the video name if non-empty.
+func videoFileName(t *track.Track, video string) string {
+	if video == "" {
+		return t.FileName()
+	}
+	return t.FileName() + "-" + video
+}
@@ -0,0 +1,283 @@
+// Generated by ego.
+// DO NOT EDIT
+
+//line report.ego:1
+
+package render
+
+import "fmt"
+import "html"
+import "io"
+import "context"
+
+import "time"
+import "strconv"
+import "github.com/mkobetic/gpx/track"
+
+func (m *Map) renderReport(w io.Writer, t *track.Track) {
+	stats := newReportStats(t)
+	tz := t.Timezone()
+	title := t.FileName()
+
+//line report.ego:13
+	_, _ = io.WriteString(w, "\n<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n<title>")
+//line report.ego:18
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(title)))
+//line report.ego:18
+	_, _ = io.WriteString(w, "</title>\n<style>\n")
+//line report.ego:20
+	_, _ = fmt.Fprint(w, ReportCSS)
+//line report.ego:21
+	_, _ = io.WriteString(w, "\n</style>\n</head>\n<body>\n<h1>")
+//line report.ego:24
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(title)))
+//line report.ego:24
+	_, _ = io.WriteString(w, "</h1>\n<table id=\"summary\">\n    <tr><th>Start</th><td>")
+//line report.ego:26
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Start.In(tz).Format(time.DateTime))))
+//line report.ego:26
+	_, _ = io.WriteString(w, "</td></tr>\n    <tr><th>Duration</th><td>")
+//line report.ego:27
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(HMS(stats.Duration))))
+//line report.ego:27
+	_, _ = io.WriteString(w, "</td></tr>\n    <tr><th>Distance</th><td>")
+//line report.ego:28
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%.2f %s", stats.Distance, t.Params.LongDistanceLabel()))))
+//line report.ego:28
+	_, _ = io.WriteString(w, "</td></tr>\n    <tr><th>Speed (avg/max)</th><td>")
+//line report.ego:29
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%.1f / %.1f %s", stats.AvgSpeed, stats.MaxSpeed, t.Params.SpeedLabel()))))
+//line report.ego:29
+	_, _ = io.WriteString(w, "</td></tr>\n    ")
+//line report.ego:30
+	if t.Wind == track.UNK {
+//line report.ego:31
+		_, _ = io.WriteString(w, "\n    <tr><th>Wind</th><td>unknown</td></tr>\n    <tr><th>Turns over ")
+//line report.ego:32
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(track.ManeuverTurn)))
+//line report.ego:32
+		_, _ = io.WriteString(w, "&deg;</th><td>")
+//line report.ego:32
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(stats.Maneuvers)))
+//line report.ego:32
+		_, _ = io.WriteString(w, "</td></tr>\n    ")
+//line report.ego:33
+	} else {
+//line report.ego:34
+		_, _ = io.WriteString(w, "\n    <tr><th>Wind</th><td>")
+//line report.ego:34
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Wind.String())))
+//line report.ego:34
+		_, _ = io.WriteString(w, "</td></tr>\n    <tr><th>Tacks</th><td>")
+//line report.ego:35
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(stats.Tacks)))
+//line report.ego:35
+		_, _ = io.WriteString(w, "</td></tr>\n    <tr><th>Gybes</th><td>")
+//line report.ego:36
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(stats.Gybes)))
+//line report.ego:36
+		_, _ = io.WriteString(w, "</td></tr>\n    ")
+//line report.ego:37
+	}
+//line report.ego:38
+	_, _ = io.WriteString(w, "\n    ")
+//line report.ego:38
+	for _, m := range t.Metrics() {
+//line report.ego:39
+		_, _ = io.WriteString(w, "\n    <tr><th>")
+//line report.ego:39
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.Name)))
+//line report.ego:39
+		_, _ = io.WriteString(w, "</th><td>")
+//line report.ego:39
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(m.Value)))
+//line report.ego:39
+		_, _ = io.WriteString(w, "</td></tr>\n    ")
+//line report.ego:40
+	}
+//line report.ego:41
+	_, _ = io.WriteString(w, "\n    <tr><th>Segments</th><td>")
+//line report.ego:41
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(len(t.Segments))))
+//line report.ego:41
+	_, _ = io.WriteString(w, "</td></tr>\n</table>\n\n<h2>Map</h2>\n<div id=\"map-container\">\n")
+//line report.ego:46
+	m.render(w, t)
+//line report.ego:47
+	_, _ = io.WriteString(w, "\n</div>\n\n")
+//line report.ego:49
+	for _, chart := range []struct {
+		name, unit string
+		c          *reportChart
+	}{
+		{"Speed", t.Params.SpeedLabel(), speedChart(t, stats.MaxSpeed)},
+		{"Heading", "°", headingChart(t)},
+	} {
+
+//line report.ego:54
+		_, _ = io.WriteString(w, "\n<h2>")
+//line report.ego:54
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chart.name)))
+//line report.ego:54
+		_, _ = io.WriteString(w, "</h2>\n<svg class=\"chart\" viewBox=\"-40 -10 ")
+//line report.ego:55
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chartWidth+50)))
+//line report.ego:55
+		_, _ = io.WriteString(w, " ")
+//line report.ego:55
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chartHeight+30)))
+//line report.ego:55
+		_, _ = io.WriteString(w, "\">\n    ")
+//line report.ego:56
+		for _, tick := range chart.c.Ticks {
+//line report.ego:57
+			_, _ = io.WriteString(w, "\n    <line class=\"grid\" x1=\"0\" y1=\"")
+//line report.ego:57
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chart.c.Y(tick))))
+//line report.ego:57
+			_, _ = io.WriteString(w, "\" x2=\"")
+//line report.ego:57
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chartWidth)))
+//line report.ego:57
+			_, _ = io.WriteString(w, "\" y2=\"")
+//line report.ego:57
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chart.c.Y(tick))))
+//line report.ego:57
+			_, _ = io.WriteString(w, "\"/>\n    <text class=\"tick\" x=\"-5\" y=\"")
+//line report.ego:58
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chart.c.Y(tick)+4)))
+//line report.ego:58
+			_, _ = io.WriteString(w, "\" text-anchor=\"end\">")
+//line report.ego:58
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(tick)))
+//line report.ego:58
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chart.unit)))
+//line report.ego:58
+			_, _ = io.WriteString(w, "</text>\n    ")
+//line report.ego:59
+		}
+//line report.ego:60
+		_, _ = io.WriteString(w, "\n    <path class=\"line\" d=\"")
+//line report.ego:60
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chart.c.Path)))
+//line report.ego:60
+		_, _ = io.WriteString(w, "\"/>\n    <text class=\"tick\" x=\"0\" y=\"")
+//line report.ego:61
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chartHeight+16)))
+//line report.ego:61
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:61
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Start.In(tz).Format(time.TimeOnly))))
+//line report.ego:61
+		_, _ = io.WriteString(w, "</text>\n    <text class=\"tick\" x=\"")
+//line report.ego:62
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chartWidth)))
+//line report.ego:62
+		_, _ = io.WriteString(w, "\" y=\"")
+//line report.ego:62
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(chartHeight+16)))
+//line report.ego:62
+		_, _ = io.WriteString(w, "\" text-anchor=\"end\">")
+//line report.ego:62
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.End.In(tz).Format(time.TimeOnly))))
+//line report.ego:62
+		_, _ = io.WriteString(w, "</text>\n</svg>\n")
+//line report.ego:64
+	}
+//line report.ego:65
+	_, _ = io.WriteString(w, "\n\n<h2>Segments</h2>\n<table id=\"segments\">\n    <thead>\n    <tr>\n        <th>#</th>\n        <th>Start</th>\n        <th>Type</th>\n        <th>Duration</th>\n        <th>Distance (")
+//line report.ego:74
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Params.DistanceLabel())))
+//line report.ego:74
+	_, _ = io.WriteString(w, ")</th>\n        <th>Avg speed (")
+//line report.ego:75
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Params.SpeedLabel())))
+//line report.ego:75
+	_, _ = io.WriteString(w, ")</th>\n        <th>Max speed (")
+//line report.ego:76
+	_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(t.Params.SpeedLabel())))
+//line report.ego:76
+	_, _ = io.WriteString(w, ")</th>\n        <th>Heading</th>\n    </tr>\n    </thead>\n    <tbody>\n    ")
+//line report.ego:81
+	for i, s := range t.Segments {
+//line report.ego:82
+		_, _ = io.WriteString(w, "\n    <tr data-segment=\"s")
+//line report.ego:82
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(strconv.Itoa(i))))
+//line report.ego:82
+		_, _ = io.WriteString(w, "\">\n        <td data-sort=\"")
+//line report.ego:83
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(i)))
+//line report.ego:83
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:83
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(i+1)))
+//line report.ego:83
+		_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line report.ego:84
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Start.Unix())))
+//line report.ego:84
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:84
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Start.In(tz).Format(time.TimeOnly))))
+//line report.ego:84
+		_, _ = io.WriteString(w, "</td>\n        <td>")
+//line report.ego:85
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.TypeKey())))
+//line report.ego:85
+		_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line report.ego:86
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Duration.Seconds())))
+//line report.ego:86
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:86
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(HMS(s.Duration))))
+//line report.ego:86
+		_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line report.ego:87
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Distance)))
+//line report.ego:87
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:87
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%.0f", s.Distance))))
+//line report.ego:87
+		_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line report.ego:88
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Speed.Avg)))
+//line report.ego:88
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:88
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%.1f", s.Speed.Avg))))
+//line report.ego:88
+		_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line report.ego:89
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Speed.Max)))
+//line report.ego:89
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:89
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%.1f", s.Speed.Max))))
+//line report.ego:89
+		_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line report.ego:90
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Heading.Mid)))
+//line report.ego:90
+		_, _ = io.WriteString(w, "\">")
+//line report.ego:90
+		_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%d°-%d°", s.Heading.Min, s.Heading.Max))))
+//line report.ego:90
+		_, _ = io.WriteString(w, "</td>\n    </tr>\n    ")
+//line report.ego:92
+	}
+//line report.ego:93
+	_, _ = io.WriteString(w, "\n    </tbody>\n</table>\n<script>\n")
+//line report.ego:96
+	_, _ = fmt.Fprint(w, ReportScript)
+//line report.ego:97
+	_, _ = io.WriteString(w, "\n</script>\n</body>\n</html>\n")
+//line report.ego:100
+}
+
+var _ fmt.Stringer
+var _ io.Reader
+var _ context.Context
+var _ = html.EscapeString
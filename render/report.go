@@ -0,0 +1,102 @@
+package render
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+)
+
+//go:embed report.css
+var ReportCSS string
+
+//go:embed report.js
+var ReportScript string
+
+// reportStats are the track totals shown in the summary of the HTML report.
+type reportStats struct {
+	Distance     float64 // in long distance units
+	Duration     time.Duration
+	AvgSpeed     float64
+	MaxSpeed     float64
+	Tacks, Gybes int
+	Maneuvers    int // turns of at least ManeuverTurn degrees, used when the point of sail is unknown
+}
+
+func newReportStats(t *track.Track) *reportStats {
+	rs := &reportStats{Distance: t.Params.AsLongDistance(t.Distance), Duration: t.Duration}
+	var speed float64
+	var moving time.Duration
+	for _, s := range t.Segments {
+		speed += s.Speed.Avg * float64(s.Duration)
+		moving += s.Duration
+		rs.MaxSpeed = max(rs.MaxSpeed, s.Speed.Max)
+		if s.IsManeuver() {
+			rs.Maneuvers++
+		}
+		switch s.ManeuverKind() {
+		case "tack":
+			rs.Tacks++
+		case "gybe":
+			rs.Gybes++
+		}
+	}
+	if moving > 0 {
+		rs.AvgSpeed = speed / float64(moving)
+	}
+	return rs
+}
+
+const chartWidth, chartHeight = 1000, 200 // chart plot area in SVG coordinates
+
+// reportChart is a line chart of a point metric over the duration of the track,
+// rendered as inline SVG in the HTML report.
+type reportChart struct {
+	Path  string    // SVG path of the metric values
+	Max   float64   // top of the value axis
+	Ticks []float64 // values of the horizontal grid lines
+}
+
+// newReportChart plots the values of all the track points on the value axis from 0 to @max with grid lines every @step.
+// If @wraps is set, the line is broken where the value wraps around the axis (e.g. heading from 350 to 10).
+func newReportChart(t *track.Track, maxValue, step float64, wraps bool, value func(p *track.Point) float64) *reportChart {
+	c := &reportChart{Max: maxValue}
+	for v := 0.0; v <= maxValue; v += step {
+		c.Ticks = append(c.Ticks, v)
+	}
+	if t.Duration <= 0 {
+		return c
+	}
+	var path strings.Builder
+	prev := math.NaN()
+	for _, s := range t.Segments {
+		for _, p := range s.Points {
+			v := value(p)
+			op := "L"
+			if math.IsNaN(prev) || (wraps && math.Abs(v-prev) > maxValue/2) {
+				op = "M"
+			}
+			x := p.GPX.Timestamp.Sub(t.Start).Seconds() / t.Duration.Seconds() * chartWidth
+			fmt.Fprintf(&path, "%s%.1f,%.1f", op, x, c.Y(v))
+			prev = v
+		}
+	}
+	c.Path = path.String()
+	return c
+}
+
+// Y returns the vertical SVG coordinate of the value.
+func (c *reportChart) Y(v float64) float64 {
+	return chartHeight - min(v, c.Max)/c.Max*chartHeight
+}
+
+func speedChart(t *track.Track, maxSpeed float64) *reportChart {
+	return newReportChart(t, max(5, math.Ceil(maxSpeed/5)*5), 5, false, func(p *track.Point) float64 { return p.Speed })
+}
+
+func headingChart(t *track.Track) *reportChart {
+	return newReportChart(t, 360, 90, true, func(p *track.Point) float64 { return float64(p.Heading) })
+}
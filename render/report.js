@@ -0,0 +1,38 @@
+// Report page behaviour, the map behaviour comes from map.js embedded in the map SVG.
+(function () {
+    const segments = document.querySelector('table#segments');
+
+    // sortable segment table, clicking a column header sorts by the column, clicking again reverses the order
+    function sortValue(cell) {
+        const value = cell.dataset.sort ?? cell.textContent;
+        const number = Number(value);
+        return isNaN(number) ? value : number;
+    }
+
+    segments.querySelectorAll('th').forEach((th, column) => {
+        th.addEventListener('click', () => {
+            const ascending = th.dataset.order !== 'asc';
+            segments.querySelectorAll('th').forEach(h => delete h.dataset.order);
+            th.dataset.order = ascending ? 'asc' : 'desc';
+            const body = segments.tBodies[0];
+            const rows = Array.from(body.rows);
+            rows.sort((a, b) => {
+                const va = sortValue(a.cells[column]);
+                const vb = sortValue(b.cells[column]);
+                const order = va < vb ? -1 : va > vb ? 1 : 0;
+                return ascending ? order : -order;
+            });
+            rows.forEach(row => body.appendChild(row));
+        });
+    });
+
+    // hovering over a segment row highlights the segment in the map
+    function mapSegment(event) {
+        const row = event.target.closest('tr[data-segment]');
+        if (!row) return undefined;
+        return document.querySelector(`svg#map g#${row.dataset.segment}.segment`);
+    }
+
+    segments.addEventListener('mouseover', event => mapSegment(event)?.classList.add('segment-hovered'));
+    segments.addEventListener('mouseout', event => mapSegment(event)?.classList.remove('segment-hovered'));
+})();
@@ -0,0 +1,40 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+)
+
+func Test_Report(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	trk.Classify(track.N)
+	stats := newReportStats(trk)
+	testutil.AssertEqual(t, stats.Tacks+stats.Gybes <= stats.Maneuvers, true)
+	testutil.AssertEqual(t, stats.MaxSpeed >= stats.AvgSpeed, true)
+
+	var b bytes.Buffer
+	m, _ := newMap(trk, &MapOptions{})
+	m.renderReport(&b, trk)
+	html := b.String()
+	testutil.AssertEqual(t, strings.Count(html, "<tr data-segment="), len(trk.Segments))
+	testutil.AssertEqual(t, strings.Count(html, `<svg class="chart"`), 2)
+	testutil.AssertEqual(t, strings.Contains(html, `<svg version="1.1"`), true)
+	testutil.AssertEqual(t, strings.Contains(html, "<th>Wind</th><td>N</td>"), true)
+}
+
+func Test_ReportChart(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	c := headingChart(trk)
+	testutil.AssertEqual(t, fmt.Sprint(c.Ticks), "[0 90 180 270 360]")
+	testutil.AssertEqual(t, c.Y(0), float64(chartHeight))
+	testutil.AssertEqual(t, c.Y(360), 0.0)
+	testutil.AssertEqual(t, strings.HasPrefix(c.Path, "M0.0,"), true)
+}
@@ -0,0 +1,398 @@
+package render
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sqliteDB is a minimal read-only reader of SQLite database files, just enough to read MBTiles files
+// without a database driver. It can scan the rows of ordinary (rowid) tables, indexes and WITHOUT ROWID tables are not supported.
+// See https://www.sqlite.org/fileformat.html
+type sqliteDB struct {
+	f        *os.File
+	pageSize int
+	usable   int // page size minus the reserved space at the end of each page
+}
+
+// sqliteTable describes a table or a view from the sqlite_master table.
+type sqliteTable struct {
+	Type    string // table or view
+	Name    string
+	Root    int // root page of the table b-tree
+	SQL     string
+	Columns []string // lower cased column names of a table
+}
+
+// openSQLite opens the database file for reading. Only a subset of SQLite is supported, enough for the MBTiles files:
+//   - read-only, the database must not be written to while it is read,
+//   - the rows of ordinary (rowid) tables, views are only listed (see sqliteTable), not evaluated,
+//     the MBTiles reader resolves the usual tiles view over the map and images tables itself,
+//   - the database file alone, the changes in a write-ahead log (the -wal file) that wasn't checkpointed are not seen,
+//     so a database with a non-empty -wal file is rejected (checkpoint it with sqlite3 FILE 'PRAGMA wal_checkpoint(TRUNCATE)').
+//
+// A malformed database fails with errSQLiteCorrupt, e.g. an invalid page size in the header.
+// A database in the WAL journal mode is fine once checkpointed, SQLite checkpoints it when the last connection closes.
+func openSQLite(fn string) (*sqliteDB, error) {
+	if fi, err := os.Stat(fn + "-wal"); err == nil && fi.Size() > 0 {
+		return nil, fmt.Errorf("%s has un-checkpointed changes in %s-wal, checkpoint it first", fn, filepath.Base(fn))
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	var hdr [100]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil || string(hdr[:16]) != "SQLite format 3\x00" {
+		f.Close()
+		return nil, fmt.Errorf("%s is not an SQLite database", fn)
+	}
+	db := &sqliteDB{f: f, pageSize: int(binary.BigEndian.Uint16(hdr[16:]))}
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	db.usable = db.pageSize - int(hdr[20])
+	// the page size is a power of two between 512 and 65536, the reserved space leaves at least 480 usable bytes
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 || db.usable < 480 {
+		f.Close()
+		return nil, fmt.Errorf("%s: invalid page size %d with %d reserved bytes: %w", fn, db.pageSize, hdr[20], errSQLiteCorrupt)
+	}
+	return db, nil
+}
+
+func (db *sqliteDB) Close() error {
+	return db.f.Close()
+}
+
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid page number %d", n)
+	}
+	p := make([]byte, db.pageSize)
+	if _, err := db.f.ReadAt(p, int64(n-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("reading page %d: %w", n, err)
+	}
+	return p, nil
+}
+
+// tables returns the tables and views of the database.
+func (db *sqliteDB) tables() (tables []*sqliteTable, err error) {
+	err = db.scan(1, func(_ int64, r []any) error {
+		if len(r) < 5 {
+			return nil
+		}
+		t := &sqliteTable{}
+		t.Type, _ = r[0].(string)
+		t.Name, _ = r[1].(string)
+		root, _ := r[3].(int64)
+		t.Root = int(root)
+		t.SQL, _ = r[4].(string)
+		if t.Type == "table" {
+			t.Columns = sqliteColumns(t.SQL)
+		}
+		tables = append(tables, t)
+		return nil
+	})
+	return tables, err
+}
+
+// table returns the table or view with the name, nil if there is no such table.
+func (db *sqliteDB) table(name string) (*sqliteTable, error) {
+	tables, err := db.tables()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tables {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+var sqliteColumnConstraint = regexp.MustCompile(`(?i)^(constraint|primary|unique|check|foreign)\b`)
+
+// sqliteColumns extracts the column names from the CREATE TABLE statement.
+func sqliteColumns(sql string) (columns []string) {
+	open, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if open < 0 || end < open {
+		return nil
+	}
+	// split the column definitions on top level commas
+	var defs []string
+	depth, start := 0, open+1
+	for i := start; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[start:i])
+				start = i + 1
+			}
+		}
+	}
+	defs = append(defs, sql[start:end])
+	for _, def := range defs {
+		def = strings.TrimSpace(def)
+		if def == "" || sqliteColumnConstraint.MatchString(def) {
+			continue
+		}
+		name, _, _ := strings.Cut(def, " ")
+		columns = append(columns, strings.ToLower(strings.Trim(name, "\"'`[]")))
+	}
+	return columns
+}
+
+// column returns the position of the column in the table rows, -1 if there is no such column.
+func (t *sqliteTable) column(name string) int {
+	for i, c := range t.Columns {
+		if c == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// scan calls f with the rowid and the values of each row of the table b-tree rooted at the page.
+// The values are nil, int64, float64, string or []byte.
+func (db *sqliteDB) scan(root int, f func(rowid int64, row []any) error) error {
+	return db.scanPage(root, f, false, 0)
+}
+
+// scanLocal is like scan but it reads only the part of the rows stored in the b-tree pages,
+// skipping the overflow pages of large rows. The values that are not stored locally are nil.
+func (db *sqliteDB) scanLocal(root int, f func(rowid int64, row []any) error) error {
+	return db.scanPage(root, f, true, 0)
+}
+
+var errSQLiteCorrupt = errors.New("corrupt SQLite database")
+
+// btreePage reads the b-tree page, returning the page, its header and the number of cells.
+func (db *sqliteDB) btreePage(n int) (p, h []byte, cells int, err error) {
+	if p, err = db.page(n); err != nil {
+		return nil, nil, 0, err
+	}
+	h = p
+	if n == 1 {
+		h = p[100:] // database header
+	}
+	cells = int(binary.BigEndian.Uint16(h[3:]))
+	if end := 12 + 2*cells; end > len(h) || h[0] != 0x05 && h[0] != 0x0d {
+		return nil, nil, 0, fmt.Errorf("page %d is not a table page: %w", n, errSQLiteCorrupt)
+	}
+	return p, h, cells, nil
+}
+
+// child returns the left child page and the largest rowid in it of the i-th cell of an interior page.
+func (db *sqliteDB) child(p, h []byte, i int) (page int, key int64, err error) {
+	off := int(binary.BigEndian.Uint16(h[12+2*i:]))
+	if off+4 >= len(p) {
+		return 0, 0, errSQLiteCorrupt
+	}
+	k, _ := sqliteVarint(p[off+4:])
+	return int(binary.BigEndian.Uint32(p[off:])), int64(k), nil
+}
+
+func (db *sqliteDB) scanPage(n int, f func(int64, []any) error, local bool, depth int) error {
+	if depth > 64 {
+		return errSQLiteCorrupt
+	}
+	p, h, cells, err := db.btreePage(n)
+	if err != nil {
+		return err
+	}
+	if h[0] == 0x05 { // interior table page
+		for i := 0; i < cells; i++ {
+			page, _, err := db.child(p, h, i)
+			if err != nil {
+				return err
+			}
+			if err := db.scanPage(page, f, local, depth+1); err != nil {
+				return err
+			}
+		}
+		return db.scanPage(int(binary.BigEndian.Uint32(h[8:])), f, local, depth+1)
+	}
+	// leaf table page
+	for i := 0; i < cells; i++ {
+		rowid, row, err := db.cell(p, int(binary.BigEndian.Uint16(h[8+2*i:])), local)
+		if err != nil {
+			return err
+		}
+		if err := f(rowid, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// row returns the values of the row with the rowid in the table b-tree rooted at the page, nil if there is no such row.
+func (db *sqliteDB) row(root int, rowid int64) ([]any, error) {
+	n := root
+	for depth := 0; depth <= 64; depth++ {
+		p, h, cells, err := db.btreePage(n)
+		if err != nil {
+			return nil, err
+		}
+		if h[0] == 0x0d {
+			for i := 0; i < cells; i++ {
+				id, row, err := db.cell(p, int(binary.BigEndian.Uint16(h[8+2*i:])), false)
+				if err != nil || id == rowid {
+					return row, err
+				}
+			}
+			return nil, nil
+		}
+		n = int(binary.BigEndian.Uint32(h[8:]))
+		for i := 0; i < cells; i++ {
+			page, key, err := db.child(p, h, i)
+			if err != nil {
+				return nil, err
+			}
+			if rowid <= key {
+				n = page
+				break
+			}
+		}
+	}
+	return nil, errSQLiteCorrupt
+}
+
+// cell reads the leaf table cell at the offset of the page, following the overflow pages of large payloads unless @local is set.
+func (db *sqliteDB) cell(p []byte, off int, local bool) (int64, []any, error) {
+	if off >= len(p) {
+		return 0, nil, errSQLiteCorrupt
+	}
+	size, n := sqliteVarint(p[off:])
+	off += n
+	// a payload can't be larger than the largest database
+	if off >= len(p) || size > math.MaxInt32 {
+		return 0, nil, errSQLiteCorrupt
+	}
+	rowid, n := sqliteVarint(p[off:])
+	off += n
+	if off >= len(p) {
+		return 0, nil, errSQLiteCorrupt
+	}
+	u, x := db.usable, db.usable-35
+	stored := int(size)
+	if stored > x {
+		m := (u-12)*32/255 - 23
+		stored = m + (int(size)-m)%(u-4)
+		if stored > x {
+			stored = m
+		}
+	}
+	if off+stored > len(p) {
+		return 0, nil, errSQLiteCorrupt
+	}
+	payload := append([]byte(nil), p[off:off+stored]...)
+	if stored < int(size) && !local {
+		if off+stored+4 > len(p) {
+			return 0, nil, errSQLiteCorrupt
+		}
+		next := int(binary.BigEndian.Uint32(p[off+stored:]))
+		for len(payload) < int(size) {
+			op, err := db.page(next)
+			if err != nil {
+				return 0, nil, err
+			}
+			next = int(binary.BigEndian.Uint32(op))
+			payload = append(payload, op[4:min(u, 4+int(size)-len(payload))]...)
+		}
+	}
+	row, err := sqliteRecord(payload, local)
+	return int64(rowid), row, err
+}
+
+// sqliteRecord decodes the values of the record, the values past the end of a @truncated record are nil.
+func sqliteRecord(b []byte, truncated bool) ([]any, error) {
+	hsize, n := sqliteVarint(b)
+	if hsize > uint64(len(b)) {
+		return nil, errSQLiteCorrupt
+	}
+	var row []any
+	data := int(hsize)
+	for off := n; off < int(hsize); {
+		st, n := sqliteVarint(b[off:])
+		off += n
+		size := sqliteSerialSize(st)
+		if size < 0 {
+			return nil, errSQLiteCorrupt
+		}
+		if size > len(b)-data {
+			if truncated {
+				// the following values are past the end too
+				row = append(row, nil)
+				data = len(b) + 1
+				continue
+			}
+			return nil, errSQLiteCorrupt
+		}
+		v := b[data : data+size]
+		switch {
+		case st == 0:
+			row = append(row, nil)
+		case st <= 6:
+			// big-endian two's complement integer of 1, 2, 3, 4, 6 or 8 bytes
+			i := int64(int8(v[0]))
+			for _, c := range v[1:] {
+				i = i<<8 | int64(c)
+			}
+			row = append(row, i)
+		case st == 7:
+			row = append(row, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case st == 8:
+			row = append(row, int64(0))
+		case st == 9:
+			row = append(row, int64(1))
+		case st >= 12 && st%2 == 0:
+			row = append(row, append([]byte(nil), v...))
+		case st >= 13:
+			row = append(row, string(v))
+		default:
+			return nil, errSQLiteCorrupt
+		}
+		data += size
+	}
+	return row, nil
+}
+
+func sqliteSerialSize(st uint64) int {
+	switch {
+	case st <= 4:
+		return int(st)
+	case st == 5:
+		return 6
+	case st <= 7:
+		return 8
+	case st <= 11:
+		return 0
+	default:
+		return int((st - 12) / 2)
+	}
+}
+
+// sqliteVarint decodes a big-endian variable length integer of 1 to 9 bytes,
+// returns the value and the number of bytes read.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, len(b)
+}
@@ -0,0 +1,339 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mkobetic/gpx/track"
+)
+
+// SubtitleFormat renders subtitles for a track into the writer.
+type SubtitleFormat func(t *track.Track, w io.Writer, videoOffset time.Duration, opts *SubtitleOptions)
+
+// SubtitleFormats maps file extensions to the corresponding subtitle renderers.
+var SubtitleFormats = map[string]SubtitleFormat{
+	"vtt": renderSubtitles,
+	"srt": renderSrtSubtitles,
+	"ass": renderAssSubtitles,
+}
+
+var KnownSubtitleFormats []string
+
+func init() {
+	for f := range SubtitleFormats {
+		KnownSubtitleFormats = append(KnownSubtitleFormats, f)
+	}
+	sort.Strings(KnownSubtitleFormats)
+}
+
+// SubtitleOptions control the content and timing of the subtitle cues.
+type SubtitleOptions struct {
+	Template *template.Template // text of the cues executed with a *cue, nil means DefaultSubtitleTemplate
+	Interval time.Duration      // emit cues at fixed interval with interpolated values, zero means a cue for each point
+	Duration time.Duration      // length of the video, cues past it are dropped, zero means no limit
+	Map      *MapOptions        // speed coloring of the map matched by the ASS subtitles, nil means the default
+}
+
+// DefaultSubtitleTemplate is the cue text template used when none is specified.
+const DefaultSubtitleTemplate = `{{.Time.Format "15:04:05"}}: {{printf "%0.1f" .Point.Distance}} {{.DistanceUnit}} @ {{printf "%0.1f" .Speed}} {{.SpeedUnit}} ↑ {{.Heading}}° {{.Direction}} = {{printf "%0.2f" .LongDistance}} {{.LongDistanceUnit}}`
+
+// NewSubtitleTemplate parses the cue text template.
+// Besides the standard template functions, hms formats a duration as h:mm:ss.
+func NewSubtitleTemplate(text string) (*template.Template, error) {
+	return template.New("cue").Funcs(template.FuncMap{"hms": HMS}).Parse(text)
+}
+
+var defaultSubtitleTemplate = template.Must(NewSubtitleTemplate(DefaultSubtitleTemplate))
+
+// cue is a subtitle showing the stats of the track at given time.
+// It is also the data passed to the subtitle template.
+type cue struct {
+	Index    int            // sequence number of the cue starting from 1
+	Start    time.Duration  // video time when the cue is shown
+	End      time.Duration  // video time when the cue is hidden
+	Time     time.Time      // track time of the cue in the track timezone
+	Elapsed  time.Duration  // time since the start of the track
+	Speed    float64        // in speed units
+	Heading  int            // in degrees
+	Distance float64        // distance covered since the start of the video in distance units
+	Point    *track.Point   // the track point of the cue (an interpolated copy of the next point with the interval values)
+	Segment  *track.Segment // the segment of the Point
+	Track    *track.Track
+}
+
+func (c *cue) Direction() string        { return track.DirectionOf(c.Heading).String() }
+func (c *cue) Mode() track.Mode         { return c.Segment.Mode }
+func (c *cue) SpeedUnit() string        { return c.Track.Params.SpeedLabel() }
+func (c *cue) DistanceUnit() string     { return c.Track.Params.DistanceLabel() }
+func (c *cue) LongDistanceUnit() string { return c.Track.Params.LongDistanceLabel() }
+func (c *cue) LongDistance() float64    { return c.Track.Params.AsLongDistance(c.Distance) }
+func (c *cue) Wind() string             { return c.Track.Wind.String() }
+
+// SegmentType returns the activity specific segment type, or the segment mode if the type is unknown.
+func (c *cue) SegmentType() string { return c.Segment.TypeKey() }
+
+// WindAngle returns the angle between the heading and the wind direction (0 if the wind is unknown).
+func (c *cue) WindAngle() int {
+	if c.Track.Wind == track.UNK {
+		return 0
+	}
+	return c.Track.Wind.WindAngle(c.Heading)
+}
+
+// VMG returns the velocity made good upwind or downwind (0 if the wind is unknown).
+func (c *cue) VMG() float64 {
+	if c.Track.Wind == track.UNK {
+		return 0
+	}
+	return c.Track.Wind.VMG(c.Heading, c.Speed)
+}
+
+// eachCue calls f with a cue for each point of the track that falls into the video,
+// or with a cue for each interval of the video if opts.Interval is set.
+// Positive @videoOffset means the video starts ahead of the track, the timestamps will be adjusted accordingly.
+// Negative @videoOffset means the video starts later and therefore the corresponding initial part of the track will be skipped.
+func eachCue(t *track.Track, videoOffset time.Duration, opts *SubtitleOptions, f func(c *cue)) {
+	if opts.Duration > 0 {
+		emit := f
+		f = func(c *cue) {
+			if c.Start >= opts.Duration {
+				return
+			}
+			c.End = min(c.End, opts.Duration)
+			emit(c)
+		}
+	}
+	if opts.Interval > 0 {
+		eachIntervalCue(t, videoOffset, opts.Interval, f)
+		return
+	}
+	currentOffset := videoOffset
+	c := &cue{Track: t}
+	var prev *track.Point
+	for _, segment := range t.Segments {
+		for _, next := range segment.Points {
+			if prev == nil {
+				prev = next
+				continue
+			}
+			duration := next.GPX.Timestamp.Sub(prev.GPX.Timestamp)
+			prev = next
+			newOffset := currentOffset + duration
+			if newOffset < 0 {
+				currentOffset = newOffset
+				continue
+			}
+			c.Index++
+			c.Distance += next.Distance
+			c.Start, c.End = currentOffset, newOffset
+			c.Time = next.GPX.Timestamp.In(t.Timezone())
+			c.Elapsed = next.GPX.Timestamp.Sub(t.Start)
+			c.Speed, c.Heading = next.Speed, next.Heading
+			c.Point, c.Segment = next, segment
+			f(c)
+			currentOffset = newOffset
+		}
+	}
+}
+
+// eachIntervalCue calls f with a cue for each @interval of the video that overlaps the track.
+// The cue values are interpolated between the track points surrounding the cue start,
+// the cue Point is a copy of the next point with the interpolated values and the distance covered since the previous cue.
+func eachIntervalCue(t *track.Track, videoOffset, interval time.Duration, f func(c *cue)) {
+	if len(t.Segments) == 0 {
+		return
+	}
+	c := &cue{Track: t}
+	start := t.Segments[0].Points[0].GPX.Timestamp
+	// video time of the next cue, the first cue aligned to the interval after the track starts
+	var next time.Duration
+	if videoOffset > 0 {
+		next = (videoOffset + interval - 1) / interval * interval
+	}
+	var prev *track.Point
+	var distance, base float64 // track distance up to prev, and at the start of the video
+	for _, segment := range t.Segments {
+		for _, p := range segment.Points {
+			if prev == nil {
+				prev = p
+				continue
+			}
+			from, to := prev.GPX.Timestamp, p.GPX.Timestamp
+			for ts := start.Add(next - videoOffset); !ts.After(to); ts = start.Add(next - videoOffset) {
+				frac := 0.0
+				if step := to.Sub(from); step > 0 {
+					frac = float64(ts.Sub(from)) / float64(step)
+				}
+				if c.Index == 0 && videoOffset < 0 {
+					base = distance + p.Distance*frac
+				}
+				c.Index++
+				c.Start, c.End = next, next+interval
+				c.Time = ts.In(t.Timezone())
+				c.Elapsed = ts.Sub(t.Start)
+				c.Speed = prev.Speed + (p.Speed-prev.Speed)*frac
+				c.Heading = track.HeadingAdd(prev.Heading, int(math.Round(float64(track.HeadingDiff(prev.Heading, p.Heading))*frac)))
+				point := *p
+				point.Speed, point.Heading = c.Speed, c.Heading
+				point.Distance = distance + p.Distance*frac - base - c.Distance
+				c.Distance += point.Distance
+				c.Point, c.Segment = &point, segment
+				f(c)
+				next += interval
+			}
+			distance += p.Distance
+			prev = p
+		}
+	}
+}
+
+// cueText returns the cue text produced by the template.
+// Blank lines are dropped as they would terminate the cue in some formats.
+func cueText(t *track.Track, c *cue, opts *SubtitleOptions) string {
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = defaultSubtitleTemplate
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, c); err != nil {
+		return err.Error()
+	}
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Renders a VTT subtitle file based on the track.
+// See https://developer.mozilla.org/en-US/docs/Web/API/WebVTT_API/Web_Video_Text_Tracks_Format
+func renderSubtitles(t *track.Track, w io.Writer, videoOffset time.Duration, opts *SubtitleOptions) {
+	fmt.Fprintln(w, "WEBVTT")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "NOTE generated by gpx at %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "source = %s\n", t.Filename)
+	fmt.Fprintf(w, "video offset from source: %s\n", videoOffset)
+	fmt.Fprintln(w)
+
+	eachCue(t, videoOffset, opts, func(c *cue) {
+		fmt.Fprintf(w, "%d\n", c.Index)
+		fmt.Fprintf(w, "%s --> %s\n", vttTimestamp(c.Start), vttTimestamp(c.End))
+		fmt.Fprintln(w, cueText(t, c, opts))
+		fmt.Fprintln(w)
+	})
+}
+
+// Renders a SubRip subtitle file based on the track.
+// The format doesn't support comments so there is no header.
+// See https://en.wikipedia.org/wiki/SubRip#Format
+func renderSrtSubtitles(t *track.Track, w io.Writer, videoOffset time.Duration, opts *SubtitleOptions) {
+	eachCue(t, videoOffset, opts, func(c *cue) {
+		fmt.Fprintf(w, "%d\n", c.Index)
+		fmt.Fprintf(w, "%s --> %s\n", srtTimestamp(c.Start), srtTimestamp(c.End))
+		fmt.Fprintln(w, cueText(t, c, opts))
+		fmt.Fprintln(w)
+	})
+}
+
+// ASS script header, the styles place the stats into the corners of a 1080p video.
+// Alignment follows the numeric keypad layout, e.g. 3 is bottom right, 7 is top left.
+const assHeader = `Title: %s
+ScriptType: v4.00+
+PlayResX: 1920
+PlayResY: 1080
+WrapStyle: 2
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Speed,Arial,140,&H00FFFFFF,&H00FFFFFF,&H00000000,&H80000000,-1,0,0,0,100,100,0,0,1,4,2,3,60,60,40,1
+Style: Heading,Arial,72,&H00FFFFFF,&H00FFFFFF,&H00000000,&H80000000,-1,0,0,0,100,100,0,0,1,3,2,1,60,60,40,1
+Style: Time,Arial,48,&H00FFFFFF,&H00FFFFFF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,7,60,60,40,1
+Style: Segment,Arial,48,&H00FFFFFF,&H00FFFFFF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,9,60,60,40,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+// Renders an Advanced SubStation Alpha subtitle file based on the track.
+// Each cue is split into separately styled and positioned events:
+// the speed colored with the speed coloring of the map (see SubtitleOptions.Map), the heading, the time and distance, and the segment type.
+// A custom template replaces the time and distance event.
+// See http://www.tcax.org/docs/ass-specs.htm
+func renderAssSubtitles(t *track.Track, w io.Writer, videoOffset time.Duration, opts *SubtitleOptions) {
+	fmt.Fprintln(w, "[Script Info]")
+	fmt.Fprintf(w, "; generated by gpx at %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "; source = %s\n", t.Filename)
+	fmt.Fprintf(w, "; video offset from source: %s\n", videoOffset)
+	fmt.Fprintf(w, assHeader, t.FileName())
+
+	speed := opts.Map.speedScale(t)
+	eachCue(t, videoOffset, opts, func(c *cue) {
+		start, end := assTimestamp(c.Start), assTimestamp(c.End)
+		dialogue := func(style, text string) {
+			fmt.Fprintf(w, "Dialogue: 0,%s,%s,%s,,0,0,0,,%s\n", start, end, style, text)
+		}
+		dialogue("Speed", fmt.Sprintf("{\\c%s}%0.1f{\\fs60} %s", assColor(speed.color(c.Speed)), c.Speed, c.SpeedUnit()))
+		dialogue("Heading", fmt.Sprintf("\u2191 %d\u00b0 %s", c.Heading, c.Direction()))
+		if opts.Template != nil {
+			dialogue("Time", strings.ReplaceAll(cueText(t, c, opts), "\n", "\\N"))
+		} else {
+			dialogue("Time", fmt.Sprintf("%s\\N%0.2f %s", c.Time.Format(time.TimeOnly), c.LongDistance(), c.LongDistanceUnit()))
+		}
+		dialogue("Segment", c.SegmentType())
+	})
+}
+
+// assColor converts 12-bit RGB color into ASS color override (&HBBGGRR&).
+func assColor(rgb int) string {
+	r, g, b := (rgb>>8)&0xf, (rgb>>4)&0xf, rgb&0xf
+	return fmt.Sprintf("&H%02X%02X%02X&", b*17, g*17, r*17)
+}
+
+// splitTimestamp splits the timestamp into hours, minutes, seconds and milliseconds.
+// Negative timestamps are treated as zero.
+func splitTimestamp(ts time.Duration) (h, m, s, ms int64) {
+	if ts < 0 {
+		return 0, 0, 0, 0
+	}
+	total := ts.Milliseconds()
+	ms = total % 1000
+	total /= 1000
+	s = total % 60
+	total /= 60
+	m = total % 60
+	total /= 60
+	return total, m, s, ms
+}
+
+func vttTimestamp(ts time.Duration) string {
+	h, m, s, ms := splitTimestamp(ts)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+func srtTimestamp(ts time.Duration) string {
+	h, m, s, ms := splitTimestamp(ts)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+func assTimestamp(ts time.Duration) string {
+	h, m, s, ms := splitTimestamp(ts)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", h, m, s, ms/10)
+}
+
+// HMS formats the duration as h:mm:ss.
+func HMS(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	h, m, s, _ := splitTimestamp(d)
+	return fmt.Sprintf("%s%d:%02d:%02d", sign, h, m, s)
+}
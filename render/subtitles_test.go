@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/internal/testutil/tracktest"
+	"github.com/mkobetic/gpx/track"
+)
+
+func Test_Timestamps(t *testing.T) {
+	ts := time.Hour + 2*time.Minute + 3*time.Second + 456*time.Millisecond
+	testutil.AssertEqual(t, vttTimestamp(ts), "01:02:03.456")
+	testutil.AssertEqual(t, srtTimestamp(ts), "01:02:03,456")
+	testutil.AssertEqual(t, assTimestamp(ts), "1:02:03.45")
+	testutil.AssertEqual(t, srtTimestamp(-time.Second), "00:00:00,000")
+}
+
+func Test_AssColor(t *testing.T) {
+	testutil.AssertEqual(t, assColor(0x0f0), "&H00FF00&")
+	testutil.AssertEqual(t, assColor(0xf80), "&H0088FF&")
+}
+
+func Test_SubtitleFormats(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	for _, tt := range []struct {
+		format string
+		offset time.Duration
+		cues   int
+	}{
+		{"vtt", 0, 22},
+		{"srt", 0, 22},
+		{"ass", 0, 22},
+		{"vtt", -20 * time.Second, 18},
+		{"srt", -20 * time.Second, 18},
+		{"ass", -20 * time.Second, 18},
+	} {
+		t.Run(tt.format+tt.offset.String(), func(t *testing.T) {
+			var b bytes.Buffer
+			SubtitleFormats[tt.format](trk, &b, tt.offset, &SubtitleOptions{})
+			out := b.String()
+			switch tt.format {
+			case "ass":
+				testutil.AssertEqual(t, strings.Count(out, "Dialogue: 0,"), 4*tt.cues)
+				testutil.AssertEqual(t, strings.Contains(out, "Dialogue: 0,0:00:00.00,"), true)
+			case "srt":
+				testutil.AssertEqual(t, strings.Count(out, " --> "), tt.cues)
+				testutil.AssertEqual(t, strings.HasPrefix(out, "1\n00:00:00,000 --> "), true)
+			default:
+				testutil.AssertEqual(t, strings.Count(out, " --> "), tt.cues)
+			}
+		})
+	}
+}
+
+func Test_AssSpeedColors(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	opts := &MapOptions{SpeedRange: SpeedColorRange{4, 7}, Ramp: []int{0x00f, 0xf00}}
+	ss := opts.speedScale(trk)
+	var b bytes.Buffer
+	renderAssSubtitles(trk, &b, 0, &SubtitleOptions{Map: opts})
+	speeds := 0
+	eachCue(trk, 0, &SubtitleOptions{}, func(c *cue) {
+		// the speed is colored the same as on the map
+		exp := fmt.Sprintf("Speed,,0,0,0,,{\\c%s}%0.1f{", assColor(ss.color(c.Speed)), c.Speed)
+		testutil.AssertEqual(t, strings.Contains(b.String(), exp), true)
+		speeds++
+	})
+	testutil.AssertEqual(t, speeds, 22)
+}
+
+func Test_SubtitleInterval(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	var cues []cue
+	eachCue(trk, 0, &SubtitleOptions{Interval: 2 * time.Second}, func(c *cue) {
+		cues = append(cues, *c)
+	})
+	// track is 19:09:56 - 19:11:27
+	testutil.AssertEqual(t, len(cues), 46)
+	testutil.AssertEqual(t, cues[0].Start, time.Duration(0))
+	testutil.AssertEqual(t, cues[45].End, 92*time.Second)
+	for i, c := range cues {
+		testutil.AssertEqual(t, c.Elapsed, c.Start)
+		if i > 0 && c.Distance < cues[i-1].Distance {
+			t.Errorf("distance decreasing at %d", i)
+		}
+	}
+	// 19:10:10 is between points at 19:10:08 and 19:10:11
+	c := cues[7]
+	testutil.AssertEqual(t, c.Time.UTC().Format(time.TimeOnly), "19:10:10")
+	prev, next := trk.Segments[0].Points[2], trk.Segments[0].Points[3]
+	if c.Speed < min(prev.Speed, next.Speed) || c.Speed > max(prev.Speed, next.Speed) {
+		t.Errorf("speed %f not interpolated between %f and %f", c.Speed, prev.Speed, next.Speed)
+	}
+	// the point distance is the distance covered in the interval, 2s of the 3s step from 19:10:08
+	testutil.AssertEqual(t, math.Abs(c.Point.Distance-next.Distance*2/3) < 1e-9, true)
+	testutil.AssertEqual(t, c.Point.Speed, c.Speed)
+	var distance float64
+	for _, c := range cues {
+		distance += c.Point.Distance
+		testutil.AssertEqual(t, math.Abs(c.Distance-distance) < 1e-9, true)
+	}
+}
+
+func Test_SubtitleEmptyTrack(t *testing.T) {
+	trk := &track.Track{Params: track.Sailing.Params()}
+	for _, opts := range []*SubtitleOptions{{}, {Interval: time.Second}} {
+		eachCue(trk, 0, opts, func(c *cue) {
+			t.Errorf("unexpected cue %d", c.Index)
+		})
+	}
+	var b bytes.Buffer
+	renderSubtitles(trk, &b, 0, &SubtitleOptions{Interval: time.Second})
+	testutil.AssertEqual(t, strings.Count(b.String(), " --> "), 0)
+}
+
+func Test_SubtitleDuration(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn1)
+	trk.Analyze(track.Sailing, nil)
+	for _, opts := range []*SubtitleOptions{{Duration: 30 * time.Second}, {Duration: 30 * time.Second, Interval: 4 * time.Second}} {
+		var cues []cue
+		eachCue(trk, -10*time.Second, opts, func(c *cue) {
+			cues = append(cues, *c)
+		})
+		last := cues[len(cues)-1]
+		testutil.AssertEqual(t, last.End, 30*time.Second)
+		testutil.AssertEqual(t, last.Start < 30*time.Second, true)
+	}
+}
+
+func Test_SubtitleTemplate(t *testing.T) {
+	trk := tracktest.ReadTrack(t, testutil.Turn2)
+	trk.Analyze(track.Sailing, nil)
+	trk.Classify(track.N)
+	tmpl, err := NewSubtitleTemplate("{{hms .Elapsed}} {{.Wind}} {{.WindAngle}} {{printf \"%.1f\" .VMG}}\n\n{{.SegmentType}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	renderSrtSubtitles(trk, &b, 0, &SubtitleOptions{Template: tmpl})
+	lines := strings.Split(b.String(), "\n")
+	c := &cue{Track: trk, Segment: trk.Segments[0], Point: trk.Segments[0].Points[1]}
+	c.Elapsed = c.Point.GPX.Timestamp.Sub(trk.Start)
+	c.Heading, c.Speed = c.Point.Heading, c.Point.Speed
+	testutil.AssertEqual(t, lines[2], fmt.Sprintf("%s N %d %.1f", HMS(c.Elapsed), c.WindAngle(), c.VMG()))
+	testutil.AssertEqual(t, lines[3], trk.Segments[0].TypeString())
+	testutil.AssertEqual(t, lines[4], "")
+}
+
+func Test_VMG(t *testing.T) {
+	testutil.AssertEqual(t, track.N.WindAngle(45), 45)
+	testutil.AssertEqual(t, track.NE.WindAngle(270), 135)
+	testutil.AssertEqual(t, fmt.Sprintf("%.2f", track.N.VMG(45, 10)), "7.07")
+	testutil.AssertEqual(t, fmt.Sprintf("%.2f", track.N.VMG(135, 10)), "7.07")
+	testutil.AssertEqual(t, fmt.Sprintf("%.2f", track.W.VMG(0, 10)), "0.00")
+}
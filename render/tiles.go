@@ -0,0 +1,283 @@
+package render
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// TileSource provides map tiles in the XYZ (slippy map) tiling scheme used by OpenStreetMap,
+// i.e. Web Mercator tiles with y growing southwards.
+type TileSource interface {
+	// Zooms returns the available zoom levels in ascending order.
+	Zooms() []int
+	// Tile returns the image data of the tile, nil if the tile is not available.
+	Tile(z, x, y int) ([]byte, error)
+	io.Closer
+}
+
+// maxBackgroundTiles limits the number of tiles embedded in the map, the map picks the highest zoom level
+// that covers it with at most this many tiles.
+const maxBackgroundTiles = 64
+
+// OpenTiles opens an MBTiles file or a directory of tiles laid out as z/x/y.png (or .jpg, .webp).
+func OpenTiles(path string) (TileSource, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return openTileDir(path)
+	}
+	return openMBTiles(path)
+}
+
+// tileDir is a directory of tiles laid out as z/x/y.ext.
+type tileDir struct {
+	path  string
+	zooms []int
+}
+
+var tileExtensions = []string{".png", ".jpg", ".jpeg", ".webp"}
+
+func openTileDir(path string) (*tileDir, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	td := &tileDir{path: path}
+	for _, e := range entries {
+		if z, err := strconv.Atoi(e.Name()); err == nil && e.IsDir() {
+			td.zooms = append(td.zooms, z)
+		}
+	}
+	if len(td.zooms) == 0 {
+		return nil, fmt.Errorf("%s has no zoom level directories", path)
+	}
+	slices.Sort(td.zooms)
+	return td, nil
+}
+
+func (td *tileDir) Zooms() []int { return td.zooms }
+
+func (td *tileDir) Tile(z, x, y int) ([]byte, error) {
+	for _, ext := range tileExtensions {
+		b, err := os.ReadFile(filepath.Join(td.path, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y)+ext))
+		if err == nil {
+			return b, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (td *tileDir) Close() error { return nil }
+
+// mbTiles is an MBTiles file (https://github.com/mapbox/mbtiles-spec), an SQLite database with
+// either a tiles table, or a tiles view joining the map and images tables of deduplicated tiles.
+// MBTiles rows are in the TMS scheme, i.e. with y growing northwards.
+type mbTiles struct {
+	db      *sqliteDB
+	table   *sqliteTable      // tiles table, or images table if the tiles are deduplicated
+	data    int               // tile_data column of the table
+	rowids  map[[3]int]int64  // XYZ tile to the rowid in the tiles table
+	tileIDs map[[3]int]string // XYZ tile to the tile_id in the images table of deduplicated tiles
+	images  map[string]int64  // tile_id to the rowid in the images table, built on first use
+	zooms   []int
+}
+
+func openMBTiles(path string) (_ *mbTiles, err error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			db.Close()
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+	}()
+	mt := &mbTiles{db: db, rowids: map[[3]int]int64{}}
+	tiles, err := db.table("tiles")
+	if err != nil {
+		return nil, err
+	}
+	index := tiles
+	if tiles != nil && tiles.Type == "view" {
+		if index, err = db.table("map"); err != nil {
+			return nil, err
+		}
+		if mt.table, err = db.table("images"); err != nil {
+			return nil, err
+		}
+		if index == nil || mt.table == nil {
+			return nil, errors.New("tiles view without map and images tables is not supported")
+		}
+		mt.tileIDs = map[[3]int]string{}
+	} else {
+		mt.table = tiles
+	}
+	if index == nil || index.Type != "table" {
+		return nil, errors.New("no tiles table")
+	}
+	z, x, y, id := index.column("zoom_level"), index.column("tile_column"), index.column("tile_row"), index.column("tile_id")
+	if mt.data = mt.table.column("tile_data"); z < 0 || x < 0 || y < 0 || mt.data < 0 || (mt.tileIDs != nil && id < 0) {
+		return nil, errors.New("unsupported tiles table layout")
+	}
+	zooms := map[int]bool{}
+	// only the local part of the rows is read so that the tile data in the overflow pages is skipped
+	err = db.scanLocal(index.Root, func(rowid int64, row []any) error {
+		key := [3]int{rowInt(row, z), rowInt(row, x), rowInt(row, y)}
+		if key[0] < 0 || key[0] > 30 {
+			return nil
+		}
+		key[2] = 1<<key[0] - 1 - key[2]
+		if mt.tileIDs != nil {
+			mt.tileIDs[key] = rowString(row, id)
+		} else {
+			mt.rowids[key] = rowid
+		}
+		zooms[key[0]] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for z := range zooms {
+		mt.zooms = append(mt.zooms, z)
+	}
+	if len(mt.zooms) == 0 {
+		return nil, errors.New("no tiles")
+	}
+	slices.Sort(mt.zooms)
+	return mt, nil
+}
+
+func rowInt(row []any, i int) int {
+	if i < len(row) {
+		if v, ok := row[i].(int64); ok {
+			return int(v)
+		}
+	}
+	return -1
+}
+
+func rowString(row []any, i int) string {
+	if i < len(row) {
+		switch v := row[i].(type) {
+		case string:
+			return v
+		case []byte:
+			return string(v)
+		case nil:
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+func (mt *mbTiles) Zooms() []int { return mt.zooms }
+
+func (mt *mbTiles) Tile(z, x, y int) ([]byte, error) {
+	key := [3]int{z, x, y}
+	rowid, ok := mt.rowids[key]
+	if mt.tileIDs != nil {
+		var id string
+		if id, ok = mt.tileIDs[key]; !ok {
+			return nil, nil
+		}
+		// the images are looked up by tile_id, which requires reading the whole images table
+		if mt.images == nil {
+			if err := mt.indexImages(); err != nil {
+				return nil, err
+			}
+		}
+		rowid, ok = mt.images[id]
+	}
+	if !ok {
+		return nil, nil
+	}
+	row, err := mt.db.row(mt.table.Root, rowid)
+	if err != nil || mt.data >= len(row) {
+		return nil, err
+	}
+	data, _ := row[mt.data].([]byte)
+	return data, nil
+}
+
+func (mt *mbTiles) indexImages() error {
+	id := mt.table.column("tile_id")
+	if id < 0 {
+		return errors.New("images table has no tile_id column")
+	}
+	mt.images = map[string]int64{}
+	return mt.db.scan(mt.table.Root, func(rowid int64, row []any) error {
+		mt.images[rowString(row, id)] = rowid
+		return nil
+	})
+}
+
+func (mt *mbTiles) Close() error { return mt.db.Close() }
+
+// mapTile is a background tile positioned in the SVG coordinates of the map.
+type mapTile struct {
+	X, Y, Size float64
+	Href       string // data URI of the tile image
+}
+
+// backgroundTiles returns the tiles covering the map from the highest zoom level that needs at most maxBackgroundTiles tiles.
+// Zoom levels without any tiles covering the map are skipped.
+func (m *Map) backgroundTiles(src TileSource) ([]mapTile, error) {
+	north, west := m.unproject(0, 0)
+	south, east := m.unproject(m.w, m.h)
+	zooms := src.Zooms()
+	for i := len(zooms) - 1; i >= 0; i-- {
+		z := zooms[i]
+		x0, y0 := tileXY(z, north, west)
+		x1, y1 := tileXY(z, south, east)
+		if (x1-x0+1)*(y1-y0+1) > maxBackgroundTiles {
+			continue
+		}
+		var tiles []mapTile
+		size := 360 / float64(int(1)<<z) * m.k
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				b, err := src.Tile(z, x, y)
+				if err != nil {
+					return nil, err
+				}
+				if b == nil {
+					continue
+				}
+				tiles = append(tiles, mapTile{
+					X:    (float64(x)*360/float64(int(1)<<z)-180-m.lx)*m.k + border,
+					Y:    (m.top-(180-float64(y)*360/float64(int(1)<<z)))*m.k + border,
+					Size: size,
+					Href: "data:" + http.DetectContentType(b) + ";base64," + base64.StdEncoding.EncodeToString(b),
+				})
+			}
+		}
+		if len(tiles) > 0 {
+			return tiles, nil
+		}
+	}
+	return nil, nil
+}
+
+// tileXY returns the XYZ tile containing the position at the zoom level.
+func tileXY(z int, lat, lon float64) (x, y int) {
+	n := float64(int(1) << z)
+	x = int(math.Floor((lon + 180) / 360 * n))
+	y = int(math.Floor((180 - mercator(lat)) / 360 * n))
+	return max(0, min(int(n)-1, x)), max(0, min(int(n)-1, y))
+}
@@ -0,0 +1,178 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/track"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+func Test_MBTiles(t *testing.T) {
+	for _, fn := range []string{"plain.mbtiles", "dedup.mbtiles"} {
+		t.Run(fn, func(t *testing.T) {
+			src, err := OpenTiles(filepath.Join("../samples/tiles", fn))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer src.Close()
+			testutil.AssertEqual(t, len(src.Zooms()), 3)
+			// tile rows are flipped from TMS to XYZ
+			for _, tc := range []struct {
+				z, x, y int
+				exp     string
+			}{
+				{2, 1, 0, "2/1/3;"},
+				{2, 3, 3, "2/3/0;"},
+				{8, 75, 90, "8/75/165;"},
+				{3, 2, 2, "3/2/5;"},
+				{8, 0, 0, ""},
+			} {
+				b, err := src.Tile(tc.z, tc.x, tc.y)
+				if err != nil {
+					t.Fatal(err)
+				}
+				testutil.AssertEqual(t, strings.HasPrefix(string(b), "\x89PNG\r\n\x1a\n"+tc.exp), tc.exp != "")
+			}
+			// the large tile is stored in overflow pages
+			b, _ := src.Tile(3, 2, 2)
+			testutil.AssertEqual(t, len(b), 5008)
+		})
+	}
+}
+
+// sqlite3.mbtiles was created by the sqlite3 shell (3.40) in the WAL journal mode with 1k pages:
+// tiles of zooms 0-4 with the PNG signature and the TMS z/x/y; of the tile padded with 40 spaces,
+// tile 4/3/2 padded with 5000 spaces, the tiles of column 15 of zoom 4 deleted.
+func Test_MBTilesSQLite3(t *testing.T) {
+	fn := "../samples/tiles/sqlite3.mbtiles"
+	src, err := OpenTiles(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	testutil.AssertEqual(t, len(src.Zooms()), 5)
+	n := 0
+	for z := 0; z <= 4; z++ {
+		for x := 0; x < 1<<z; x++ {
+			for y := 0; y < 1<<z; y++ {
+				b, err := src.Tile(z, x, y)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if z == 4 && x == 15 {
+					testutil.AssertEqual(t, len(b), 0)
+					continue
+				}
+				exp := fmt.Sprintf("\x89PNG\r\n\x1a\n%d/%d/%d;", z, x, 1<<z-1-y)
+				testutil.AssertEqual(t, strings.HasPrefix(string(b), exp), true)
+				n++
+			}
+		}
+	}
+	testutil.AssertEqual(t, n, 341-16)
+	b, _ := src.Tile(4, 3, 13)
+	testutil.AssertEqual(t, len(b), 8+6+5000)
+
+	// the changes in an un-checkpointed write-ahead log would be missed
+	dir := t.TempDir()
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wal := filepath.Join(dir, "wal.mbtiles")
+	for fn, data := range map[string][]byte{wal: data, wal + "-wal": []byte("changes")} {
+		if err := os.WriteFile(fn, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := OpenTiles(wal); err == nil || !strings.Contains(err.Error(), "checkpoint") {
+		t.Errorf("expected un-checkpointed WAL error, got %v", err)
+	}
+}
+
+func Test_TileDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, fn := range []string{"3/2/2.png", "3/2/3.jpg", "5/1/1.png"} {
+		fn = filepath.Join(dir, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(fn), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := OpenTiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(src.Zooms()), 2)
+	b, _ := src.Tile(3, 2, 3)
+	testutil.AssertEqual(t, string(b), filepath.Join(dir, "3/2/3.jpg"))
+	b, _ = src.Tile(3, 2, 4)
+	testutil.AssertEqual(t, b == nil, true)
+}
+
+func Test_BackgroundTiles(t *testing.T) {
+	src, err := OpenTiles("../samples/tiles/plain.mbtiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	// the zoom 8 tiles cover lon -81.6 to -67.5 and lat 40.9 to 50.7, a map inside a single tile at zoom 8
+	m := NewMap(gpx.GpxBounds{MinLatitude: 43.8, MaxLatitude: 43.9, MinLongitude: -77.1, MaxLongitude: -77}, track.Meter)
+	tiles, err := m.backgroundTiles(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(tiles), 1)
+	testutil.AssertEqual(t, strings.HasPrefix(tiles[0].Href, "data:image/png;base64,"), true)
+	// the tile covers the whole map
+	x, y := tileXY(8, 43.85, -77.05)
+	testutil.AssertEqual(t, x, 73)
+	testutil.AssertEqual(t, y, 93)
+	testutil.AssertEqual(t, tiles[0].X <= 0 && tiles[0].Y <= 0, true)
+	testutil.AssertEqual(t, tiles[0].X+tiles[0].Size >= m.w && tiles[0].Y+tiles[0].Size >= m.h, true)
+}
+
+func Test_SQLiteCorrupt(t *testing.T) {
+	data, err := os.ReadFile("../samples/tiles/sqlite3.mbtiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name     string
+		off      int
+		patch    []byte
+		expected string
+	}{
+		{"zero page size", 16, []byte{0, 0}, "invalid page size 0"},
+		{"odd page size", 16, []byte{3, 0}, "invalid page size 768"},
+		{"reserved space", 16, []byte{2, 0, 1, 1, 64}, "invalid page size 512 with 64 reserved bytes"},
+	} {
+		fn := filepath.Join(dir, tc.name+".mbtiles")
+		corrupt := append([]byte(nil), data...)
+		copy(corrupt[tc.off:], tc.patch)
+		if err := os.WriteFile(fn, corrupt, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenTiles(fn); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected %q error, got %v", tc.name, tc.expected, err)
+		}
+	}
+	// leaf table cells with a huge payload size or cut off after a varint
+	db := &sqliteDB{pageSize: 512, usable: 512}
+	p := make([]byte, 512)
+	copy(p[500:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1, 2})
+	copy(p[509:], []byte{0x81, 0x82, 0x83})
+	for _, off := range []int{500, 509, 511, 512} {
+		if _, _, err := db.cell(p, off, false); err != errSQLiteCorrupt {
+			t.Errorf("cell at %d: expected corrupt error, got %v", off, err)
+		}
+	}
+}
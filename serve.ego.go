@@ -0,0 +1,128 @@
+// Generated by ego.
+// DO NOT EDIT
+
+//line serve.ego:1
+
+package main
+
+import "fmt"
+import "html"
+import "io"
+import "context"
+
+import "time"
+import "github.com/mkobetic/gpx/render"
+
+func renderIndex(w io.Writer, sessions []*serveSession, problems [][2]string) {
+
+//line serve.ego:9
+	_, _ = io.WriteString(w, "\n<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n<title>Tracks</title>\n<style>\n")
+//line serve.ego:16
+	_, _ = fmt.Fprint(w, render.ReportCSS)
+//line serve.ego:17
+	_, _ = io.WriteString(w, "\n</style>\n</head>\n<body>\n<h1>Tracks</h1>\n")
+//line serve.ego:21
+	if len(sessions) == 0 {
+//line serve.ego:22
+		_, _ = io.WriteString(w, "\n<p>No tracks found.</p>\n")
+//line serve.ego:23
+	} else {
+//line serve.ego:24
+		_, _ = io.WriteString(w, "\n<table id=\"segments\">\n    <thead>\n    <tr><th>Date</th><th>Start</th><th>Duration</th><th>Distance</th><th>Wind</th><th>File</th><th></th></tr>\n    </thead>\n    <tbody>\n    ")
+//line serve.ego:29
+		for _, s := range sessions {
+			path := "/s/" + s.Key + "/" + s.Name
+
+//line serve.ego:32
+			_, _ = io.WriteString(w, "\n    <tr>\n        <td data-sort=\"")
+//line serve.ego:33
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Start.Unix())))
+//line serve.ego:33
+			_, _ = io.WriteString(w, "\">")
+//line serve.ego:33
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Start.Format(time.DateOnly))))
+//line serve.ego:33
+			_, _ = io.WriteString(w, "</td>\n        <td>")
+//line serve.ego:34
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Start.Format(time.TimeOnly))))
+//line serve.ego:34
+			_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line serve.ego:35
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Duration.Seconds())))
+//line serve.ego:35
+			_, _ = io.WriteString(w, "\">")
+//line serve.ego:35
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(render.HMS(s.Duration))))
+//line serve.ego:35
+			_, _ = io.WriteString(w, "</td>\n        <td data-sort=\"")
+//line serve.ego:36
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Distance)))
+//line serve.ego:36
+			_, _ = io.WriteString(w, "\">")
+//line serve.ego:36
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(fmt.Sprintf("%.2f %s", s.Distance, s.Unit))))
+//line serve.ego:36
+			_, _ = io.WriteString(w, "</td>\n        <td>")
+//line serve.ego:37
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.Wind)))
+//line serve.ego:37
+			_, _ = io.WriteString(w, "</td>\n        <td>")
+//line serve.ego:38
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(s.File)))
+//line serve.ego:38
+			_, _ = io.WriteString(w, "</td>\n        <td><a href=\"")
+//line serve.ego:39
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(path)))
+//line serve.ego:39
+			_, _ = io.WriteString(w, ".svg\">map</a> <a href=\"")
+//line serve.ego:39
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(path)))
+//line serve.ego:39
+			_, _ = io.WriteString(w, ".html\">report</a> <a href=\"")
+//line serve.ego:39
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(path)))
+//line serve.ego:39
+			_, _ = io.WriteString(w, ".gpx\">gpx</a></td>\n    </tr>\n    ")
+//line serve.ego:41
+		}
+//line serve.ego:42
+		_, _ = io.WriteString(w, "\n    </tbody>\n</table>\n<script>\n")
+//line serve.ego:45
+		_, _ = fmt.Fprint(w, render.ReportScript)
+//line serve.ego:46
+		_, _ = io.WriteString(w, "\n</script>\n")
+//line serve.ego:47
+	}
+//line serve.ego:48
+	_, _ = io.WriteString(w, "\n")
+//line serve.ego:48
+	if len(problems) > 0 {
+//line serve.ego:49
+		_, _ = io.WriteString(w, "\n<h2>Problems</h2>\n<table id=\"problems\">\n    ")
+//line serve.ego:51
+		for _, problem := range problems {
+//line serve.ego:52
+			_, _ = io.WriteString(w, "\n    <tr><td>")
+//line serve.ego:52
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(problem[0])))
+//line serve.ego:52
+			_, _ = io.WriteString(w, "</td><td>")
+//line serve.ego:52
+			_, _ = io.WriteString(w, html.EscapeString(fmt.Sprint(problem[1])))
+//line serve.ego:52
+			_, _ = io.WriteString(w, "</td></tr>\n    ")
+//line serve.ego:53
+		}
+//line serve.ego:54
+		_, _ = io.WriteString(w, "\n</table>\n")
+//line serve.ego:55
+	}
+//line serve.ego:56
+	_, _ = io.WriteString(w, "\n</body>\n</html>\n")
+//line serve.ego:58
+}
+
+var _ fmt.Stringer
+var _ io.Reader
+var _ context.Context
+var _ = html.EscapeString
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/mkobetic/gpx/render"
+	"github.com/mkobetic/gpx/track"
+)
+
+// ServeOptions configure the local web server for browsing the tracks (see -serve).
+type ServeOptions struct {
+	Addr             string
+	Inputs           []string // files and directories (searched recursively) with the track files
+	Cache            string   // directory of the cached analysis results
+	Activity         track.Activity
+	Wind             *track.Direction // wind direction for point of sail analysis, nil skips it
+	MinSegmentLength int
+	Map              *render.MapOptions
+	// Command line options affecting the generated files, part of the cache keys
+	// and of the crop command of the maps, without the inputs and the server options.
+	Command []string
+}
+
+// serveExtensions are the file extensions of the track files picked from the input directories.
+var serveExtensions = []string{".gpx", ".fit"}
+
+// serveSession is a track of an input file as listed by the server.
+// The sessions of each input file are cached in a sessions.json file along with the generated files of the tracks.
+type serveSession struct {
+	Name     string        `json:"name"`  // base name of the generated files, see Track.FileName
+	Start    time.Time     `json:"start"` // in the track timezone
+	Duration time.Duration `json:"duration"`
+	Distance float64       `json:"distance"` // in long distance units
+	Unit     string        `json:"unit"`
+	Wind     string        `json:"wind"`
+	Problem  string        `json:"problem,omitempty"` // analysis problem, e.g. undetermined wind direction
+	File     string        `json:"-"`                 // input file
+	Key      string        `json:"-"`                 // cache key of the input file
+}
+
+// serveFile is an input file with its cache key, remembered with the size and modification time
+// so that the file doesn't have to be hashed again if it didn't change.
+type serveFile struct {
+	size int64
+	mod  time.Time
+	key  string
+}
+
+type server struct {
+	opts  *ServeOptions
+	mu    sync.Mutex // serializes the analysis of the input files
+	files map[string]*serveFile
+}
+
+// Serve runs the web server listing the sessions of the input files with links to their maps, reports and GPX files.
+// The input files are analyzed when they are first listed, the results are cached on disk
+// keyed by the hash of the file content and the options, so that they are reused after a restart.
+func Serve(opts *ServeOptions) error {
+	if err := os.MkdirAll(opts.Cache, 0755); err != nil {
+		return err
+	}
+	fmt.Printf("Serving %s at http://%s/\n", strings.Join(opts.Inputs, ", "), opts.Addr)
+	return http.ListenAndServe(opts.Addr, newServer(opts))
+}
+
+func newServer(opts *ServeOptions) http.Handler {
+	s := &server{opts: opts, files: map[string]*serveFile{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.index)
+	mux.HandleFunc("/s/", s.file)
+	return mux
+}
+
+// index lists the sessions of all the input files, the most recent first.
+func (s *server) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	files, err := s.inputs()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var sessions []*serveSession
+	var problems [][2]string // file, error
+	for _, fn := range files {
+		ss, err := s.sessions(fn)
+		if err != nil {
+			problems = append(problems, [2]string{fn, err.Error()})
+			continue
+		}
+		for _, session := range ss {
+			if session.Problem != "" {
+				problems = append(problems, [2]string{fn, session.Name + ": " + session.Problem})
+			}
+		}
+		sessions = append(sessions, ss...)
+	}
+	slices.SortFunc(sessions, func(a, b *serveSession) int { return b.Start.Compare(a.Start) })
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	renderIndex(w, sessions, problems)
+}
+
+var serveFilePath = regexp.MustCompile(`^/s/([0-9a-f]{64})/([^/]+\.(svg|html|gpx))$`)
+
+// file serves a generated file of a session, /s/<cache key>/<file name>.
+func (s *server) file(w http.ResponseWriter, r *http.Request) {
+	m := serveFilePath.FindStringSubmatch(r.URL.Path)
+	if m == nil || strings.HasPrefix(m[2], ".") {
+		http.NotFound(w, r)
+		return
+	}
+	if m[3] == "gpx" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", m[2]))
+	}
+	http.ServeFile(w, r, filepath.Join(s.opts.Cache, m[1], m[2]))
+}
+
+// inputs returns the track files of the inputs.
+func (s *server) inputs() (files []string, err error) {
+	cache, _ := filepath.Abs(s.opts.Cache)
+	for _, input := range s.opts.Inputs {
+		fi, err := os.Stat(input)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, input)
+			continue
+		}
+		err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if abs, _ := filepath.Abs(path); abs == cache {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if slices.Contains(serveExtensions, strings.ToLower(filepath.Ext(path))) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// sessions returns the sessions of the input file, analyzing it if it isn't cached yet.
+func (s *server) sessions(fn string) ([]*serveSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, err := s.key(fn)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(s.opts.Cache, key)
+	var sessions []*serveSession
+	if b, err := os.ReadFile(filepath.Join(dir, "sessions.json")); err == nil {
+		err = json.Unmarshal(b, &sessions)
+		if err != nil {
+			return nil, fmt.Errorf("cached sessions: %w", err)
+		}
+	} else if errors.Is(err, fs.ErrNotExist) {
+		if sessions, err = s.analyze(fn, dir); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.File, session.Key = fn, key
+	}
+	return sessions, nil
+}
+
+// serveCacheVersion is the version of the format of the cached analysis results, part of the cache keys.
+// Bump it when the sessions or the generated files change.
+const serveCacheVersion = 2
+
+// buildID identifies the program build in the cache keys, the commit of the release builds,
+// or the modification time of the executable of the builds without a commit (go build or go run).
+func buildID() string {
+	if Commit != "" {
+		return Commit
+	}
+	if exe, err := os.Executable(); err == nil {
+		if fi, err := os.Stat(exe); err == nil {
+			return fi.ModTime().UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return ""
+}
+
+// key returns the cache key of the input file, the hash of the file content, the options,
+// the cache format version and the program build.
+func (s *server) key(fn string) (string, error) {
+	fi, err := os.Stat(fn)
+	if err != nil {
+		return "", err
+	}
+	if f := s.files[fn]; f != nil && f.size == fi.Size() && f.mod.Equal(fi.ModTime()) {
+		return f.key, nil
+	}
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "\x00%s\x00%d\x00%s", strings.Join(s.opts.Command, "\x00"), serveCacheVersion, buildID())
+	key := hex.EncodeToString(h.Sum(nil))
+	s.files[fn] = &serveFile{size: fi.Size(), mod: fi.ModTime(), key: key}
+	return key, nil
+}
+
+// analyze processes the input file the same way as the command line does for each file,
+// generating the map, the report and the GPX file of each track into the directory.
+func (s *server) analyze(fn, dir string) ([]*serveSession, error) {
+	src, err := formats.ReadSource(fn)
+	if err != nil {
+		return nil, err
+	}
+	tracks, _ := track.Collect(track.SourceSegments(src), s.opts.MinSegmentLength)
+	// the files are generated into a temporary directory renamed when complete,
+	// so that an interrupted analysis isn't mistaken for a cached one
+	tmp, err := os.MkdirTemp(s.opts.Cache, ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	mapOpts := *s.opts.Map
+	mapOpts.Command, mapOpts.Inputs = s.opts.Command, []string{fn}
+	sessions := []*serveSession{}
+	for _, t := range tracks {
+		analyzed := t.Analyze(s.opts.Activity, s.opts.Wind)
+		for _, write := range []func() error{
+			func() error { return render.WriteMapFile(&t, tmp, &mapOpts) },
+			func() error { return render.WriteReportFile(&t, tmp, &mapOpts) },
+			func() error { return t.WriteGpxFile(tmp) },
+		} {
+			if err := write(); err != nil {
+				return nil, err
+			}
+		}
+		session := &serveSession{
+			Name:     t.FileName(),
+			Start:    t.Start.In(t.Timezone()),
+			Duration: t.Duration,
+			Distance: t.Params.AsLongDistance(t.Distance),
+			Unit:     t.Params.LongDistanceLabel(),
+		}
+		if t.Wind != track.UNK {
+			session.Wind = t.Wind.String()
+		}
+		if !analyzed {
+			session.Problem = "could not determine wind direction, skipping point of sail analysis"
+		}
+		sessions = append(sessions, session)
+	}
+	b, _ := json.Marshal(sessions)
+	if err := os.WriteFile(filepath.Join(tmp, "sessions.json"), b, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+	"github.com/mkobetic/gpx/render"
+	"github.com/mkobetic/gpx/track"
+)
+
+func Test_Serve(t *testing.T) {
+	dir, cache := t.TempDir(), t.TempDir()
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+	<gpx xmlns="http://www.topografix.com/GPX/1/1" version="1.1">
+	<trk><trkseg>` + testutil.Turn1 + `</trkseg></trk></gpx>`
+	if err := os.WriteFile(filepath.Join(dir, "turn1.gpx"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &ServeOptions{Inputs: []string{dir}, Cache: cache, Activity: track.Sailing, Map: &render.MapOptions{}, Command: []string{"gpx"}}
+	get := func(h http.Handler, path string) (int, string) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code, w.Body.String()
+	}
+	code, body := get(newServer(opts), "/")
+	testutil.AssertEqual(t, code, http.StatusOK)
+	testutil.AssertEqual(t, strings.Contains(body, "turn1.gpx"), true)
+	links := regexp.MustCompile(`href="(/s/[0-9a-f]{64}/[^"]+\.svg)"`).FindStringSubmatch(body)
+	if links == nil {
+		t.Fatal("no map link in the index")
+	}
+	matches, _ := filepath.Glob(filepath.Join(cache, "*", "sessions.json"))
+	testutil.AssertEqual(t, len(matches), 1)
+	// a new server reuses the cached results
+	os.Remove(filepath.Join(filepath.Dir(matches[0]), filepath.Base(links[1])))
+	s := newServer(opts)
+	code, body = get(s, "/")
+	testutil.AssertEqual(t, code, http.StatusOK)
+	testutil.AssertEqual(t, strings.Contains(body, links[1]), true)
+	code, _ = get(s, links[1])
+	testutil.AssertEqual(t, code, http.StatusNotFound)
+	code, _ = get(s, strings.TrimSuffix(links[1], ".svg")+".gpx")
+	testutil.AssertEqual(t, code, http.StatusOK)
+	code, _ = get(s, filepath.Dir(links[1])+"/sessions.json")
+	testutil.AssertEqual(t, code, http.StatusNotFound)
+}
+
+func Test_ServeProblems(t *testing.T) {
+	dir, cache := t.TempDir(), t.TempDir()
+	data, err := os.ReadFile("samples/in/160525.gpx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "160525.gpx"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the wind direction can't be determined from the track
+	wind := track.UNK
+	opts := &ServeOptions{Inputs: []string{dir}, Cache: cache, Activity: track.Sailing, Wind: &wind, MinSegmentLength: 20, Map: &render.MapOptions{}, Command: []string{"gpx"}}
+	for i := 0; i < 2; i++ { // analyzed and cached
+		w := httptest.NewRecorder()
+		newServer(opts).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		testutil.AssertEqual(t, strings.Contains(w.Body.String(), "could not determine wind direction"), true)
+	}
+}
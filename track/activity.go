@@ -0,0 +1,273 @@
+package track
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Activity is a kind of activity of the tracks. It provides the parameters of the analysis of the track points,
+// classifies the analyzed segments (see Segment.Type) and summarizes the track with activity specific metrics.
+// Activities other than those shipped here can be added to Activities to make them available by name.
+type Activity interface {
+	// Name returns the name of the activity, e.g. sail.
+	Name() string
+	// Params returns the parameters of the analysis of the track points.
+	Params() *AnalysisParameters
+	// Classify sets the Type of the segments of the analyzed track.
+	// The wind direction is nil if it isn't known, UNK if it should be determined from the track.
+	// Returns false if the classification failed.
+	Classify(t *Track, wind *Direction) bool
+	// Metrics returns the activity specific summary of the analyzed and classified track.
+	Metrics(t *Track) []Metric
+}
+
+// Metric is an activity specific summary value of a track, see Activity.Metrics.
+type Metric struct {
+	Name  string
+	Value string // formatted with its unit
+}
+
+func (m Metric) String() string {
+	return m.Name + ": " + m.Value
+}
+
+// Sailing is the activity of sail racing tracks.
+var Sailing = NewWindActivity("sail", &AnalysisParameters{
+	DistanceUnit:     Meter,
+	LongDistanceUnit: NauticalMile,
+	SpeedUnit:        NauticalMile,
+	LookAround:       50, // m
+	MovingSpeed:      1,  // kts
+	TurningChange:    60, // degrees
+})
+
+// Windsurfing is the activity of windsurfing tracks, faster than sailing with wider turns.
+var Windsurfing = NewWindActivity("windsurf", &AnalysisParameters{
+	DistanceUnit:     Meter,
+	LongDistanceUnit: NauticalMile,
+	SpeedUnit:        NauticalMile,
+	LookAround:       80, // m
+	MovingSpeed:      3,  // kts
+	TurningChange:    60, // degrees
+})
+
+// Kitesurfing is the activity of kitesurfing tracks.
+var Kitesurfing = NewWindActivity("kitesurf", &AnalysisParameters{
+	DistanceUnit:     Meter,
+	LongDistanceUnit: NauticalMile,
+	SpeedUnit:        NauticalMile,
+	LookAround:       80, // m
+	MovingSpeed:      4,  // kts
+	TurningChange:    60, // degrees
+})
+
+// paddleParams returns the parameters of the human powered activities,
+// a new value for each activity so that adjusting one doesn't change the others.
+func paddleParams() *AnalysisParameters {
+	return &AnalysisParameters{
+		DistanceUnit:     Meter,
+		LongDistanceUnit: Kilometer,
+		SpeedUnit:        Kilometer,
+		LookAround:       30, // m
+		MovingSpeed:      2,  // km/h
+		TurningChange:    45, // degrees
+	}
+}
+
+// Rowing and Kayaking are the activities of rowing and kayaking tracks, the speeds are in km/h.
+var Rowing = NewPaddleActivity("row", paddleParams())
+var Kayaking = NewPaddleActivity("kayak", paddleParams())
+
+// Motorboating is the activity of motorboat tracks, the segments at 12 kts or faster are planing.
+var Motorboating = NewMotorActivity("motorboat", &AnalysisParameters{
+	DistanceUnit:     Meter,
+	LongDistanceUnit: NauticalMile,
+	SpeedUnit:        NauticalMile,
+	LookAround:       150, // m
+	MovingSpeed:      2,   // kts
+	TurningChange:    45,  // degrees
+}, 12) // kts
+
+// Activities are the activities available by name, e.g. with the -a option.
+var Activities = map[string]Activity{}
+
+// KnownActivities are the sorted names of the Activities.
+var KnownActivities []string
+
+func init() {
+	for _, a := range []Activity{Sailing, Windsurfing, Kitesurfing, Rowing, Kayaking, Motorboating} {
+		Activities[a.Name()] = a
+	}
+	for a := range Activities {
+		KnownActivities = append(KnownActivities, a)
+	}
+	sort.Strings(KnownActivities)
+}
+
+// windActivity is an activity powered by the wind, its segments are classified by the point of sail.
+type windActivity struct {
+	name   string
+	params *AnalysisParameters
+}
+
+// NewWindActivity returns an activity powered by the wind analyzed with the parameters,
+// its segments are classified by the point of sail (see Track.Classify).
+func NewWindActivity(name string, params *AnalysisParameters) Activity {
+	return &windActivity{name: name, params: params}
+}
+
+func (a *windActivity) Name() string                { return a.name }
+func (a *windActivity) Params() *AnalysisParameters { return a.params }
+
+// Classify classifies the segments by the point of sail if the wind direction is known or can be determined from the track.
+func (a *windActivity) Classify(t *Track, wind *Direction) bool {
+	if wind == nil {
+		return true
+	}
+	windDirection := *wind
+	if windDirection == UNK {
+		windDirection = t.windDirection()
+	}
+	if windDirection == UNK {
+		return false
+	}
+	t.Classify(windDirection)
+	return true
+}
+
+// Metrics returns the average VMG of the upwind and downwind moving segments if the wind direction is known.
+func (a *windActivity) Metrics(t *Track) []Metric {
+	if t.Wind == UNK {
+		return nil
+	}
+	upwind := t.Segments.average(func(s *Segment) bool { return s.Mode == Moving && s.WindAttitude() == Upwind },
+		func(s *Segment) float64 { return t.Wind.VMG(s.Heading.Mid, s.Speed.Avg) })
+	downwind := t.Segments.average(func(s *Segment) bool { return s.Mode == Moving && s.WindAttitude() == Downwind },
+		func(s *Segment) float64 { return t.Wind.VMG(s.Heading.Mid, s.Speed.Avg) })
+	unit := a.params.SpeedLabel()
+	return []Metric{
+		{Name: "Upwind VMG", Value: fmt.Sprintf("%.1f %s", upwind, unit)},
+		{Name: "Downwind VMG", Value: fmt.Sprintf("%.1f %s", downwind, unit)},
+	}
+}
+
+// Effort is the classification of the moving segments of a rowing or paddling track, see NewPaddleActivity.
+type Effort string
+
+const (
+	Work Effort = "work"
+	Easy Effort = "easy"
+)
+
+func (e Effort) String() string { return string(e) }
+
+// EasyRatio is the fraction of the average moving speed of the track below which a moving segment is Easy.
+const EasyRatio = 0.8
+
+// paddleActivity is a human powered activity, its moving segments are classified by the effort.
+type paddleActivity struct {
+	name   string
+	params *AnalysisParameters
+}
+
+// NewPaddleActivity returns a human powered activity analyzed with the parameters, e.g. rowing or kayaking.
+// Its moving segments are classified as Work or Easy (e.g. the rest between the pieces of an interval training),
+// depending on whether their speed is below EasyRatio of the average moving speed of the track.
+func NewPaddleActivity(name string, params *AnalysisParameters) Activity {
+	return &paddleActivity{name: name, params: params}
+}
+
+func (a *paddleActivity) Name() string                { return a.name }
+func (a *paddleActivity) Params() *AnalysisParameters { return a.params }
+
+// Classify classifies the moving segments by the effort, the wind direction is ignored.
+func (a *paddleActivity) Classify(t *Track, _ *Direction) bool {
+	moving := func(s *Segment) bool { return s.Mode == Moving }
+	avg := t.Segments.average(moving, func(s *Segment) float64 { return s.Speed.Avg })
+	for _, s := range t.Segments {
+		if !moving(s) {
+			continue
+		}
+		if s.Speed.Avg < EasyRatio*avg {
+			s.Type = Easy
+		} else {
+			s.Type = Work
+		}
+	}
+	return true
+}
+
+// Metrics returns the time and distance of the Work segments and their average split, i.e. time per 500m.
+func (a *paddleActivity) Metrics(t *Track) []Metric {
+	duration, distance := t.Segments.total(func(s *Segment) bool { return s.Type == Work })
+	metrics := []Metric{a.params.totalMetric("Work", duration, distance)}
+	if meters := Meter.convertDistance(distance, a.params.DistanceUnit); meters > 0 {
+		split := time.Duration(float64(duration) * 500 / meters).Round(time.Second)
+		metrics = append(metrics, Metric{Name: "Work split", Value: fmt.Sprintf("%d:%02d /500m", int(split.Minutes()), int(split.Seconds())%60)})
+	}
+	return metrics
+}
+
+// HullMode is the classification of the moving segments of a motorboat track, see NewMotorActivity.
+type HullMode string
+
+const (
+	Displacement HullMode = "displacement"
+	Planing      HullMode = "planing"
+)
+
+func (m HullMode) String() string { return string(m) }
+
+// motorActivity is a motorboat activity, its moving segments are classified by the hull mode.
+type motorActivity struct {
+	name         string
+	params       *AnalysisParameters
+	planingSpeed float64
+}
+
+// NewMotorActivity returns a motorboat activity analyzed with the parameters.
+// Its moving segments are classified as Planing if their average speed is at least the planing speed (in SpeedUnit),
+// or Displacement otherwise.
+func NewMotorActivity(name string, params *AnalysisParameters, planingSpeed float64) Activity {
+	return &motorActivity{name: name, params: params, planingSpeed: planingSpeed}
+}
+
+func (a *motorActivity) Name() string                { return a.name }
+func (a *motorActivity) Params() *AnalysisParameters { return a.params }
+
+// Classify classifies the moving segments by the hull mode, the wind direction is ignored.
+func (a *motorActivity) Classify(t *Track, _ *Direction) bool {
+	for _, s := range t.Segments {
+		if s.Mode != Moving {
+			continue
+		}
+		if s.Speed.Avg >= a.planingSpeed {
+			s.Type = Planing
+		} else {
+			s.Type = Displacement
+		}
+	}
+	return true
+}
+
+// Metrics returns the time and distance of the Planing and Displacement segments.
+func (a *motorActivity) Metrics(t *Track) []Metric {
+	var metrics []Metric
+	for _, m := range []struct {
+		name string
+		mode HullMode
+	}{{"Planing", Planing}, {"Displacement", Displacement}} {
+		duration, distance := t.Segments.total(func(s *Segment) bool { return s.Type == m.mode })
+		metrics = append(metrics, a.params.totalMetric(m.name, duration, distance))
+	}
+	return metrics
+}
+
+// totalMetric returns a metric with the duration and the distance (in DistanceUnit), e.g. of the segments of a type.
+func (params *AnalysisParameters) totalMetric(name string, duration time.Duration, distance float64) Metric {
+	return Metric{
+		Name:  name,
+		Value: fmt.Sprintf("%s %.2f %s", duration.Round(time.Second), params.AsLongDistance(distance), params.LongDistanceLabel()),
+	}
+}
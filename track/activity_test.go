@@ -0,0 +1,87 @@
+package track
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+// activityTestTrack returns a track with a segment of a minute at each speed (in the SpeedUnit of the activity),
+// static if the speed is 0.
+func activityTestTrack(activity Activity, speeds ...float64) *Track {
+	params := activity.Params()
+	trk := &Track{Activity: activity, Params: params, Wind: UNK}
+	for _, speed := range speeds {
+		mode := Moving
+		if speed == 0 {
+			mode = Static
+		}
+		trk.Segments = append(trk.Segments, &Segment{
+			Mode:     mode,
+			Speed:    SpeedRange{Avg: speed},
+			Duration: time.Minute,
+			Distance: params.DistanceUnit.convertDistance(speed/60, params.SpeedUnit), // in a minute
+		})
+	}
+	return trk
+}
+
+func Test_Activities(t *testing.T) {
+	testutil.AssertEqual(t, len(KnownActivities), 6)
+	testutil.AssertEqual(t, KnownActivities[0], "kayak")
+	testutil.AssertEqual(t, Activities["sail"], Sailing)
+	testutil.AssertEqual(t, Activities["motorboat"].Params().SpeedLabel(), "kts")
+	// the activities don't share their parameters
+	testutil.AssertEqual(t, *Rowing.Params(), *Kayaking.Params())
+	testutil.AssertEqual(t, Rowing.Params() != Kayaking.Params(), true)
+}
+
+func Test_MotorboatClassify(t *testing.T) {
+	trk := activityTestTrack(Motorboating, 20, 6, 0)
+	testutil.AssertEqual(t, Motorboating.Classify(trk, nil), true)
+	testutil.AssertEqual(t, trk.Segments[0].TypeKey(), "planing")
+	testutil.AssertEqual(t, trk.Segments[1].TypeKey(), "displacement")
+	testutil.AssertEqual(t, trk.Segments[2].TypeKey(), string(Static))
+	metrics := trk.Metrics()
+	testutil.AssertEqual(t, len(metrics), 2)
+	testutil.AssertEqual(t, metrics[0].String(), "Planing: 1m0s 0.33 nm")
+	testutil.AssertEqual(t, metrics[1].String(), "Displacement: 1m0s 0.10 nm")
+}
+
+func Test_RowingClassify(t *testing.T) {
+	trk := activityTestTrack(Rowing, 12, 12, 6, 12)
+	testutil.AssertEqual(t, Rowing.Classify(trk, nil), true)
+	testutil.AssertEqual(t, trk.Segments[0].TypeKey(), "work")
+	testutil.AssertEqual(t, trk.Segments[2].TypeKey(), "easy")
+	metrics := trk.Metrics()
+	testutil.AssertEqual(t, len(metrics), 2)
+	testutil.AssertEqual(t, metrics[0].String(), "Work: 3m0s 0.60 km")
+	testutil.AssertEqual(t, metrics[1].String(), "Work split: 2:30 /500m")
+}
+
+func Test_AnalyzeActivity(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn3)
+	wind := N
+	testutil.AssertEqual(t, trk.Analyze(Motorboating, &wind), true)
+	testutil.AssertEqual(t, trk.Activity, Motorboating)
+	testutil.AssertEqual(t, trk.Wind, UNK)
+	var duration time.Duration
+	var distance float64
+	for _, s := range trk.Segments {
+		if s.Mode == Moving {
+			testutil.AssertEqual(t, s.TypeKey(), "displacement")
+			duration += s.Duration
+			distance += s.Distance
+		}
+	}
+	// the analyzed distances are in meters, the metrics in nautical miles
+	metrics := trk.Metrics()
+	testutil.AssertEqual(t, metrics[0].String(), "Planing: 0s 0.00 nm")
+	testutil.AssertEqual(t, metrics[1].String(), fmt.Sprintf("Displacement: %s %.2f nm", duration.Round(time.Second), distance/1852))
+	trk = readTrackSample(t, testutil.Turn3)
+	testutil.AssertEqual(t, trk.Analyze(Sailing, &wind), true)
+	testutil.AssertEqual(t, trk.Wind, N)
+	testutil.AssertEqual(t, len(trk.Metrics()), 2)
+}
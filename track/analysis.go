@@ -0,0 +1,122 @@
+package track
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// units for Distance and Speed functions,
+// expressed as the length of one degree of longitude at the equator
+type Unit float64
+
+const EquatorialRadius = 6378
+const Kilometer Unit = 2 * math.Pi * EquatorialRadius / 360
+const Meter Unit = 1000 * Kilometer
+const NauticalMile Unit = 60
+
+func (u Unit) distance() string {
+	switch u {
+	case Kilometer:
+		return "km"
+	case Meter:
+		return "m"
+	case NauticalMile:
+		return "nm"
+	default:
+		panic(fmt.Errorf("unknown unit %f", u))
+	}
+}
+
+func (u Unit) convertDistance(d float64, from Unit) float64 {
+	return d * float64(u) / float64(from)
+}
+
+func (u Unit) speed() string {
+	switch u {
+	case Kilometer:
+		return "km/h"
+	case Meter:
+		return "m/s"
+	case NauticalMile:
+		return "kts" // nm/h
+	default:
+		panic(fmt.Errorf("unknown unit %f", u))
+	}
+}
+
+// Area is the context of the analysis of the points of a track, the points are compared as if on a flat surface,
+// with the degrees of longitude adjusted to the latitude of the area of the track.
+type Area struct {
+	Coef float64 // longitudinal adjustment coeficient (degrees of longitude are shorter in higher latitudes)
+}
+
+// NewArea creates the area of the track with the bounds.
+func NewArea(b gpx.GpxBounds) *Area {
+	return &Area{Coef: math.Cos((b.MaxLatitude + b.MinLatitude) * math.Pi / 360)}
+}
+
+// Distance computes the distance between two GPS points in specified units.
+func (a *Area) Distance(p1, p2 *gpx.GPXPoint, unit Unit) float64 {
+	x := p2.Latitude - p1.Latitude
+	y := (p2.Longitude - p1.Longitude) * a.Coef
+	return float64(unit) * math.Sqrt(x*x+y*y)
+}
+
+// Speed computes the average speed between two GPS points in specified units of distance.
+// The time aspect is derived from the distance unit, i.e. meter => m/s, km => km/h, nm => kts.
+func (a *Area) Speed(p1, p2 *gpx.GPXPoint, unit Unit) float64 {
+	t := float64(p2.Timestamp.Sub(p1.Timestamp))
+	if unit == Meter {
+		t /= float64(time.Second)
+	} else {
+		t /= float64(time.Hour)
+	}
+	return a.Distance(p1, p2, unit) / t
+}
+
+// Heading computes the direction from p1 to p2 in degrees.
+func (a *Area) Heading(p1, p2 *gpx.GPXPoint) int {
+	lat := p2.Latitude - p1.Latitude
+	lon := (p2.Longitude - p1.Longitude) * a.Coef
+	deg := int(math.Round(math.Atan2(lon, lat) / math.Pi * 180))
+	if deg < 0 {
+		return 360 + deg
+	} else {
+		return deg
+	}
+}
+
+// AnalysisParameters control the analysis of the track points, see Point.Analyze.
+// NOTE: all distance values are in DistanceUnit!
+// LongDistanceUnit can be used to convert the distance values to larger units if desired, e.g. full track length
+type AnalysisParameters struct {
+	DistanceUnit     Unit    // what is point distance measured in
+	LongDistanceUnit Unit    // unit to use for longer distance (e.g. track distance)
+	SpeedUnit        Unit    // what is speed measured in (in distance units, time is implied m/s, km/h, nm/h=kts)
+	LookAround       float64 // how far back and ahead to look when analyzing a point (in DistanceUnit)
+	MovingSpeed      float64 // what's the minimum speed to be considered as moving as opposed to stationary (in SpeedUnit)
+	TurningChange    int     // what's the minimum heading change to consider the point to be part of a turn (in degrees)
+}
+
+// AsLongDistance converts the distance in DistanceUnit to LongDistanceUnit.
+func (params *AnalysisParameters) AsLongDistance(dist float64) float64 {
+	return params.LongDistanceUnit.convertDistance(dist, params.DistanceUnit)
+}
+
+// DistanceLabel returns the name of the DistanceUnit, e.g. m.
+func (params *AnalysisParameters) DistanceLabel() string {
+	return params.DistanceUnit.distance()
+}
+
+// LongDistanceLabel returns the name of the LongDistanceUnit, e.g. nm.
+func (params *AnalysisParameters) LongDistanceLabel() string {
+	return params.LongDistanceUnit.distance()
+}
+
+// SpeedLabel returns the name of the speed unit, e.g. kts.
+func (params *AnalysisParameters) SpeedLabel() string {
+	return params.SpeedUnit.speed()
+}
@@ -0,0 +1,44 @@
+package track
+
+import (
+	"testing"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+func Test_AnalyzeTurn1(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn1)
+	trk.gpxAnalyze(Sailing.Params())
+	testutil.AssertEqual(t, len(trk.Segments), 2)
+	testutil.AssertEqual(t, trk.Segments[0].Mode, Turning)
+	testutil.AssertEqual(t, len(trk.Segments[0].Points), 15)
+	testutil.AssertEqual(t, trk.Segments[1].Mode, Moving)
+	testutil.AssertEqual(t, len(trk.Segments[1].Points), 8)
+	testutil.AssertEqual(t, trk.FileName(), "240824-0h01-00.2nm")
+}
+
+func Test_AnalyzeTurn2(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn2)
+	trk.gpxAnalyze(Sailing.Params())
+	testutil.AssertEqual(t, len(trk.Segments), 2)
+	testutil.AssertEqual(t, trk.Segments[0].Mode, Moving)
+	testutil.AssertEqual(t, len(trk.Segments[0].Points), 9)
+	testutil.AssertEqual(t, trk.Segments[1].Mode, Moving)
+	testutil.AssertEqual(t, len(trk.Segments[1].Points), 11)
+	testutil.AssertEqual(t, trk.FileName(), "240824-0h01-00.1nm")
+	// logTrackPoints(t, trk)
+}
+
+func Test_AnalyzeTurn3(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn3)
+	trk.gpxAnalyze(Sailing.Params())
+	testutil.AssertEqual(t, len(trk.Segments), 3)
+	testutil.AssertEqual(t, trk.Segments[0].Mode, Moving)
+	testutil.AssertEqual(t, len(trk.Segments[0].Points), 5)
+	testutil.AssertEqual(t, trk.Segments[1].Mode, Turning)
+	testutil.AssertEqual(t, len(trk.Segments[1].Points), 7)
+	testutil.AssertEqual(t, trk.Segments[2].Mode, Moving)
+	testutil.AssertEqual(t, len(trk.Segments[2].Points), 11)
+	testutil.AssertEqual(t, trk.FileName(), "240824-0h01-00.1nm")
+	// logTrackPoints(t, trk)
+}
@@ -0,0 +1,157 @@
+package track
+
+import "github.com/tkrajina/gpxgo/gpx"
+
+// Analyzer is the streaming counterpart of AnalyzeSegment, it analyzes the points of a track segment one at a time,
+// so that a growing track or a very long log can be processed without holding all of it in memory.
+// The analysis of a point needs the points following it up to the LookAround distance (see Point.Analyze)
+// and a segment is complete only when the next one starts, so the points and the segments are returned with a lag.
+// The results are the same as those of AnalyzeSegment for the same points and Area.
+type Analyzer struct {
+	area     *Area
+	filename string
+	params   *AnalysisParameters
+	last     *Point   // last added point
+	pending  Points   // points pending the analysis
+	analyzed Points   // analyzed points not split into runs yet (see Points.nextRun)
+	short    Points   // previous run that was too short for a segment
+	segment  *Segment // last segment, it isn't complete until the next one is created
+}
+
+// NewAnalyzer creates an analyzer of the points of a track segment.
+// The Area is the context of the analysis, see AnalyzeSegment, if it is nil
+// the longitudinal adjustment is derived from the first point.
+func NewAnalyzer(area *Area, filename string, params *AnalysisParameters) *Analyzer {
+	return &Analyzer{area: area, filename: filename, params: params}
+}
+
+// Add adds the next point and returns the points and the segments that were completed by it.
+func (a *Analyzer) Add(gp *gpx.GPXPoint) (Points, Segments) {
+	p := &Point{GPX: gp, params: a.params}
+	if a.last == nil {
+		if a.area == nil {
+			a.area = NewArea(gpx.GpxBounds{MinLatitude: gp.Latitude, MaxLatitude: gp.Latitude})
+		}
+	} else {
+		prev := a.last
+		p.previous, prev.next = prev, p
+		p.Heading = a.area.Heading(prev.GPX, gp)
+		p.Distance = a.area.Distance(prev.GPX, gp, a.params.DistanceUnit)
+		p.Speed = a.area.Speed(prev.GPX, gp, a.params.SpeedUnit)
+		// Fix up movement params of the first point (we don't want the Speed and Heading to be zero)
+		if prev.previous == nil {
+			prev.Speed, prev.Heading = p.Speed, p.Heading
+		}
+	}
+	a.last = p
+	a.pending = append(a.pending, p)
+	var points Points
+	for len(a.pending) > 0 && a.settled(a.pending[0]) {
+		a.pending[0].Analyze(a.params)
+		points = append(points, a.pending[0])
+		a.pending = a.pending[1:]
+	}
+	if len(a.pending) > 0 {
+		a.unlink(a.pending[0])
+	}
+	return points, a.segments(points, false)
+}
+
+// Close analyzes the remaining points and returns them along with the remaining segments.
+func (a *Analyzer) Close() (Points, Segments) {
+	points := a.pending
+	a.pending = nil
+	if a.last == nil || a.last.previous == nil {
+		return nil, nil // a single point can't be analyzed
+	}
+	for _, p := range points {
+		p.Analyze(a.params)
+	}
+	segments := a.segments(points, true)
+	if a.short != nil {
+		if a.segment == nil {
+			a.segment = SegmentFromPoints(a.short, a.short.mode(), a.filename, a.params)
+		} else {
+			// We were left with a shortie at the end, append it to the last segment.
+			a.segment = SegmentFromPoints(append(a.segment.Points, a.short...), a.segment.Mode, a.filename, a.params)
+		}
+		a.short = nil
+	}
+	if a.segment != nil {
+		segments = append(segments, a.segment)
+		a.segment = nil
+	}
+	return points, segments
+}
+
+// Pending returns the points added but not analyzed yet.
+func (a *Analyzer) Pending() Points {
+	return a.pending
+}
+
+// settled reports whether the points following the point cover its LookAround distance or stop moving,
+// i.e. more points can't change its analysis (see Point.headingChange).
+func (a *Analyzer) settled(p *Point) bool {
+	distance := a.params.LookAround
+	if p.previous == nil { // see Point.Analyze
+		if p.next == nil {
+			return false
+		}
+		p, distance = p.next, distance-p.next.Distance
+	}
+	for next := p.next; next != nil; next = next.next {
+		if next.Speed < a.params.MovingSpeed {
+			return true
+		}
+		if distance -= next.Distance; distance <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// unlink drops the link to the points preceding the LookAround distance of the point,
+// none of the following points looks back that far (see Point.headingChange), so they can be released.
+func (a *Analyzer) unlink(p *Point) {
+	distance := a.params.LookAround
+	for prev := p.previous; prev != nil; prev = prev.previous {
+		if distance -= prev.Distance; distance <= 0 {
+			prev.previous = nil
+			return
+		}
+	}
+}
+
+// segments splits the analyzed points into runs of points in the same Mode
+// and returns the segments completed by them, see AnalyzeSegment.
+func (a *Analyzer) segments(points Points, complete bool) (segments Segments) {
+	a.analyzed = append(a.analyzed, points...)
+	for len(a.analyzed) > 0 {
+		i, mode, ok := a.analyzed.nextRun(complete)
+		if !ok {
+			break
+		}
+		run := a.analyzed[:i:i]
+		a.analyzed = a.analyzed[i:]
+		// join stashed previous short run
+		run = append(a.short, run...)
+		// if run was short then recompute mode
+		if len(run)/2 < len(a.short) {
+			mode = run.mode()
+		}
+		// if we are still short stash it and continue
+		if len(run) < 5 {
+			a.short = run
+			continue
+		}
+		a.short = nil // clear the short stash
+		segment := SegmentFromPoints(run, mode, a.filename, a.params)
+		if a.segment != nil {
+			a.segment.next = segment
+			segment.previous = a.segment
+			segments = append(segments, a.segment)
+		}
+		a.segment = segment
+	}
+	return segments
+}
@@ -0,0 +1,95 @@
+package track
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+// Test_Analyzer checks that the streaming analysis of the sample tracks matches the batch analysis.
+func Test_Analyzer(t *testing.T) {
+	files, _ := filepath.Glob("../samples/in/*.gpx")
+	for _, fn := range files {
+		t.Run(filepath.Base(fn), func(t *testing.T) {
+			src, err := formats.ReadSource(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			segments := SplitSegments(DedupeSegments(SourceSegments(src), 20), time.Hour)
+			for _, trk := range BuildTracks(DedupeSegments(segments, 20), time.Hour) {
+				area := NewArea(trk.GPX.Bounds())
+				for i := range trk.GPX.Segments {
+					s := &trk.GPX.Segments[i]
+					expected := AnalyzeSegment(s, trk.Filename, area, Sailing.Params())
+					a := NewAnalyzer(area, trk.Filename, Sailing.Params())
+					var points Points
+					var streamed Segments
+					var lag int // the most points pending the analysis
+					for j := range s.Points {
+						ps, ss := a.Add(&s.Points[j])
+						points, streamed = append(points, ps...), append(streamed, ss...)
+						lag = max(lag, len(a.Pending()))
+					}
+					// only the last few segments and points are left for Close
+					testutil.AssertEqual(t, len(streamed) >= len(expected)-3, true)
+					testutil.AssertEqual(t, lag <= 10, true)
+					ps, ss := a.Close()
+					points, streamed = append(points, ps...), append(streamed, ss...)
+					compareAnalysis(t, points, streamed, expected)
+				}
+			}
+		})
+	}
+}
+
+func compareAnalysis(t *testing.T, points Points, segments, expected Segments) {
+	t.Helper()
+	var i int
+	for _, s := range expected {
+		for _, p := range s.Points {
+			if i >= len(points) {
+				t.Fatalf("missing points from %d", i)
+			}
+			testutil.AssertEqual(t, points[i].GPX, p.GPX)
+			testutil.AssertEqual(t, points[i].String(), p.String())
+			i++
+		}
+	}
+	testutil.AssertEqual(t, len(points), i)
+	if len(segments) != len(expected) {
+		t.Fatalf("got %d segments, expected %d", len(segments), len(expected))
+	}
+	for i, s := range segments {
+		e := expected[i]
+		testutil.AssertEqual(t, s.String(), e.String())
+		testutil.AssertEqual(t, *s.Heading, *e.Heading)
+		testutil.AssertEqual(t, len(s.Points), len(e.Points))
+		testutil.AssertEqual(t, s.previous == nil, e.previous == nil)
+		testutil.AssertEqual(t, s.next == nil, e.next == nil)
+	}
+}
+
+func Test_AnalyzerShort(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn1)
+	s := &trk.GPX.Segments[0]
+	area := NewArea(trk.GPX.Bounds())
+	expected := AnalyzeSegment(s, "", area, Sailing.Params())
+	a := NewAnalyzer(area, "", Sailing.Params())
+	var points Points
+	var segments Segments
+	for j := range s.Points {
+		ps, ss := a.Add(&s.Points[j])
+		points, segments = append(points, ps...), append(segments, ss...)
+	}
+	// the last points wait for the points following them and the segments for the next one
+	testutil.AssertEqual(t, fmt.Sprint(len(points), len(segments)), "20 0")
+	ps, ss := a.Close()
+	compareAnalysis(t, append(points, ps...), append(segments, ss...), expected)
+	// closing again doesn't return anything
+	ps, ss = a.Close()
+	testutil.AssertEqual(t, len(ps)+len(ss), 0)
+}
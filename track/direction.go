@@ -0,0 +1,329 @@
+package track
+
+import (
+	"math"
+	"sort"
+)
+
+func init() {
+	for _, wd := range directionToString {
+		WindDirections = append(WindDirections, wd)
+	}
+	sort.Strings(WindDirections)
+}
+
+// Direction is a compass direction in degrees, e.g. the wind direction.
+type Direction int
+
+// PointOfSail is the heading relative to the wind, the value is the heading assuming wind from N.
+type PointOfSail int
+
+// Turn is the kind of the turn between two points of sail, e.g. a tack.
+type Turn int
+type tack int
+
+// WindAttitude tells whether a point of sail or a turn is upwind, downwind or neither.
+type WindAttitude int
+
+const (
+	UNK Direction = -1 // UNKNOWN
+	N   Direction = 0
+	NNE Direction = 23
+	NE  Direction = 45
+	ENE Direction = 68
+	E   Direction = 90
+	ESE Direction = 113
+	SE  Direction = 135
+	SSE Direction = 158
+	S   Direction = 180
+	SSW Direction = 203
+	SW  Direction = 225
+	WSW Direction = 248
+	W   Direction = 270
+	WNW Direction = 293
+	NW  Direction = 315
+	NNW Direction = 338
+
+	// value is heading assuming wind from N
+	Irons   PointOfSail = 0
+	ClosePT PointOfSail = 45
+	BeamPT  PointOfSail = 90
+	BroadPT PointOfSail = 135
+	Run     PointOfSail = 180
+	BroadSB PointOfSail = 225
+	BeamSB  PointOfSail = 270
+	CloseSB PointOfSail = 315
+)
+
+var directionToString = map[Direction]string{
+	N:   "N",
+	NNE: "NNE",
+	NE:  "NE",
+	ENE: "ENE",
+	E:   "E",
+	ESE: "ESE",
+	SE:  "SE",
+	SSE: "SSE",
+	S:   "S",
+	SSW: "SSW",
+	SW:  "SW",
+	WSW: "WSW",
+	W:   "W",
+	WNW: "WNW",
+	NW:  "NW",
+	NNW: "NNW",
+	UNK: "UNK",
+}
+
+var WindDirections []string
+
+var posToString = map[PointOfSail]string{
+	Irons:   "irons",
+	ClosePT: "close reach port",
+	BeamPT:  "beam reach port",
+	BroadPT: "broad reach port",
+	Run:     "downwind run",
+	CloseSB: "close reach starboard",
+	BeamSB:  "beam reach starboard",
+	BroadSB: "broad reach starboard",
+}
+
+const (
+	starboard tack = 1
+	unknown   tack = 0
+	port      tack = -1
+
+	Upwind   WindAttitude = 1
+	Beam     WindAttitude = 0
+	Downwind WindAttitude = -1
+
+	// turn bits:
+	// 0 - FROM DOWNWIND=0 / UPWIND=1
+	// 1 - FROM PT=0 / SB=1
+	// 2 - TO DOWNWIND=0 / UPWIND=1
+	// 3 - TO PT=0 / SB=1
+	bearawayPT Turn = 0b0001
+	bearawaySB Turn = 0b1011
+	roundupPT  Turn = 0b0100
+	roundupSB  Turn = 0b1110
+
+	tackSBPT Turn = 0b0111
+	tackPTSB Turn = 0b1101
+	gybeSBPT Turn = 0b0010
+	gybePTSB Turn = 0b1000
+
+	drifting Turn = 0b0000
+)
+
+var turnToString = map[Turn]string{
+	bearawayPT: "bear away port",
+	bearawaySB: "bear away starboard",
+	roundupPT:  "round up port",
+	roundupSB:  "round up starboard",
+	tackPTSB:   "tack port to starboard",
+	tackSBPT:   "tack starboard to port",
+	gybePTSB:   "gybe port to starboard",
+	gybeSBPT:   "gybe starboard to port",
+	drifting:   "drifting",
+}
+
+var posToWindAttitude = map[PointOfSail]WindAttitude{
+	CloseSB: Upwind,
+	Irons:   Upwind,
+	ClosePT: Upwind,
+	BeamPT:  Beam,
+	BroadPT: Downwind,
+	Run:     Downwind,
+	BroadSB: Downwind,
+	BeamSB:  Beam,
+}
+
+var posToTack = map[PointOfSail]tack{
+	Irons:   unknown,
+	ClosePT: port,
+	BeamPT:  port,
+	BroadPT: port,
+	Run:     unknown,
+	BroadSB: starboard,
+	BeamSB:  starboard,
+	CloseSB: starboard,
+}
+
+// DirectionOf returns the compass direction closest to the heading.
+func DirectionOf(heading int) Direction {
+	idx := int(math.Floor((float64(heading) + 11.25) / 22.5))
+	if idx > 15 {
+		idx = 0
+	}
+	return []Direction{N, NNE, NE, ENE, E, ESE, SE, SSE, S, SSW, SW, WSW, W, WNW, NW, NNW}[idx]
+}
+
+// ParseDirection returns the direction of the name, e.g. NE, false if the name isn't known.
+func ParseDirection(name string) (Direction, bool) {
+	for d, s := range directionToString {
+		if s == name {
+			return d, true
+		}
+	}
+	return UNK, false
+}
+
+func (d Direction) String() string {
+	return directionToString[d]
+}
+
+// PointOfSail returns the segment type of the point of sail of the heading with the wind from this direction.
+func (windDirection Direction) PointOfSail(heading int) *SegmentType {
+	// adjust heading for wind direction
+	adjusted := heading - int(windDirection)
+	if adjusted < 0 {
+		adjusted = 360 + adjusted
+	}
+	idx := int(math.Floor((float64(adjusted) + 22.5) / 45))
+	if idx > 7 {
+		idx = 0
+	}
+	pos := []PointOfSail{Irons, ClosePT, BeamPT, BroadPT, Run, BroadSB, BeamSB, CloseSB}[idx]
+	return &SegmentType{WindDirection: windDirection, PointOfSail: pos}
+}
+
+// WindAngle returns the angle between the heading and the wind direction (0-180).
+func (windDirection Direction) WindAngle(heading int) int {
+	return abs(HeadingDiff(int(windDirection), heading))
+}
+
+// VMG returns the velocity made good, i.e. the component of the speed along the wind axis,
+// towards the wind when going upwind and away from the wind when going downwind.
+func (windDirection Direction) VMG(heading int, speed float64) float64 {
+	return math.Abs(speed * math.Cos(float64(windDirection.WindAngle(heading))*math.Pi/180))
+}
+
+// TurnType returns the segment type of the turn between the points of sail with the wind from this direction.
+func (windDirection Direction) TurnType(from, to *SegmentType) *SegmentType {
+	return &SegmentType{WindDirection: windDirection, Turn: turnType(from.PointOfSail, to.PointOfSail)}
+}
+
+func (pos PointOfSail) String() string {
+	return posToString[pos]
+}
+
+func (pos PointOfSail) windAttitude() WindAttitude {
+	return posToWindAttitude[pos]
+}
+
+func (pos PointOfSail) tack() tack {
+	return posToTack[pos]
+}
+
+type turns []Turn
+
+func (ts turns) set(bitValue int, ambiguous bool) turns {
+	var ts2 turns
+	if ambiguous {
+		ts2 = append(ts2, ts...)
+	}
+	for _, t := range ts {
+		ts2 = append(ts2, t+Turn(bitValue))
+	}
+	return ts2
+}
+
+func turnType(from, to PointOfSail) Turn {
+	toWA := to.windAttitude()
+	toTack := to.tack()
+	fromWA := from.windAttitude()
+	fromTack := from.tack()
+	// When tack or attitude is uncertain, we add both cases as candidates
+	var candidates turns = turns{0}
+	if toTack == starboard {
+		candidates = candidates.set(8, false)
+	} else if toTack == unknown {
+		candidates = candidates.set(8, true)
+	}
+	if toWA == Upwind {
+		candidates = candidates.set(4, false)
+	} else if toWA == Beam {
+		candidates = candidates.set(4, true)
+	}
+	if fromTack == starboard {
+		candidates = candidates.set(2, false)
+	} else if fromTack == unknown {
+		candidates = candidates.set(2, true)
+	}
+	if fromWA == Upwind {
+		candidates = candidates.set(1, false)
+	} else if fromWA == Beam {
+		candidates = candidates.set(1, true)
+	}
+	// See if any of the candidates are valid,
+	// return first one that is
+	for _, t := range candidates {
+		if _, found := turnToString[t]; found {
+			return t
+		}
+	}
+	// Otherwise return unknown
+	return 15
+}
+
+func (t Turn) windAttitude() WindAttitude {
+	if t&5 == 0 {
+		return Downwind
+	} else if t&5 == 5 {
+		return Upwind
+	} else {
+		return Beam
+	}
+}
+
+// changesTack tells whether the turn is a tack or a gybe.
+func (t Turn) changesTack() bool {
+	return t == tackPTSB || t == tackSBPT || t == gybePTSB || t == gybeSBPT
+}
+
+// Kind returns the kind of the turn (tack, gybe, roundup or bearaway), or empty string if unknown.
+func (t Turn) Kind() string {
+	switch t {
+	case tackPTSB, tackSBPT:
+		return "tack"
+	case gybePTSB, gybeSBPT:
+		return "gybe"
+	case roundupPT, roundupSB:
+		return "roundup"
+	case bearawayPT, bearawaySB:
+		return "bearaway"
+	}
+	return ""
+}
+
+func (t Turn) String() string {
+	if t, found := turnToString[t]; !found {
+		return "unknown"
+	} else {
+		return t
+	}
+}
+
+// SegmentType is the sailing classification of a segment, the point of sail of a moving segment,
+// or the turn of a turning segment, see Track.Classify.
+type SegmentType struct {
+	Turn
+	PointOfSail
+	WindDirection Direction
+}
+
+func (st *SegmentType) String() string {
+	if st.Turn == 0 {
+		return st.PointOfSail.String() + " " + st.WindDirection.String()
+	} else {
+		return st.Turn.String() + " " + st.WindDirection.String()
+	}
+}
+
+func (st *SegmentType) windAttitude() WindAttitude {
+	if st.Turn == 0 {
+		return st.PointOfSail.windAttitude()
+	} else {
+		return st.Turn.windAttitude()
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package track
 
 import (
 	"fmt"
@@ -8,7 +8,7 @@ import (
 func Test_Direction(t *testing.T) {
 	for i, tt := range []struct {
 		heading   int
-		direction direction
+		direction Direction
 	}{
 		{0, N},
 		{11, N},
@@ -46,7 +46,7 @@ func Test_Direction(t *testing.T) {
 		{359, N},
 	} {
 		t.Run(fmt.Sprintf("%d: %d %s", i, tt.heading, tt.direction.String()), func(t *testing.T) {
-			dir := Direction(tt.heading)
+			dir := DirectionOf(tt.heading)
 			if dir != tt.direction {
 				t.Errorf("exp: %s got: %s", tt.direction.String(), dir.String())
 			}
@@ -56,26 +56,26 @@ func Test_Direction(t *testing.T) {
 
 func Test_PointOfSail(t *testing.T) {
 	for i, tt := range []struct {
-		wind    direction
+		wind    Direction
 		heading int
-		pos     pointOfSail
+		pos     PointOfSail
 	}{
-		{N, 45, closePT},
-		{N, 90, beamPT},
-		{N, 135, broadPT},
-		{N, 180, run},
-		{N, 215, broadSB},
-		{N, 270, beamSB},
-		{N, 315, closeSB},
+		{N, 45, ClosePT},
+		{N, 90, BeamPT},
+		{N, 135, BroadPT},
+		{N, 180, Run},
+		{N, 215, BroadSB},
+		{N, 270, BeamSB},
+		{N, 315, CloseSB},
 
-		{NW, 90, broadPT},
-		{NW, 180, broadSB},
-		{S, 90, beamSB},
-		{S, 270, beamPT},
+		{NW, 90, BroadPT},
+		{NW, 180, BroadSB},
+		{S, 90, BeamSB},
+		{S, 270, BeamPT},
 	} {
 		t.Run(fmt.Sprintf("%d: %s %d", i, tt.wind.String(), tt.heading), func(t *testing.T) {
-			pos := tt.wind.pointOfSail(tt.heading)
-			if pos.pointOfSail != tt.pos {
+			pos := tt.wind.PointOfSail(tt.heading)
+			if pos.PointOfSail != tt.pos {
 				t.Errorf("exp %s got %s", tt.pos.String(), pos.String())
 			}
 		})
@@ -84,37 +84,41 @@ func Test_PointOfSail(t *testing.T) {
 
 func Test_TurnType(t *testing.T) {
 	for i, tt := range []struct {
-		from, to pointOfSail
-		turn     turn
+		from, to PointOfSail
+		turn     Turn
+		kind     string
 	}{
-		{closePT, closeSB, tackPTSB},
-		{closeSB, closePT, tackSBPT},
-		{closeSB, broadSB, bearawaySB},
-		{closePT, broadPT, bearawayPT},
-		{broadPT, broadSB, gybePTSB},
-		{broadSB, broadPT, gybeSBPT},
-		{broadSB, closeSB, roundupSB},
-		{broadPT, closePT, roundupPT},
+		{ClosePT, CloseSB, tackPTSB, "tack"},
+		{CloseSB, ClosePT, tackSBPT, "tack"},
+		{CloseSB, BroadSB, bearawaySB, "bearaway"},
+		{ClosePT, BroadPT, bearawayPT, "bearaway"},
+		{BroadPT, BroadSB, gybePTSB, "gybe"},
+		{BroadSB, BroadPT, gybeSBPT, "gybe"},
+		{BroadSB, CloseSB, roundupSB, "roundup"},
+		{BroadPT, ClosePT, roundupPT, "roundup"},
 	} {
 		t.Run(fmt.Sprintf("%d: %s-%s", i, tt.from, tt.to), func(t *testing.T) {
 			got := turnType(tt.from, tt.to)
 			if got != tt.turn {
 				t.Errorf("exp %s got %s", tt.turn, got)
 			}
+			if got.Kind() != tt.kind {
+				t.Errorf("exp %s got %s", tt.kind, got.Kind())
+			}
 		})
 	}
 }
 
 func Test_WindDirectionTurnType(t *testing.T) {
 	for i, tt := range []struct {
-		wind     direction
+		wind     Direction
 		from, to int
 		turn     string
 	}{
 		{N, 310, 40, "tack starboard to port N"},
 	} {
 		t.Run(fmt.Sprintf("%d: %d->%d@%s", i, tt.from, tt.to, tt.wind), func(t *testing.T) {
-			got := tt.wind.turnType(tt.wind.pointOfSail(tt.from), tt.wind.pointOfSail(tt.to))
+			got := tt.wind.TurnType(tt.wind.PointOfSail(tt.from), tt.wind.PointOfSail(tt.to))
 			if got.String() != tt.turn {
 				t.Errorf("exp %s got %s", tt.turn, got)
 			}
@@ -0,0 +1,205 @@
+package track
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// GPXSegments collects original segments from a GPX file.
+// Attaches the filename to the segments.
+func GPXSegments(g *gpx.GPX, filename string) (ss Segments) {
+	for i := range g.Tracks {
+		t := &g.Tracks[i]
+		for j := range t.Segments {
+			ss = append(ss, &Segment{GPX: &t.Segments[j], filename: filename, track: t})
+		}
+	}
+	return ss
+}
+
+// DedupeSegments removes subsequent segments with the same time bounds
+// and segments that have @min points or less.
+func DedupeSegments(ss Segments, min int) (t Segments) {
+	if len(ss) == 0 {
+		return
+	}
+	p := ss[0]
+	t = append(t, p)
+	for _, s := range ss[1:] {
+		points := s.GPX.GetTrackPointsNo()
+		bounds := s.GPX.TimeBounds()
+		pBounds := p.GPX.TimeBounds()
+		pPoints := p.GPX.GetTrackPointsNo()
+		if bounds.Equals(pBounds) && points == pPoints {
+			continue
+		}
+		if points > min {
+			t = append(t, s)
+		}
+		p = s
+	}
+	return t
+}
+
+// SplitSegments splits the segments where the time difference between points is more than @limit.
+func SplitSegments(ss Segments, limit time.Duration) (t Segments) {
+	for _, seg := range ss {
+		t = append(t, gpxSplitSegment(seg, limit)...)
+	}
+	return t
+}
+
+// BuildTracks reassembles tracks from subsequent original segments
+// with time bounds that are less than limit time apart.
+func BuildTracks(ss Segments, limit time.Duration) (tracks Tracks) {
+	if len(ss) == 0 {
+		return
+	}
+	p := ss[0]
+	t := gpxNewTrack(p)
+	t.AppendSegment(p.GPX)
+	sources := formats.Sources{}.Add(p.source)
+	for _, s := range ss[1:] {
+		if s.GPX.TimeBounds().StartTime.Sub(p.GPX.TimeBounds().EndTime) > limit {
+			tracks = append(tracks, Track{GPX: t, Filename: s.filename, sources: sources})
+			t = gpxNewTrack(s)
+			sources = nil
+		}
+		t.AppendSegment(s.GPX)
+		sources = sources.Add(s.source)
+		p = s
+	}
+	tracks = append(tracks, Track{GPX: t, Filename: p.filename, sources: sources})
+	return
+}
+
+// gpxNewTrack creates an empty track with the name, type, etc of the original track of the segment.
+func gpxNewTrack(s *Segment) *gpx.GPXTrack {
+	t := new(gpx.GPXTrack)
+	if s.track != nil {
+		*t = *s.track
+		t.Segments = nil
+	}
+	return t
+}
+
+// gpxSplit segment where the time difference between points is more than @limit.
+func gpxSplitSegment(s *Segment, limit time.Duration) Segments {
+	limitSeconds := limit.Seconds()
+	prev := s.gpxPoint(len(s.GPX.Points) - 1)
+	for i := len(s.GPX.Points) - 2; i >= 0; i-- {
+		next := s.gpxPoint(i)
+		if next.TimeDiff(prev) > limitSeconds {
+			s1, s2 := s.GPX.Split(i)
+			return append(gpxSplitSegment((&Segment{GPX: s1, filename: s.filename, source: s.source, track: s.track}), limit), &Segment{GPX: s2, filename: s.filename, source: s.source, track: s.track})
+		}
+		prev = next
+	}
+	return Segments{s}
+}
+
+func gpxEachPair(s *gpx.GPXTrackSegment, f func(prev, next *gpx.GPXPoint)) {
+	prev := &s.Points[0]
+	for i := 1; i < len(s.Points); i++ {
+		next := &s.Points[i]
+		f(prev, next)
+		prev = next
+	}
+}
+
+// AnalyzeSegment analyzes the segment and splits it up into runs of points of the same Mode of movement (static, moving, turning).
+// The Area is the context to use for the analysis derived from the Track, it is the same for all segments of the track.
+// See Analyzer for the analysis of the points one at a time.
+func AnalyzeSegment(s *gpx.GPXTrackSegment, filename string, a *Area, params *AnalysisParameters) Segments {
+	previousPt := &Point{GPX: &s.Points[0], params: params}
+	points := Points{previousPt}
+	gpxEachPair(s, func(prev, next *gpx.GPXPoint) {
+		nextPoint := &Point{
+			GPX:      next,
+			params:   params,
+			previous: previousPt,
+			Heading:  a.Heading(prev, next),
+			Distance: a.Distance(prev, next, params.DistanceUnit),
+			Speed:    a.Speed(prev, next, params.SpeedUnit),
+		}
+		previousPt.next = nextPoint
+		previousPt = nextPoint
+		points = append(points, nextPoint)
+	})
+	// Fix up movement params of the first point (we don't want the Speed and Heading to be zero)
+	first := points[0]
+	first.Speed = first.next.Speed
+	first.Heading = first.next.Heading
+	// Run point analysis (determine Mode and HeadingChange)
+	for _, p := range points {
+		p.Analyze(params)
+	}
+	// Create segments of points in the same Mode.
+	// Combine segments that are too short (< 5 points) with neighbouring segments.
+	segments := Segments{}
+	var previousSeg *Segment
+	var short Points // holds previous run that was too short
+	points.eachRun(func(run Points, mode Mode) {
+		// join stashed previous short run
+		run = append(short, run...)
+		// if run was short then recompute mode
+		if len(run)/2 < len(short) {
+			mode = run.mode()
+		}
+		// if we are still short stash it and continue
+		if len(run) < 5 {
+			short = run
+			return
+		}
+		short = nil // clear the short stash
+		segment := SegmentFromPoints(run, mode, filename, params)
+		segments = append(segments, segment)
+		if previousSeg != nil {
+			previousSeg.next = segment
+			segment.previous = previousSeg
+		}
+		previousSeg = segment
+	})
+	if short != nil {
+		// We were left with a shortie at the end, append it to the last segment.
+		lastIndex := len(segments) - 1
+		last := segments[lastIndex]
+		segments[lastIndex] = SegmentFromPoints(append(last.Points, short...), last.Mode, filename, params)
+	}
+	return segments
+}
+
+// SourceSegments returns the original segments of the source file.
+func SourceSegments(src *formats.Source) Segments {
+	ss := GPXSegments(src.GPX, src.Filename)
+	for _, s := range ss {
+		s.source = src
+	}
+	return ss
+}
+
+// Collect reassembles the tracks from the original segments of the source files, e.g. from SourceSegments.
+// Drops duplicate segments and segments with @min points or less, splits the segments at gaps longer than an hour
+// and joins subsequent segments less than an hour apart into tracks. Returns the tracks and the number of dropped segments.
+func Collect(ss Segments, min int) (Tracks, int) {
+	sort.Sort(ss)
+	n := len(ss)
+	ss = DedupeSegments(ss, min)
+	ss = SplitSegments(ss, time.Hour)
+	ss = DedupeSegments(ss, min)
+	return BuildTracks(ss, time.Hour), n - len(ss)
+}
+
+// AnalyzeGPX collects the tracks of the GPX document (see Collect) and analyzes them with the activity parameters,
+// followed by point of sail analysis if the wind direction is not nil (see Track.Analyze).
+// The segments with @min points or less are dropped.
+func AnalyzeGPX(g *gpx.GPX, min int, activity Activity, wind *Direction) Tracks {
+	tracks, _ := Collect(GPXSegments(g, ""), min)
+	for i := range tracks {
+		tracks[i].Analyze(activity, wind)
+	}
+	return tracks
+}
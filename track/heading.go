@@ -1,4 +1,4 @@
-package main
+package track
 
 import (
 	"fmt"
@@ -17,7 +17,7 @@ func NewHeadingRange(min, max int) *HeadingRange {
 	dist := headingDist(min, max)
 	return &HeadingRange{
 		Min:       min,
-		Mid:       headingAdd(min, dist/2),
+		Mid:       HeadingAdd(min, dist/2),
 		Max:       max,
 		Variation: dist,
 	}
@@ -1,4 +1,4 @@
-package main
+package track
 
 import (
 	"fmt"
@@ -7,6 +7,7 @@ import (
 	"github.com/tkrajina/gpxgo/gpx"
 )
 
+// Mode is the mode of movement of a point or a segment.
 type Mode string
 
 const (
@@ -18,7 +19,7 @@ const (
 // Point is a track point with computed motion parameters and analysis results.
 // Note that the starting point has zero Speed and Heading!
 type Point struct {
-	gpx *gpx.GPXPoint
+	GPX *gpx.GPXPoint
 	// Analysis results
 	params        *AnalysisParameters
 	previous      *Point  // previous point on the track
@@ -26,32 +27,33 @@ type Point struct {
 	Speed         float64 // speed from previous point
 	Heading       int     // heading from previous point
 	Distance      float64 // distance from previous point
-	HeadingChange int     // how much does the heading change in the lookAround range
+	HeadingChange int     // how much does the heading change in the LookAround range
 	Mode          Mode
 }
 
 func (p *Point) String() string {
-	return fmt.Sprintf("%0.1fm @ %0.1f %s \u2191 %d\u00b0 %s < %d (%s)", p.Distance, p.Speed, p.params.speedUnit.speed(), p.Heading, Direction(p.Heading).String(), p.HeadingChange, p.Mode)
+	return fmt.Sprintf("%0.1fm @ %0.1f %s \u2191 %d\u00b0 %s < %d (%s)", p.Distance, p.Speed, p.params.SpeedUnit.speed(), p.Heading, DirectionOf(p.Heading).String(), p.HeadingChange, p.Mode)
 }
 
 func (p *Point) ShortString() string {
-	return fmt.Sprintf("%0.1fm @ %0.1f %s \u2191 %d\u00b0 %s", p.Distance, p.Speed, p.params.speedUnit.speed(), p.Heading, Direction(p.Heading).String())
+	return fmt.Sprintf("%0.1fm @ %0.1f %s \u2191 %d\u00b0 %s", p.Distance, p.Speed, p.params.SpeedUnit.speed(), p.Heading, DirectionOf(p.Heading).String())
 }
 
+// Analyze computes the speed, heading and distance of the point from the previous point.
 func (p *Point) Analyze(params *AnalysisParameters) {
 	var speed float64
 	if p.previous == nil {
-		p.HeadingChange = p.next.headingChange(0, params.lookAround-p.next.Distance, true, params.movingSpeed)
+		p.HeadingChange = p.next.headingChange(0, params.LookAround-p.next.Distance, true, params.MovingSpeed)
 		speed = p.next.Speed
 	} else {
-		p.HeadingChange = p.headingChange(0, params.lookAround, true, params.movingSpeed) - p.headingChange(0, params.lookAround, false, params.movingSpeed)
+		p.HeadingChange = p.headingChange(0, params.LookAround, true, params.MovingSpeed) - p.headingChange(0, params.LookAround, false, params.MovingSpeed)
 		speed = p.Speed
 	}
-	if speed < params.movingSpeed {
+	if speed < params.MovingSpeed {
 		p.Mode = Static
 		return
 	}
-	if abs(p.HeadingChange) < params.turningChange {
+	if abs(p.HeadingChange) < params.TurningChange {
 		p.Mode = Moving
 	} else {
 		p.Mode = Turning
@@ -68,7 +70,7 @@ func (p *Point) headingChange(change int, distance float64, forward bool, moving
 	if next == nil || next.Speed < movingSpeed {
 		return change
 	}
-	change += headingDiff(p.Heading, next.Heading)
+	change += HeadingDiff(p.Heading, next.Heading)
 	distance -= next.Distance
 	if distance <= 0 {
 		return change
@@ -78,16 +80,14 @@ func (p *Point) headingChange(change int, distance float64, forward bool, moving
 
 type Points []*Point
 
+// mode returns the most common Mode of the points, the one that got there first if there are more.
 func (ps Points) mode() Mode {
 	modes := make(map[Mode]int)
+	var maxm Mode
 	for _, p := range ps {
 		modes[p.Mode] += 1
-	}
-	var maxn int
-	var maxm Mode
-	for m, n := range modes {
-		if n > maxn {
-			maxm = m
+		if modes[p.Mode] > modes[maxm] {
+			maxm = p.Mode
 		}
 	}
 	return maxm
@@ -132,11 +132,11 @@ func (ps Points) duration() time.Duration {
 }
 
 func (ps Points) end() time.Time {
-	return ps[len(ps)-1].gpx.Timestamp
+	return ps[len(ps)-1].GPX.Timestamp
 }
 
 func (ps Points) start() time.Time {
-	return ps[0].gpx.Timestamp
+	return ps[0].GPX.Timestamp
 }
 
 func (ps Points) headingRange(mode Mode) *HeadingRange {
@@ -153,10 +153,10 @@ func (ps Points) headingRange(mode Mode) *HeadingRange {
 		if p.Mode != mode {
 			continue
 		}
-		diff := headingDiff(prev.Heading, p.Heading)
-		if diff < 0 && headingDiff(p.Heading, min) > 0 {
+		diff := HeadingDiff(prev.Heading, p.Heading)
+		if diff < 0 && HeadingDiff(p.Heading, min) > 0 {
 			min = p.Heading
-		} else if diff > 0 && headingDiff(p.Heading, max) < 0 {
+		} else if diff > 0 && HeadingDiff(p.Heading, max) < 0 {
 			max = p.Heading
 		}
 		prev = p
@@ -167,20 +167,7 @@ func (ps Points) headingRange(mode Mode) *HeadingRange {
 // Split points into longest runs by mode.
 func (ps Points) eachRun(f func(run Points, mode Mode)) {
 	for {
-		mode := ps[0].Mode
-		var i int
-		for i = 1; i < len(ps) && ps[i].Mode == mode; i++ {
-		}
-		// Don't want segments with single point
-		if i == 1 && len(ps) > 1 {
-			mode := ps[1].Mode
-			for i = 2; i < len(ps) && ps[i].Mode == mode; i++ {
-			}
-		}
-		// Check that we're not left with a single point run at the end
-		if i+1 == len(ps) {
-			i += 1
-		}
+		i, mode, _ := ps.nextRun(true)
 		f(ps[0:i], mode)
 		if i == len(ps) {
 			break
@@ -189,9 +176,34 @@ func (ps Points) eachRun(f func(run Points, mode Mode)) {
 	}
 }
 
-// Difference in degrees from heading a to heading b (-179 ... 180),
+// nextRun returns the length and the mode of the first run of the points (see eachRun).
+// Unless the points are @complete, more points may follow and it returns false if they could change the run.
+func (ps Points) nextRun(complete bool) (int, Mode, bool) {
+	runEnd := func(start int) int {
+		i := start + 1
+		for ; i < len(ps) && ps[i].Mode == ps[start].Mode; i++ {
+		}
+		return i
+	}
+	mode := ps[0].Mode
+	i := runEnd(0)
+	// Don't want segments with single point
+	if i == 1 && len(ps) > 1 {
+		i = runEnd(1)
+	}
+	if !complete && i+1 >= len(ps) {
+		return 0, mode, false
+	}
+	// Check that we're not left with a single point run at the end
+	if i+1 == len(ps) {
+		i += 1
+	}
+	return i, mode, true
+}
+
+// HeadingDiff returns the difference in degrees from heading a to heading b (-179 ... 180),
 // positive if turning clockwise, negative counter-clockwise.
-func headingDiff(a, b int) int {
+func HeadingDiff(a, b int) int {
 	diff := b - a
 	if diff > 180 {
 		diff -= 360
@@ -203,8 +215,8 @@ func headingDiff(a, b int) int {
 
 // Is heading h between heading min and max inclusive (clockwise)
 func headingBetween(h, min, max int) bool {
-	minDiff := headingDiff(h, min)
-	maxDiff := headingDiff(h, max)
+	minDiff := HeadingDiff(h, min)
+	maxDiff := HeadingDiff(h, max)
 	if headingDist(min, max) < 180 {
 		return minDiff <= 0 && maxDiff >= 0
 	} else {
@@ -214,15 +226,15 @@ func headingBetween(h, min, max int) bool {
 
 // The distance in degrees between min and max inclusive (0-359) (clockwise)
 func headingDist(min, max int) int {
-	diff := headingDiff(min, max)
+	diff := HeadingDiff(min, max)
 	if diff >= 0 {
 		return diff
 	}
 	return 360 + diff
 }
 
-// Add diff d (-179...180) to heading h
-func headingAdd(h, d int) int {
+// HeadingAdd adds diff d (-179...180) to heading h
+func HeadingAdd(h, d int) int {
 	h2 := h + d
 	if h2 > 359 {
 		return h2 - 360
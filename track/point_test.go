@@ -1,4 +1,4 @@
-package main
+package track
 
 import (
 	"fmt"
@@ -24,7 +24,7 @@ func Test_HeadingDiff(t *testing.T) {
 		{340, 159, 179},
 	} {
 		t.Run(fmt.Sprintf("%d: %d - %d", i, tt.a, tt.b), func(t *testing.T) {
-			got := headingDiff(tt.a, tt.b)
+			got := HeadingDiff(tt.a, tt.b)
 			if tt.diff != got {
 				t.Errorf("exp %d got %d", tt.diff, got)
 			}
@@ -73,7 +73,7 @@ func Test_HeadingAdd(t *testing.T) {
 		{240, -179, 61},
 	} {
 		t.Run(fmt.Sprintf("%d: %d - %d", i, tt.h, tt.diff), func(t *testing.T) {
-			got := headingAdd(tt.h, tt.diff)
+			got := HeadingAdd(tt.h, tt.diff)
 			if tt.result != got {
 				t.Errorf("exp %d got %d", tt.result, got)
 			}
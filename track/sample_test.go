@@ -0,0 +1,28 @@
+package track
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+// readTrackSample is tracktest.ReadTrack for the tests of this package, which can't import it (import cycle).
+func readTrackSample(t *testing.T, data string) *Track {
+	g := testutil.ParseSample(t, data)
+	ss := GPXSegments(g, "")
+	ts := BuildTracks(ss, time.Hour)
+	if len(ts) != 1 {
+		t.Errorf("found %d tracks", len(ts))
+	}
+	return &ts[0]
+}
+
+func logTrackPoints(t *testing.T, trk *Track) {
+	for _, s := range trk.Segments {
+		t.Log(s.String())
+		for _, p := range s.Points {
+			t.Log(p.String())
+		}
+	}
+}
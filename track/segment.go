@@ -1,4 +1,4 @@
-package main
+package track
 
 import (
 	"fmt"
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/bradfitz/latlong"
+	"github.com/mkobetic/gpx/formats"
 	"github.com/tkrajina/gpxgo/gpx"
 )
 
@@ -16,7 +17,7 @@ type SpeedRange struct {
 	Max float64
 }
 
-func (s *SpeedRange) String(unit unit) string {
+func (s *SpeedRange) String(unit Unit) string {
 	return fmt.Sprintf("%.1f/%.1f/%.1f %s", s.Avg, s.Min, s.Max, unit.speed())
 }
 
@@ -24,8 +25,10 @@ func (s *SpeedRange) String(unit unit) string {
 // This segment type is represented by its Mode.
 // As such a neighboring segments in a track should always have different Mode.
 type Segment struct {
-	gpx      *gpx.GPXTrackSegment
+	GPX      *gpx.GPXTrackSegment
 	filename string
+	source   *formats.Source // original file of the segment
+	track    *gpx.GPXTrack   // original track of the segment
 	// Analysis results
 	params   *AnalysisParameters
 	Points   Points
@@ -42,14 +45,15 @@ type Segment struct {
 	Type fmt.Stringer
 }
 
+// SegmentFromPoints returns a segment of the points with the mode, the points are copied into the gpx segment.
 func SegmentFromPoints(ps Points, mode Mode, filename string, params *AnalysisParameters) *Segment {
 	gpxPoints := make([]gpx.GPXPoint, len(ps))
 	for i, p := range ps {
-		gpxPoints[i] = *p.gpx
+		gpxPoints[i] = *p.GPX
 	}
 
 	return &Segment{
-		gpx:      &gpx.GPXTrackSegment{Points: gpxPoints},
+		GPX:      &gpx.GPXTrackSegment{Points: gpxPoints},
 		filename: filename,
 		params:   params,
 		Points:   ps,
@@ -65,7 +69,7 @@ func SegmentFromPoints(ps Points, mode Mode, filename string, params *AnalysisPa
 
 // gpxPoint returns i-th point of the segment.
 func (s *Segment) gpxPoint(i int) *gpx.GPXPoint {
-	return &s.gpx.Points[i]
+	return &s.GPX.Points[i]
 }
 
 // EachPair iterates over a segment with pairs of subsequent points.
@@ -78,7 +82,7 @@ func (s *Segment) EachPair(f func(prev, next *Point)) {
 }
 
 func (s *Segment) Timezone() *time.Location {
-	b := s.gpx.Bounds()
+	b := s.GPX.Bounds()
 	var err error
 	tz, err := time.LoadLocation(latlong.LookupZoneName(b.MinLatitude, b.MinLongitude))
 	if err != nil {
@@ -88,13 +92,13 @@ func (s *Segment) Timezone() *time.Location {
 }
 
 func (s *Segment) gpxString() string {
-	tb := s.gpx.TimeBounds()
+	tb := s.GPX.TimeBounds()
 	return fmt.Sprintf("%s @ %s = %05.2f%s (%d)",
 		tb.EndTime.Sub(tb.StartTime),
-		tb.StartTime.In(s.Timezone()).Format(strFormat),
-		s.params.longDistanceUnit.convertDistance(s.gpx.Length2D(), meter),
-		s.params.distance(),
-		s.gpx.GetTrackPointsNo(),
+		tb.StartTime.In(s.Timezone()).Format(TimeFormat),
+		s.params.LongDistanceUnit.convertDistance(s.GPX.Length2D(), Meter),
+		s.params.DistanceLabel(),
+		s.GPX.GetTrackPointsNo(),
 	)
 }
 
@@ -115,7 +119,7 @@ func (s *Segment) String() string {
 	moving, turning, static := s.ModeCounts()
 	return fmt.Sprintf("%.0fm/%.0fs @ %.1f/%.1f/%.1f %s \u2191 %d\u00b0/%d\u00b0 < %d\u00b0 %s (M:%d/T:%d/S:%d)",
 		s.Distance, s.Duration.Seconds(),
-		s.Speed.Min, s.Speed.Avg, s.Speed.Max, s.params.speedUnit.speed(),
+		s.Speed.Min, s.Speed.Avg, s.Speed.Max, s.params.SpeedUnit.speed(),
 		s.Heading.Min, s.Heading.Max, s.Heading.Variation,
 		s.Mode, moving, turning, static)
 }
@@ -123,7 +127,7 @@ func (s *Segment) String() string {
 func (s *Segment) ShortString() string {
 	return fmt.Sprintf("%.0fm/%.0fs @ %.1f/%.1f/%.1f %s \u2191 %d\u00b0/%d\u00b0 %s",
 		s.Distance, s.Duration.Seconds(),
-		s.Speed.Min, s.Speed.Avg, s.Speed.Max, s.params.speedUnit.speed(),
+		s.Speed.Min, s.Speed.Avg, s.Speed.Max, s.params.SpeedUnit.speed(),
 		s.Heading.Min, s.Heading.Max,
 		s.Mode)
 }
@@ -135,23 +139,50 @@ func (s *Segment) TypeString() string {
 	return s.Type.String()
 }
 
-func (s *Segment) windAttitude() windAttitude {
+// ManeuverTurn is the minimum heading change of a maneuver when the segments are not classified by point of sail.
+const ManeuverTurn = 60
+
+// IsManeuver tells whether the segment is a tack or a gybe,
+// or a turn of at least ManeuverTurn degrees if the point of sail is unknown.
+func (s *Segment) IsManeuver() bool {
+	if s.Mode != Turning {
+		return false
+	}
 	if st, ok := s.Type.(*SegmentType); ok {
-		return st.windAttitude()
+		return st.Turn.changesTack()
 	}
-	return beam
+	return s.Heading.Variation >= ManeuverTurn
 }
 
-func (s *Segment) Timeline(offset int) string {
-	points := []string{fmt.Sprintf("%d,%d", offset, tlHeight)}
-	for _, p := range s.Points {
-		x := int(p.gpx.Timestamp.Sub(s.Start).Seconds())
-		y := tlHeight - int(p.Speed)*(tlUnitHeight)
-		points = append(points, fmt.Sprintf("%d,%d", offset+x, y))
+// ManeuverKind returns the kind of the turn of a turning segment (see Turn.Kind),
+// "turn" for a maneuver if the point of sail is unknown, or empty string otherwise.
+func (s *Segment) ManeuverKind() string {
+	if s.Mode != Turning {
+		return ""
+	}
+	if st, ok := s.Type.(*SegmentType); ok {
+		return st.Turn.Kind()
 	}
-	x := int(s.Duration.Seconds())
-	points = append(points, fmt.Sprintf("%d,%d", offset+x, tlHeight))
-	return strings.Join(points, " ")
+	if s.IsManeuver() {
+		return "turn"
+	}
+	return ""
+}
+
+// TypeKey returns the activity specific segment type, or the segment mode if the type is unknown.
+func (s *Segment) TypeKey() string {
+	if st := s.TypeString(); st != "" {
+		return st
+	}
+	return string(s.Mode)
+}
+
+// WindAttitude returns the wind attitude of the segment type, Beam if the segment isn't classified.
+func (s *Segment) WindAttitude() WindAttitude {
+	if st, ok := s.Type.(*SegmentType); ok {
+		return st.windAttitude()
+	}
+	return Beam
 }
 
 type Segments []*Segment
@@ -164,11 +195,38 @@ func (ss Segments) gpxString() string {
 	return strings.Join(all, "\n")
 }
 
+// average returns the average of the value of the selected segments weighted by their duration.
+func (ss Segments) average(selected func(s *Segment) bool, value func(s *Segment) float64) float64 {
+	var sum float64
+	var duration time.Duration
+	for _, s := range ss {
+		if selected(s) {
+			sum += value(s) * float64(s.Duration)
+			duration += s.Duration
+		}
+	}
+	if duration == 0 {
+		return 0
+	}
+	return sum / float64(duration)
+}
+
+// total returns the total duration and distance of the selected segments.
+func (ss Segments) total(selected func(s *Segment) bool) (duration time.Duration, distance float64) {
+	for _, s := range ss {
+		if selected(s) {
+			duration += s.Duration
+			distance += s.Distance
+		}
+	}
+	return duration, distance
+}
+
 // Sort segments by start time
 func (s Segments) Len() int      { return len(s) }
 func (s Segments) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 func (s Segments) Less(i, j int) bool {
-	return s[i].gpx.TimeBounds().StartTime.Before(s[j].gpx.TimeBounds().StartTime)
+	return s[i].GPX.TimeBounds().StartTime.Before(s[j].GPX.TimeBounds().StartTime)
 }
 
 func (ss Segments) EachPair(f func(prev *Point, next *Point)) {
@@ -1,4 +1,4 @@
-package main
+package track
 
 import (
 	"testing"
@@ -12,16 +12,16 @@ func Test_Split(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	ss := gpxGetSegments(g, "")
+	ss := GPXSegments(g, "")
 	if len(ss) != 1 {
 		t.Fail()
 	}
-	ss = gpxSplitSegments(ss, time.Hour)
+	ss = SplitSegments(ss, time.Hour)
 	if len(ss) != 2 {
 		t.Error(ss)
 	}
 	t.Log("\n", ss)
-	ts := gpxBuildTracks(ss, time.Hour)
+	ts := BuildTracks(ss, time.Hour)
 	if len(ts) != 2 {
 		t.Error(ts)
 	}
@@ -29,16 +29,16 @@ func Test_Split(t *testing.T) {
 }
 
 func Test_Analysis(t *testing.T) {
-	g, err := gpx.ParseFile("samples/out/160604-1h23-04.9nm.gpx")
+	g, err := gpx.ParseFile("../samples/out/160604-1h23-04.9nm.gpx")
 	if err != nil {
 		t.Error(err)
 	}
-	ss := gpxGetSegments(g, "")
-	ts := gpxBuildTracks(ss, time.Hour)
+	ss := GPXSegments(g, "")
+	ts := BuildTracks(ss, time.Hour)
 	if len(ts) != 1 {
 		t.Errorf("found %d tracks", len(ts))
 	}
-	ts[0].gpxAnalyze(Sailing)
+	ts[0].gpxAnalyze(Sailing.Params())
 }
 
 var prostart = []byte(`
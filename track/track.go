@@ -0,0 +1,284 @@
+// Package track reassembles the tracks from the segments of the GPS sources,
+// analyzes the speed, heading and mode of movement of their points
+// and classifies their segments by the activity, e.g. by the point of sail.
+//
+// AnalyzeGPX is the simplest entry point, it analyzes the tracks of a parsed GPX file.
+package track
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/latlong"
+	"github.com/mkobetic/gpx/formats"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+const fnFormat = "060102"
+const TimeFormat = "06-01-02 15:04:05"
+
+// Track is a track reassembled from the segments of the source files (see Collect),
+// along with the results of its analysis (see Track.Analyze).
+type Track struct {
+	GPX      *gpx.GPXTrack
+	tz       *time.Location
+	Filename string          // file from which the track was collected
+	sources  formats.Sources // original files of the track segments
+	// Analysis results
+	Activity Activity
+	Params   *AnalysisParameters
+	Segments Segments
+	Distance float64
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Wind     Direction // wind direction used for point of sail classification (UNK if not classified)
+}
+
+// WriteGpxFile generates track's GPX file into the specified directory.
+// The metadata of the original file and the extensions of the points are carried over.
+func (t *Track) WriteGpxFile(dir string) error {
+	f, err := os.Create(filepath.Join(dir, t.FileName()+".gpx"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return formats.WriteGPX(f, t.GPX, t.sources)
+}
+
+// GPXPoints returns the points of all the segments of the track.
+func (t *Track) GPXPoints() (points []*gpx.GPXPoint) {
+	for _, s := range t.Segments {
+		for _, p := range s.Points {
+			points = append(points, p.GPX)
+		}
+	}
+	return points
+}
+
+// Timezone returns the timezone for track's location.
+func (t *Track) Timezone() *time.Location {
+	if t.tz != nil {
+		return t.tz
+	}
+	b := t.GPX.Bounds()
+	lat := (b.MaxLatitude + b.MinLatitude) / 2
+	lon := (b.MaxLongitude + b.MinLongitude) / 2
+	var err error
+	t.tz, err = time.LoadLocation(latlong.LookupZoneName(lat, lon))
+	if err != nil {
+		t.tz = time.UTC
+	}
+	return t.tz
+}
+
+// FileName generates a file name based on track's time bounds and length.
+func (t *Track) FileName() string {
+	return fmt.Sprintf("%s-%dh%02d-%04.1f%s",
+		t.Start.In(t.Timezone()).Format(fnFormat),
+		int(t.Duration.Hours()),
+		int(t.Duration.Minutes())%60,
+		t.Params.AsLongDistance(t.Distance),
+		t.Params.LongDistanceLabel())
+}
+
+// Extent returns box dimensions of the track in specified units.
+func (t *Track) Extent(unit Unit) (width, height float64) {
+	b := t.GPX.Bounds()
+	height = (b.MaxLongitude - b.MinLongitude) * NewArea(b).Coef * float64(unit)
+	width = (b.MaxLatitude - b.MinLatitude) * float64(unit)
+	return width, height
+}
+
+// String returns track description.
+func (t *Track) String() string {
+	tb := t.GPX.TimeBounds()
+	w, h := t.Extent(t.Params.LongDistanceUnit)
+	unit := t.Params.LongDistanceLabel()
+	return fmt.Sprintf("%s %05.2f%s %05.2f%s x %05.2f%s (%s) [%d segments]",
+		tb.StartTime.In(t.Timezone()).Format(TimeFormat),
+		t.Params.AsLongDistance(t.Distance),
+		unit,
+		w,
+		unit,
+		h,
+		unit,
+		tb.EndTime.Sub(tb.StartTime),
+		len(t.Segments),
+	)
+}
+
+// TimeRange is a time interval of a track, see Track.Crop. Zero From or To leaves that end of the track as is.
+// The times with only the time of day (year 0) are on the day of the track start in the track timezone.
+type TimeRange struct {
+	From, To time.Time
+}
+
+// ParseCropTime parses a time of day in the track timezone, e.g. 15:04 or 15:04:05,
+// or a full timestamp in RFC 3339 format, e.g. 2016-05-25T18:05:00-04:00.
+func ParseCropTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.TimeOnly, "15:04", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: expected time of day (15:04:05) or timestamp (2006-01-02T15:04:05Z07:00)", s)
+}
+
+// IsZero tells whether the range covers the whole track.
+func (r TimeRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// resolve returns the times of the range for the track, resolving the times of day.
+// A time of day of the end before the start is on the next day, i.e. the range spans midnight.
+func (r TimeRange) resolve(t *Track) (from, to time.Time) {
+	tz := t.Timezone()
+	y, m, d := t.GPX.TimeBounds().StartTime.In(tz).Date()
+	at := func(c time.Time) time.Time {
+		if c.IsZero() || c.Year() != 0 {
+			return c
+		}
+		return time.Date(y, m, d, c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), tz)
+	}
+	from, to = at(r.From), at(r.To)
+	if r.To.Year() == 0 && !from.IsZero() && to.Before(from) {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to
+}
+
+// Crop drops the points of the track outside of the time range, and the segments left with @min points or less,
+// the same as DedupeSegments drops them.
+// The track must be cropped before the analysis. Returns false if there's nothing left of the track.
+func (t *Track) Crop(r TimeRange, min int) bool {
+	from, to := r.resolve(t)
+	cropped := *t.GPX
+	cropped.Segments = nil
+	for _, s := range t.GPX.Segments {
+		var points []gpx.GPXPoint
+		for _, p := range s.Points {
+			if !from.IsZero() && p.Timestamp.Before(from) || !to.IsZero() && p.Timestamp.After(to) {
+				continue
+			}
+			points = append(points, p)
+		}
+		if len(points) > min {
+			cropped.AppendSegment(&gpx.GPXTrackSegment{Points: points})
+		}
+	}
+	if len(cropped.Segments) == 0 {
+		return false
+	}
+	t.GPX = &cropped
+	return true
+}
+
+// Analyze analyzes the track using the activity parameters, followed by the classification of the segments by the activity.
+// The activities powered by the wind classify the segments by the point of sail if the wind direction is not nil.
+// Wind direction UNK is determined from the track, returns false if that fails and the point of sail analysis is skipped.
+func (t *Track) Analyze(activity Activity, wind *Direction) bool {
+	t.gpxAnalyze(activity.Params())
+	t.Activity = activity
+	return activity.Classify(t, wind)
+}
+
+// Metrics returns the activity specific summary of the analyzed track, see Activity.Metrics.
+func (t *Track) Metrics() []Metric {
+	if t.Activity == nil {
+		return nil
+	}
+	return t.Activity.Metrics(t)
+}
+
+func (t *Track) gpxAnalyze(params *AnalysisParameters) {
+	t.Params = params
+	var segments Segments
+	area := NewArea(t.GPX.Bounds())
+	for i := range t.GPX.Segments {
+		segment := &t.GPX.Segments[i]
+		segments = append(segments, AnalyzeSegment(segment, t.Filename, area, params)...)
+	}
+	var distance float64
+	for _, s := range segments {
+		distance += s.Distance
+	}
+	t.Segments = segments
+	analyzed := *t.GPX
+	analyzed.Segments = nil
+	t.GPX = &analyzed
+	for _, s := range t.Segments {
+		t.GPX.AppendSegment(s.GPX)
+	}
+	t.Start = segments[0].Start
+	t.End = segments[len(segments)-1].End
+	t.Duration = t.End.Sub(t.Start)
+	t.Distance = distance
+	t.Wind = UNK
+}
+
+// Classify classifies the segments of the analyzed track by the point of sail with the wind from the direction,
+// see SegmentType.
+func (t *Track) Classify(windDirection Direction) {
+	t.Wind = windDirection
+	for _, s := range t.Segments {
+		if s.Mode == Moving {
+			s.Type = windDirection.PointOfSail(s.Heading.Mid)
+		} else if s.Mode == Turning {
+			from := windDirection.PointOfSail(s.Points[0].Heading)
+			to := windDirection.PointOfSail(s.Points[len(s.Points)-1].Heading)
+			s.Type = windDirection.TurnType(from, to)
+		} else {
+			s.Type = drifting
+		}
+	}
+}
+
+// Try to determine the prevailing wind direction by
+// combining heading ranges of all moving segments,
+// finding the gaps that are more than 80 degrees wide,
+// and picking the one that includes the defaultDirection,
+// or picking the largest one.
+func (t *Track) windDirection() Direction {
+	headings := HeadingSet{}
+	for _, s := range t.Segments {
+		if s.Speed.Min < 2*t.Params.MovingSpeed {
+			continue
+		}
+		if _, turning, static := s.ModeCounts(); turning+static > 0 {
+			continue
+		}
+		headings = headings.Add(s.Heading)
+		// fmt.Println(s.String())
+		// fmt.Println(headings.String())
+	}
+
+	var candidates HeadingSet
+	for _, hr := range headings.Inverse() {
+		if hr.Variation > 60 {
+			candidates = append(candidates, hr)
+		}
+	}
+	if len(candidates) == 0 {
+		return UNK
+	}
+	// sort by range width
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Variation > candidates[j].Variation
+	})
+	return DirectionOf(candidates[0].Mid)
+}
+
+type Tracks []Track
+
+func (ts Tracks) String() string {
+	var ss []string
+	for _, t := range ts {
+		ss = append(ss, t.String())
+	}
+	return strings.Join(ss, "\n")
+}
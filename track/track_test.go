@@ -0,0 +1,101 @@
+package track
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+func Test_Crop(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		points   int
+	}{
+		{"", "", 23},
+		// the track is at 19:09:56 to 19:11:26 UTC, 15:09:56 to 15:11:26 in the track timezone
+		{"15:10", "15:11", 16},
+		{"15:10:30", "", 11},
+		{"", "2024-08-24T19:10:16Z", 6},
+		// too few points left, exactly the minimum is dropped like in DedupeSegments
+		{"", "2024-08-24T19:10:12Z", 0},
+		{"", "2024-08-24T19:10:11Z", 0},
+		{"15:12", "", 0},
+	} {
+		t.Run(tc.from+"-"+tc.to, func(t *testing.T) {
+			var r TimeRange
+			if tc.from != "" {
+				r.From, _ = ParseCropTime(tc.from)
+			}
+			if tc.to != "" {
+				r.To, _ = ParseCropTime(tc.to)
+			}
+			trk := readTrackSample(t, testutil.Turn1)
+			if !trk.Crop(r, 5) {
+				testutil.AssertEqual(t, tc.points, 0)
+				return
+			}
+			testutil.AssertEqual(t, trk.GPX.GetTrackPointsNo(), tc.points)
+			trk.gpxAnalyze(Sailing.Params())
+			testutil.AssertEqual(t, len(trk.GPXPoints()), tc.points)
+		})
+	}
+}
+
+func Test_ParseCropTime(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{"15:04", "0000-01-01T15:04:00Z"},
+		{"15:04:05", "0000-01-01T15:04:05Z"},
+		{"2016-05-25T18:05:00-04:00", "2016-05-25T18:05:00-04:00"},
+		{"3pm", "error"},
+	} {
+		tm, err := ParseCropTime(tc.in)
+		out := tm.Format(time.RFC3339)
+		if err != nil {
+			out = "error"
+		}
+		testutil.AssertEqual(t, out, tc.out)
+	}
+}
+
+func Test_WriteGpxFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "garmin.gpx")
+	if err := os.WriteFile(fn, []byte(testutil.Garmin), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := formats.ReadSource(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks := BuildTracks(SourceSegments(src), time.Hour)
+	trk := tracks[0]
+	trk.gpxAnalyze(Sailing.Params())
+	dir := t.TempDir()
+	if err := trk.WriteGpxFile(dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, trk.FileName()+".gpx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	testutil.AssertEqual(t, strings.Count(out, "<trkpt "), 23)
+	// the metadata and the point extensions of the source are carried over
+	testutil.AssertEqual(t, strings.Count(out, "<gpxtpx:TrackPointExtension>"), 23)
+	testutil.AssertEqual(t, strings.Contains(out, `creator="Garmin Connect"`), true)
+	testutil.AssertEqual(t, strings.Contains(out, "<name>Kingston Sailing</name>"), true)
+}
+
+func Test_AnalyzeGPX(t *testing.T) {
+	tracks := AnalyzeGPX(testutil.ParseSample(t, testutil.Turn1), 20, Sailing, nil)
+	testutil.AssertEqual(t, len(tracks), 1)
+	testutil.AssertEqual(t, len(tracks[0].Segments), 2)
+	testutil.AssertEqual(t, tracks[0].Segments[0].Mode, Turning)
+	testutil.AssertEqual(t, tracks[0].Wind, UNK)
+}
@@ -0,0 +1,273 @@
+package track
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/tkrajina/gpxgo/gpx"
+)
+
+// Video describes a video file recorded during a track,
+// used to compute the video offset for the subtitles and chapters.
+type Video struct {
+	filename string
+	Created  time.Time     // creation time recorded in the video file, only time of day (year 0) if specified by hand
+	Duration time.Duration // duration of the video, zero if unknown
+	// Camera clock correction added to the creation time, e.g. -90s if the camera clock is 90s ahead.
+	Correction time.Duration
+	// Timezone of the camera clock, cameras often record local time as if it was UTC.
+	// Nil means the timezone of the track.
+	Timezone *time.Location
+	// GPS telemetry recorded by the camera (GoPro), used instead of the creation time if available.
+	telemetry *formats.Telemetry
+	// The video continues where the previous video of the session ended,
+	// e.g. a chapter of a GoPro recording or a video specified with duration only.
+	follows bool
+}
+
+// ReadVideo reads the creation time and duration from an MP4/MOV video file.
+func ReadVideo(fn string) (*Video, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	created, duration, err := formats.MovieHeader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	v := &Video{filename: filepath.Base(fn), Created: created, Duration: duration, Timezone: time.UTC}
+	if tm, err := formats.DecodeTelemetry(f); err == nil {
+		tm.Filename = v.filename
+		v.telemetry = tm
+	}
+	return v, nil
+}
+
+// ParseVideo reads the video file, or if there is no such file, parses a video specified by hand
+// as name=start, where start is the time of day of the camera clock, e.g. GX010042.MP4=15:04:05,
+// or as name=duration, e.g. GX010042.MP4=17m42s, for a video that continues where the previous one ended.
+func ParseVideo(spec string) (*Video, error) {
+	v, err := ReadVideo(spec)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return v, err
+	}
+	fn, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, err
+	}
+	v = &Video{filename: filepath.Base(fn), Timezone: time.UTC}
+	if v.Duration, err = time.ParseDuration(value); err == nil {
+		if v.Duration <= 0 {
+			return nil, fmt.Errorf("%s: video duration must be positive", spec)
+		}
+		v.follows = true
+		return v, nil
+	}
+	if v.Created, err = time.Parse(time.TimeOnly, value); err != nil {
+		return nil, fmt.Errorf("%s: expected video start time (15:04:05) or duration (17m42s)", spec)
+	}
+	return v, nil
+}
+
+// Name returns the file name of the video without the extension.
+func (v *Video) Name() string {
+	return strings.TrimSuffix(v.filename, filepath.Ext(v.filename))
+}
+
+// Start returns the corrected start time of the video for the track.
+func (v *Video) Start(t *Track) time.Time {
+	tz := v.Timezone
+	if tz == nil {
+		tz = t.Timezone()
+	}
+	c := v.Created
+	if c.Year() == 0 {
+		// only time of day is known, assume the day of the track
+		y, m, d := t.Start.In(tz).Date()
+		c = time.Date(y, m, d, c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), time.UTC)
+	}
+	start := time.Date(c.Year(), c.Month(), c.Day(), c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), tz)
+	return start.Add(v.Correction)
+}
+
+// Offset returns the video offset for the track (see -vo),
+// and whether the video overlaps the track at all.
+// If the video has GPS telemetry, the offset is derived from it and
+// the clock correction and timezone are ignored.
+func (v *Video) Offset(t *Track) (offset time.Duration, overlaps bool) {
+	if v.telemetry != nil {
+		return telemetryOffset(v.telemetry, t)
+	}
+	start := v.Start(t)
+	end := start.Add(v.Duration)
+	offset = t.Start.Sub(start)
+	return offset, start.Before(t.End) && end.After(t.Start)
+}
+
+func (v *Video) String() string {
+	s := v.filename
+	switch {
+	case v.follows:
+	case v.Created.Year() == 0:
+		s += " " + v.Created.Format(time.TimeOnly)
+	default:
+		s += " " + v.Created.Format(TimeFormat)
+	}
+	if v.Duration > 0 {
+		s += fmt.Sprintf(" (%s)", v.Duration)
+	}
+	if v.telemetry != nil {
+		s += " [GPS]"
+	}
+	return s
+}
+
+// Videos is a session of videos recorded during a track, e.g. the chapters of a long GoPro recording,
+// or several recordings from starting and stopping the camera.
+type Videos []*Video
+
+// goproName matches GoPro file names, GH/GX/GP followed by the chapter and recording numbers, e.g. GX020042.MP4.
+var goproName = regexp.MustCompile(`^G[HXP](\d\d)(\d{4})\.`)
+
+// goproChapter returns the recording and chapter numbers of a GoPro video file name.
+// The first chapter of older cameras is named GOPR0042.MP4.
+func goproChapter(fn string) (recording, chapter string, ok bool) {
+	if m := goproName.FindStringSubmatch(fn); m != nil {
+		return m[2], m[1], true
+	}
+	if strings.HasPrefix(fn, "GOPR") && len(fn) > 8 {
+		return fn[4:8], "00", true
+	}
+	return "", "", false
+}
+
+// ParseVideos parses the list of videos (see ParseVideo) in recording order.
+// Consecutive chapters of a GoPro recording without GPS telemetry continue where the previous chapter ended,
+// as they may all carry the creation time of the recording.
+func ParseVideos(specs []string) (vs Videos, err error) {
+	for _, spec := range specs {
+		v, err := ParseVideo(spec)
+		if err != nil {
+			return nil, err
+		}
+		if len(vs) > 0 && v.telemetry == nil && v.Created.Year() > 0 {
+			prev := vs[len(vs)-1]
+			pr, pc, pok := goproChapter(prev.filename)
+			r, c, ok := goproChapter(v.filename)
+			if ok && pok && r == pr && c > pc && prev.Duration > 0 {
+				v.follows = true
+			}
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+// VideoSpan places a video of a session on the track timeline.
+type VideoSpan struct {
+	Video    *Video
+	Offset   time.Duration // video offset for the track, see -vo
+	Duration time.Duration // length of the video, zero if unknown
+	Overlaps bool          // whether the video overlaps the track, false also if the video couldn't be placed
+}
+
+// Spans places the videos of the session on the track timeline.
+// Videos that follow the previous video start where it ended, if the first video follows
+// then @offset is its video offset (nil if unknown). A video of unknown duration ends where the next video starts.
+func (vs Videos) Spans(t *Track, offset *time.Duration) []VideoSpan {
+	spans := make([]VideoSpan, len(vs))
+	placed := make([]bool, len(vs))
+	for i, v := range vs {
+		spans[i] = VideoSpan{Video: v, Duration: v.Duration}
+		switch {
+		case !v.follows:
+			spans[i].Offset, _ = v.Offset(t)
+		case i == 0 && offset != nil:
+			spans[i].Offset = *offset
+		case i > 0 && placed[i-1] && spans[i-1].Duration > 0:
+			spans[i].Offset = spans[i-1].Offset - spans[i-1].Duration
+		default:
+			continue
+		}
+		placed[i] = true
+		if i > 0 && placed[i-1] && spans[i-1].Duration == 0 && spans[i].Offset < spans[i-1].Offset {
+			spans[i-1].Duration = spans[i-1].Offset - spans[i].Offset
+		}
+	}
+	for i := range spans {
+		if !placed[i] {
+			continue
+		}
+		// the track timeline relative to the start of the video
+		start, end := spans[i].Offset, spans[i].Offset+t.Duration
+		spans[i].Overlaps = end > 0 && (spans[i].Duration == 0 || start < spans[i].Duration)
+	}
+	return spans
+}
+
+// telemetryOffset returns the video offset for the track (see -vo), and whether the video overlaps the track.
+// The offset is derived from the camera GPS time and then refined by aligning
+// the camera GPS positions with the track positions to sub-second precision.
+func telemetryOffset(tm *formats.Telemetry, t *Track) (offset time.Duration, overlaps bool) {
+	start := tm.Start()
+	last := tm.Samples[len(tm.Samples)-1]
+	end := start.Add(last.VideoTime)
+	if !start.Before(t.End) || !end.After(t.Start) {
+		return t.Start.Sub(start), false
+	}
+	return t.Start.Sub(start.Add(telemetryAlignment(tm, t, 2*time.Second, 50*time.Millisecond))), true
+}
+
+// telemetryAlignment finds the shift of the camera GPS times within ±@limit (in @step increments)
+// that minimizes the mean squared distance between the camera positions and the track positions.
+func telemetryAlignment(tm *formats.Telemetry, t *Track, limit, step time.Duration) time.Duration {
+	points := t.GPXPoints()
+	best, bestCost := time.Duration(0), math.Inf(1)
+	for shift := -limit; shift <= limit; shift += step {
+		var cost float64
+		var n int
+		for _, s := range tm.Samples {
+			lat, lon, ok := Interpolate(points, s.Time.Add(shift))
+			if !ok {
+				continue
+			}
+			dLat := lat - s.Lat
+			dLon := (lon - s.Lon) * math.Cos(lat*math.Pi/180)
+			cost += dLat*dLat + dLon*dLon
+			n++
+		}
+		// Require meaningful overlap to avoid aligning on a handful of points.
+		if n < 10 {
+			continue
+		}
+		if cost /= float64(n); cost < bestCost {
+			best, bestCost = shift, cost
+		}
+	}
+	return best
+}
+
+// Interpolate returns the position at time ts interpolated between the surrounding points.
+func Interpolate(points []*gpx.GPXPoint, ts time.Time) (lat, lon float64, ok bool) {
+	i := sort.Search(len(points), func(i int) bool { return !points[i].Timestamp.Before(ts) })
+	if i == len(points) || (i == 0 && points[0].Timestamp.After(ts)) {
+		return 0, 0, false
+	}
+	next := points[i]
+	if i == 0 || next.Timestamp.Equal(ts) {
+		return next.Latitude, next.Longitude, true
+	}
+	prev := points[i-1]
+	frac := float64(ts.Sub(prev.Timestamp)) / float64(next.Timestamp.Sub(prev.Timestamp))
+	return prev.Latitude + (next.Latitude-prev.Latitude)*frac, prev.Longitude + (next.Longitude-prev.Longitude)*frac, true
+}
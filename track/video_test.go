@@ -0,0 +1,212 @@
+package track
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkobetic/gpx/formats"
+	"github.com/mkobetic/gpx/internal/testutil"
+)
+
+func Test_VideoOffset(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn1)
+	trk.gpxAnalyze(Sailing.Params())
+	// track is 2024-08-24 19:09:56 - 19:11:27 UTC, 15:09:56 EDT
+	for i, tt := range []struct {
+		created    time.Time
+		duration   time.Duration
+		correction time.Duration
+		timezone   *time.Location
+		offset     time.Duration
+		overlaps   bool
+	}{
+		{time.Date(2024, 8, 24, 19, 9, 0, 0, time.UTC), time.Minute, 0, time.UTC, 56 * time.Second, true},
+		{time.Date(2024, 8, 24, 19, 10, 0, 0, time.UTC), time.Minute, 0, time.UTC, -4 * time.Second, true},
+		{time.Date(2024, 8, 24, 19, 10, 0, 0, time.UTC), time.Minute, -30 * time.Second, time.UTC, 26 * time.Second, true},
+		// camera recording local time as UTC
+		{time.Date(2024, 8, 24, 15, 9, 0, 0, time.UTC), time.Minute, 0, nil, 56 * time.Second, true},
+		{time.Date(2024, 8, 24, 15, 9, 0, 0, time.UTC), time.Minute, 0, time.UTC, 4*time.Hour + 56*time.Second, false},
+		{time.Date(2024, 8, 24, 19, 12, 0, 0, time.UTC), time.Minute, 0, time.UTC, -124 * time.Second, false},
+		{time.Date(2024, 8, 24, 19, 8, 0, 0, time.UTC), time.Minute, 0, time.UTC, 116 * time.Second, false},
+	} {
+		v := &Video{Created: tt.created, Duration: tt.duration, Correction: tt.correction, Timezone: tt.timezone}
+		offset, overlaps := v.Offset(trk)
+		if offset != tt.offset || overlaps != tt.overlaps {
+			t.Errorf("%d: got %s %t exp %s %t", i, offset, overlaps, tt.offset, tt.overlaps)
+		}
+	}
+}
+
+func Test_VideoSpans(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn1)
+	trk.gpxAnalyze(Sailing.Params())
+	at := func(hms string) time.Time {
+		ts, err := time.Parse(time.TimeOnly, hms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2024, 8, 24, ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC)
+	}
+	offset := 10 * time.Second
+	for i, tt := range []struct {
+		videos Videos
+		offset *time.Duration
+		spans  []VideoSpan
+	}{
+		// two recordings, the second starting when the first one ends
+		{
+			Videos{
+				{Created: at("19:09:00"), Duration: time.Minute, Timezone: time.UTC},
+				{Created: at("19:10:30"), Duration: time.Minute, Timezone: time.UTC},
+			}, nil,
+			[]VideoSpan{
+				{Offset: 56 * time.Second, Duration: time.Minute, Overlaps: true},
+				{Offset: -34 * time.Second, Duration: time.Minute, Overlaps: true},
+			},
+		},
+		// chapters of a single recording
+		{
+			Videos{
+				{Created: at("19:09:00"), Duration: time.Minute, Timezone: time.UTC},
+				{Duration: time.Minute, follows: true},
+				{Duration: time.Minute, follows: true},
+				{Duration: time.Minute, follows: true},
+			}, nil,
+			[]VideoSpan{
+				{Offset: 56 * time.Second, Duration: time.Minute, Overlaps: true},
+				{Offset: -4 * time.Second, Duration: time.Minute, Overlaps: true},
+				{Offset: -64 * time.Second, Duration: time.Minute, Overlaps: true},
+				{Offset: -124 * time.Second, Duration: time.Minute, Overlaps: false},
+			},
+		},
+		// durations only, anchored by -vo
+		{
+			Videos{{Duration: time.Minute, follows: true}, {Duration: time.Minute, follows: true}}, &offset,
+			[]VideoSpan{
+				{Offset: 10 * time.Second, Duration: time.Minute, Overlaps: true},
+				{Offset: -50 * time.Second, Duration: time.Minute, Overlaps: true},
+			},
+		},
+		// durations only, without -vo
+		{
+			Videos{{Duration: time.Minute, follows: true}}, nil,
+			[]VideoSpan{{Duration: time.Minute}},
+		},
+		// start times only, the first video ends where the second starts
+		{
+			Videos{
+				{Created: at("19:09:00").AddDate(-2024, -7, -23), Timezone: time.UTC},
+				{Created: at("19:10:00").AddDate(-2024, -7, -23), Timezone: time.UTC},
+			}, nil,
+			[]VideoSpan{
+				{Offset: 56 * time.Second, Duration: time.Minute, Overlaps: true},
+				{Offset: -4 * time.Second, Overlaps: true},
+			},
+		},
+	} {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			spans := tt.videos.Spans(trk, tt.offset)
+			testutil.AssertEqual(t, len(spans), len(tt.spans))
+			for j := range spans {
+				spans[j].Video = nil
+				testutil.AssertEqual(t, spans[j], tt.spans[j])
+			}
+		})
+	}
+}
+
+func Test_ParseVideos(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2024, 8, 24, 19, 9, 0, 0, time.UTC)
+	for _, fn := range []string{"GX010042.MP4", "GX020042.MP4", "GX010043.MP4"} {
+		if err := os.WriteFile(filepath.Join(dir, fn), testutil.MP4Movie(0, created, 1000, 60000), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vs, err := ParseVideos([]string{
+		filepath.Join(dir, "GX010042.MP4"),
+		filepath.Join(dir, "GX020042.MP4"),
+		filepath.Join(dir, "GX010043.MP4"),
+		"GX020043.MP4=17m42s",
+		"DJI_0001.MP4=15:10:05",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, len(vs), 5)
+	for i, follows := range []bool{false, true, false, true, false} {
+		if vs[i].follows != follows {
+			t.Errorf("%s: follows %t", vs[i].filename, vs[i].follows)
+		}
+	}
+	testutil.AssertEqual(t, vs[1].String(), "GX020042.MP4 (1m0s)")
+	testutil.AssertEqual(t, vs[3].Duration, 17*time.Minute+42*time.Second)
+	testutil.AssertEqual(t, vs[4].Name(), "DJI_0001")
+	testutil.AssertEqual(t, vs[4].String(), "DJI_0001.MP4 15:10:05")
+	if _, err := ParseVideos([]string{"GX010042.MP4=soon"}); err == nil {
+		t.Error("expected error for invalid video specification")
+	}
+	if _, err := ParseVideos([]string{filepath.Join(dir, "missing.MP4")}); err == nil {
+		t.Error("expected error for missing video file")
+	}
+}
+
+func Test_TelemetryOffset(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn1)
+	trk.gpxAnalyze(Sailing.Params())
+	start := time.Date(2024, 8, 24, 19, 9, 50, 300e6, time.UTC)
+	fn := filepath.Join(t.TempDir(), "GX010042.MP4")
+	if err := os.WriteFile(fn, testutil.GoProVideo(trk.GPXPoints(), start, 100, 400*time.Millisecond), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tm, err := formats.ReadTelemetry(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, overlaps := telemetryOffset(tm, trk)
+	testutil.AssertEqual(t, overlaps, true)
+	if diff := offset - 5700*time.Millisecond; diff < -50*time.Millisecond || diff > 50*time.Millisecond {
+		t.Errorf("offset %s, expected 5.7s", offset)
+	}
+	// the video is well before the track
+	if err := os.WriteFile(fn, testutil.GoProVideo(trk.GPXPoints(), start, 100, -time.Hour), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tm, err = formats.ReadTelemetry(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, overlaps = telemetryOffset(tm, trk)
+	testutil.AssertEqual(t, overlaps, false)
+}
+
+func Test_TelemetryVideo(t *testing.T) {
+	trk := readTrackSample(t, testutil.Turn1)
+	trk.gpxAnalyze(Sailing.Params())
+	start := time.Date(2024, 8, 24, 19, 9, 50, 300e6, time.UTC)
+	fn := filepath.Join(t.TempDir(), "GX010042.MP4")
+	if err := os.WriteFile(fn, testutil.GoProVideo(trk.GPXPoints(), start, 100, 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := formats.ReadSource(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := SourceSegments(src)
+	testutil.AssertEqual(t, len(ss), 1)
+	tracks := BuildTracks(ss, time.Hour)
+	tracks[0].gpxAnalyze(Sailing.Params())
+	testutil.AssertEqual(t, tracks[0].Start, time.Date(2024, 8, 24, 19, 9, 56, 0, time.UTC))
+
+	v, err := ReadVideo(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, v.Duration, 100*time.Second)
+	offset, overlaps := v.Offset(trk)
+	testutil.AssertEqual(t, overlaps, true)
+	testutil.AssertEqual(t, offset.Round(100*time.Millisecond), 5700*time.Millisecond)
+}